@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// batchOutput is the name of the file generated in each package directory
+// when the types are read from a file.
+const batchOutput = "deepcopy_gen.go"
+
+// batchEntry is a single line of a types file, or a single qualified -type
+// flag, in which case it has no line.
+type batchEntry struct {
+	line    int
+	pattern string
+	kind    string
+	skips   skips
+}
+
+func (e batchEntry) String() string {
+	if e.line == 0 {
+		return e.pattern + " " + e.kind
+	}
+
+	return fmt.Sprintf("line %d: %s %s", e.line, e.pattern, e.kind)
+}
+
+// generatedFile is the content generated for a single package. Its rel is the
+// directory of the package relative to the root of its module, or its import
+// path outside of modules, as in GOPATH mode.
+type generatedFile struct {
+	path    string
+	pkgPath string
+	rel     string
+	content []byte
+}
+
+// splitQualifiedType splits a -type value of the form pkg/path.Type into the
+// package pattern and the type name. Unqualified type names are returned with
+// an empty pattern.
+func splitQualifiedType(v string) (pattern, kind string) {
+	i := strings.LastIndex(v, ".")
+	if i == -1 {
+		return "", v
+	}
+
+	return v[:i], v[i+1:]
+}
+
+// qualifiedEntries turns the -type and -skip flags into batch entries, one per
+// type. Qualified types use their own package, while unqualified ones use the
+// package at path, which is then required.
+func qualifiedEntries(types typesVal, skipsList skipsVal, path string) ([]batchEntry, error) {
+	entries := make([]batchEntry, 0, len(types))
+	for i, v := range types {
+		pattern, kind := splitQualifiedType(v)
+		if pattern == "" {
+			if path == "" {
+				return nil, fmt.Errorf("type %q is not qualified, and no package path given", v)
+			}
+			pattern = path
+		}
+		if kind == "" {
+			return nil, fmt.Errorf("type %q has no type name", v)
+		}
+
+		entry := batchEntry{pattern: pattern, kind: kind, skips: skips{}}
+		if i < len(skipsList) {
+			entry.skips = skipsList[i]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// hasQualifiedType reports whether any of the types is qualified with its
+// package.
+func hasQualifiedType(types typesVal) bool {
+	for _, v := range types {
+		if pattern, _ := splitQualifiedType(v); pattern != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outputPath returns the path the file is written to. Without a directory, it
+// is written next to the package sources. Otherwise, it is written under dir,
+// in a tree mirroring the directories of the module, so that dir can be the
+// root of the module, or of a copy of it, in which the methods compile.
+func (f generatedFile) outputPath(dir string) string {
+	if dir == "" {
+		return f.path
+	}
+
+	return filepath.Join(dir, f.rel, batchOutput)
+}
+
+// moduleRelative returns the directory of p relative to the root of its
+// module, or its import path when it isn't part of a module.
+func moduleRelative(p *packages.Package) string {
+	dir := filepath.Dir(p.GoFiles[0])
+	if p.Module != nil && p.Module.Dir != "" {
+		if rel, err := filepath.Rel(evalSymlinks(p.Module.Dir), evalSymlinks(dir)); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return filepath.FromSlash(p.PkgPath)
+}
+
+// parseTypesFile parses a types file. Each line has the form
+//
+//	pkgpattern Type [skip selectors...]
+//
+// where the optional skip selectors are separated by spaces or commas. Blank
+// lines and lines starting with # are ignored.
+func parseTypesFile(r io.Reader) ([]batchEntry, error) {
+	var entries []batchEntry
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected a package pattern and a type", line)
+		}
+
+		entry := batchEntry{line: line, pattern: fields[0], kind: fields[1], skips: skips{}}
+		for _, f := range fields[2:] {
+			for _, sel := range strings.Split(f, ",") {
+				if sel != "" {
+					entry.skips[sel] = struct{}{}
+				}
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// generateBatch generates a file per package for the given entries. Each
+// package is loaded only once, no matter how many of its types are listed.
+// Errors are collected per entry and the failing entries are left out of the
+// result, unless the app is strict, in which case the first error stops the
+// whole batch.
+func (a *app) generateBatch(entries []batchEntry) ([]generatedFile, []error) {
+	var (
+		patterns []string
+		grouped  = map[string][]batchEntry{}
+		errs     []error
+	)
+	for _, e := range entries {
+		if _, ok := grouped[e.pattern]; !ok {
+			patterns = append(patterns, e.pattern)
+		}
+		grouped[e.pattern] = append(grouped[e.pattern], e)
+	}
+
+	var files []generatedFile
+	for _, pattern := range patterns {
+		fail := func(e batchEntry, err error) bool {
+			errs = append(errs, fmt.Errorf("%s: %w", e, err))
+			return a.strict
+		}
+
+		p, err := a.loadPackage(pattern)
+		if err == nil && len(p.GoFiles) == 0 {
+			err = resolveError{fmt.Errorf("package %s has no Go files", pattern)}
+		}
+		if err != nil {
+			for _, e := range grouped[pattern] {
+				if fail(e, err) {
+					return nil, errs
+				}
+			}
+			continue
+		}
+
+		var (
+			types typesVal
+			skips skipsVal
+		)
+		for _, e := range grouped[pattern] {
+			if _, err := locateType(p.Name, e.kind, p); err != nil {
+				if fail(e, resolveError{err}) {
+					return nil, errs
+				}
+				continue
+			}
+
+			types = append(types, e.kind)
+			skips = append(skips, e.skips)
+		}
+		if len(types) == 0 {
+			continue
+		}
+
+		b, err := a.generate(p, types, skips)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pattern, err))
+			if a.strict {
+				return nil, errs
+			}
+			if b == nil {
+				continue
+			}
+		}
+
+		files = append(files, generatedFile{
+			path:    filepath.Join(filepath.Dir(p.GoFiles[0]), batchOutput),
+			pkgPath: p.PkgPath,
+			rel:     moduleRelative(p),
+			content: b,
+		})
+	}
+
+	return files, errs
+}
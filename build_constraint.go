@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// knownOS and knownArch are the GOOS and GOARCH values that constrain the
+// files whose names end with them, as in config_linux.go, as in go/build.
+var (
+	knownOS   = setOf("aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js", "linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos")
+	knownArch = setOf("386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64", "mips", "mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le", "riscv", "riscv64", "s390", "s390x", "sparc", "sparc64", "wasm")
+)
+
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
+
+// typesConstraint returns the build constraint of the files declaring the
+// types of package p, which the generated file must share, as its methods
+// may refer to types that only exist under it. Types declared under different
+// constraints can't be generated in the same file.
+func typesConstraint(p *packages.Package, types []string) (string, error) {
+	if p.Types == nil {
+		return "", nil
+	}
+
+	var first, expr string
+	for _, kind := range types {
+		obj := p.Types.Scope().Lookup(kind)
+		if obj == nil {
+			continue
+		}
+
+		e := fileConstraint(p, obj.Pos())
+		if first == "" {
+			first, expr = kind, e
+			continue
+		}
+		if e != expr {
+			return "", fmt.Errorf("%s is declared under %s, and %s under %s; generate them in separate files", first, describeConstraint(expr), kind, describeConstraint(e))
+		}
+	}
+
+	return expr, nil
+}
+
+func describeConstraint(expr string) string {
+	if expr == "" {
+		return "no build constraint"
+	}
+
+	return fmt.Sprintf("the build constraint %q", expr)
+}
+
+// fileConstraint returns the build constraint of the file at pos: the
+// expression of its //go:build line, or of its // +build lines, along with
+// the GOOS and GOARCH of its name.
+func fileConstraint(p *packages.Package, pos token.Pos) string {
+	tf := p.Fset.File(pos)
+	if tf == nil {
+		return ""
+	}
+
+	var exprs []constraint.Expr
+	for _, f := range p.Syntax {
+		if p.Fset.File(f.Package) == tf {
+			exprs = append(exprs, commentConstraint(f)...)
+			break
+		}
+	}
+	if e := nameConstraint(tf.Name()); e != nil {
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 0 {
+		return ""
+	}
+
+	expr := exprs[0]
+	for _, e := range exprs[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: e}
+	}
+
+	return expr.String()
+}
+
+// commentConstraint returns the expression of the //go:build line of f, or
+// those of its // +build lines when it has none.
+func commentConstraint(f *ast.File) []constraint.Expr {
+	var plusBuild []constraint.Expr
+	for _, group := range f.Comments {
+		if group.Pos() >= f.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				if e, err := constraint.Parse(c.Text); err == nil {
+					return []constraint.Expr{e}
+				}
+			case constraint.IsPlusBuild(c.Text):
+				if e, err := constraint.Parse(c.Text); err == nil {
+					plusBuild = append(plusBuild, e)
+				}
+			}
+		}
+	}
+
+	return plusBuild
+}
+
+// nameConstraint returns the constraint of the GOOS and GOARCH suffixes of
+// the name of a file, as in config_linux_amd64.go, or nil.
+func nameConstraint(name string) constraint.Expr {
+	name = strings.TrimSuffix(filepath.Base(name), ".go")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n > 0 && l[n-1] == "test" {
+		l = l[:n-1]
+	}
+
+	n := len(l)
+	switch {
+	case n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]]:
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: l[n-2]}, Y: &constraint.TagExpr{Tag: l[n-1]}}
+	case n >= 1 && (knownOS[l[n-1]] || knownArch[l[n-1]]):
+		return &constraint.TagExpr{Tag: l[n-1]}
+	}
+
+	return nil
+}
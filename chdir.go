@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// chdirVal changes the working directory as soon as -C is parsed, so that the
+// paths of the flags that follow, such as -o, which is opened when parsed, are
+// resolved from it, as the package paths are.
+type chdirVal string
+
+func (f *chdirVal) String() string {
+	return string(*f)
+}
+
+func (f *chdirVal) Set(v string) error {
+	if err := os.Chdir(v); err != nil {
+		return err
+	}
+	*f = chdirVal(v)
+
+	return nil
+}
+
+// checkChdirFirst checks that -C, if given, is the first of the command line
+// args, as with the go command.
+func checkChdirFirst(args []string) error {
+	for i, arg := range args {
+		if arg == "--" {
+			return nil
+		}
+
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if i > 0 && strings.HasPrefix(arg, "-") && name == "C" {
+			return errors.New("-C must be the first flag")
+		}
+	}
+
+	return nil
+}
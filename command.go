@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loggingFlags don't change the generated code, they are left out of the
+// canonical command.
+var loggingFlags = map[string]bool{"v": true, "q": true, "quiet": true, "log-level": true}
+
+// pathFlags hold file system paths, which are made relative to the module
+// root in the canonical command.
+var pathFlags = map[string]bool{"o": true, "dir": true, "types-file": true, "src": true, "input-file": true, "overlay": true}
+
+type commandFlag struct {
+	name, value string
+	isBool      bool
+}
+
+// canonicalCommand renders the command line args of deep-copy so that it is
+// the same on every machine: the flags are sorted by name, keeping the order
+// of repeated ones, the logging flags are left out, and the paths are made
+// relative to root. The directory of -C is rendered as chdir, as the other
+// paths are resolved from it.
+func canonicalCommand(args []string, fs *flag.FlagSet, root, chdir string) string {
+	var (
+		flags      []commandFlag
+		positional []string
+	)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			if arg == "--" {
+				i++
+			}
+			positional = args[i:]
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := commandFlag{name: name, value: value}
+		if fl := fs.Lookup(name); fl != nil {
+			bf, ok := fl.Value.(interface{ IsBoolFlag() bool })
+			f.isBool = ok && bf.IsBoolFlag()
+		}
+		if f.isBool && !hasValue {
+			f.value = "true"
+		} else if !f.isBool && !hasValue && i+1 < len(args) {
+			i++
+			f.value = args[i]
+		}
+
+		if loggingFlags[name] {
+			continue
+		}
+		if name == "C" {
+			f.value = chdir
+		} else if pathFlags[name] {
+			f.value = relativePath(f.value, root)
+		}
+		flags = append(flags, f)
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flags[i].name < flags[j].name
+	})
+
+	parts := []string{"deep-copy"}
+	for _, f := range flags {
+		if f.isBool && f.value == "true" {
+			parts = append(parts, "-"+f.name)
+			continue
+		}
+		parts = append(parts, "-"+f.name+"="+quoteArg(f.value))
+	}
+	for _, arg := range positional {
+		// Package patterns are either paths or import paths.
+		if strings.HasPrefix(arg, ".") || filepath.IsAbs(arg) {
+			arg = relativePath(arg, root)
+		}
+		parts = append(parts, quoteArg(arg))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// relativePath returns path relative to root, in slash separated form and
+// starting with ./, unless it is outside of root.
+func relativePath(path, root string) string {
+	if path == "" || path == "-" {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	if rel == "." {
+		return rel
+	}
+
+	return "./" + filepath.ToSlash(rel)
+}
+
+// quoteArg quotes the argument if it isn't made of plain characters only, so
+// that the command fits on the line of a comment.
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$*?[]{}|&;<>()`!#~") {
+		return strconv.Quote(arg)
+	}
+
+	return arg
+}
+
+// moduleRoot returns the closest directory holding a go.mod file, from dir up,
+// or dir if there is none.
+func moduleRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// optionsHash returns a short hash of the command, to tell apart at a glance
+// files generated with different options.
+func optionsHash(command string) string {
+	sum := sha256.Sum256([]byte(command))
+
+	return hex.EncodeToString(sum[:6])
+}
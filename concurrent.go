@@ -0,0 +1,66 @@
+package main
+
+import (
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// generateFuncsConcurrently generates the method of each type in its own
+// goroutine, with imports of its own, which are merged once all of them are
+// done. It returns the same results as generateFuncs.
+func (a *app) generateFuncsConcurrently(p *packages.Package, objs []object, objSkip []map[string]struct{}) ([][]byte, map[string]string, int, error) {
+	type result struct {
+		fn       []byte
+		imports  map[string]string
+		warnings []warning
+		err      error
+	}
+
+	results := make([]result, len(objs))
+
+	var wg sync.WaitGroup
+	for i, obj := range objs {
+		wg.Add(1)
+		go func(i int, obj object) {
+			defer wg.Done()
+
+			// Each type is generated with a copy of the app, so that the
+			// stats and warnings of the types don't mix.
+			c := *a
+			c.warnings = nil
+			imports := a.newImports(p)
+
+			fn, err := c.generateFunc(p, obj, imports, objSkip[i], objs)
+			results[i] = result{fn: fn, imports: imports, warnings: c.warnings, err: err}
+		}(i, obj)
+	}
+	wg.Wait()
+
+	fns := make([][]byte, 0, len(objs))
+	imports := map[string]string{}
+	for i, r := range results {
+		// The progress is reported in the order of the types, as when
+		// they are generated one after the other.
+		a.logger.Progressf("generating %s (%d/%d)", objs[i].Obj().Name(), i+1, len(objs))
+		if r.err != nil {
+			return nil, nil, i, r.err
+		}
+
+		for name, path := range r.imports {
+			if existing, ok := imports[name]; ok && existing != path {
+				// Packages imported under the same name by different
+				// types are only aliased when sharing the imports.
+				a.logger.Infof("import %s is ambiguous between types, generating them one after the other", name)
+				a.warnings = nil
+				return a.generateFuncs(p, objs, objSkip)
+			}
+			imports[name] = path
+		}
+
+		fns = append(fns, r.fn)
+		a.warnings = append(a.warnings, r.warnings...)
+	}
+
+	return fns, imports, -1, nil
+}
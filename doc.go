@@ -15,4 +15,8 @@
 // members, or map members. To achieve that, selectors can be specified in the
 // optional comma-separated --skip flag. Multiple --skip flags can be
 // specified, to match the number of --type flags.
+//
+// Tooling that only needs to know what would be generated can pass the
+// --machine-output flag, which prints a JSON description of each type to
+// STDOUT instead of writing Go source.
 package main
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+)
+
+// The exit codes of the tool, so that scripts can tell a misuse of the flags
+// apart from types that can't be found and from output that can't be written.
+const (
+	// exitUsage reports invalid flags or arguments.
+	exitUsage = 1
+	// exitResolve reports a package, type or selector that can't be
+	// resolved.
+	exitResolve = 2
+	// exitGenerate reports a type whose method can't be generated.
+	exitGenerate = 3
+	// exitWrite reports a result that can't be written.
+	exitWrite = 4
+)
+
+// resolveError is the error of resolving a package, a type or a selector
+// given on the command line.
+type resolveError struct {
+	err error
+}
+
+func (e resolveError) Error() string {
+	return e.err.Error()
+}
+
+func (e resolveError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code reporting err, which is exitResolve when it
+// or all of the type errors it holds are resolution errors.
+func exitCode(err error) int {
+	var errs typeErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			if exitCode(e) != exitResolve {
+				return exitGenerate
+			}
+		}
+
+		return exitResolve
+	}
+
+	if errors.As(err, &resolveError{}) {
+		return exitResolve
+	}
+
+	return exitGenerate
+}
+
+// batchExitCode returns the exit code reporting the errors of a batch.
+func batchExitCode(errs []error) int {
+	for _, err := range errs {
+		if exitCode(err) != exitResolve {
+			return exitGenerate
+		}
+	}
+
+	return exitResolve
+}
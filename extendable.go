@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// extendable returns an error when methods can't be declared on the types of
+// p, as its files are part of the standard library, or in a read-only
+// directory such as the module cache: the generated methods could never be
+// compiled into it. Standalone functions can be generated for them instead.
+func extendable(p *packages.Package) error {
+	if len(p.GoFiles) == 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(p.GoFiles[0])
+	if root := build.Default.GOROOT; root != "" && isWithin(dir, filepath.Join(root, "src")) {
+		return fmt.Errorf("%s is part of the standard library, methods can't be added to its types; generate standalone functions in your package with -func -package name", p.PkgPath)
+	}
+	if fi, err := os.Stat(dir); err == nil && fi.Mode().Perm()&0222 == 0 {
+		return fmt.Errorf("%s is in the read-only directory %s, such as the module cache, methods can't be added to its types; generate standalone functions in your package with -func -package name", p.PkgPath, dir)
+	}
+
+	return nil
+}
+
+// outputInPackage returns an error unless the output file is in the directory
+// of p, as methods only compile into the package of their type.
+func outputInPackage(p *packages.Package, output string) error {
+	if len(p.GoFiles) == 0 {
+		return nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return err
+	}
+	if sameDir(dir, filepath.Dir(p.GoFiles[0])) {
+		return nil
+	}
+
+	return fmt.Errorf("%s is outside of the directory of %s, the methods wouldn't compile into another package; write it next to the package, or generate standalone functions with -func -package name", output, p.PkgPath)
+}
+
+// isWithin reports whether dir is root or one of its subdirectories.
+func isWithin(dir, root string) bool {
+	rel, err := filepath.Rel(evalSymlinks(root), evalSymlinks(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// sameDir reports whether the directories a and b are the same, once their
+// symbolic links are resolved.
+func sameDir(a, b string) bool {
+	return evalSymlinks(a) == evalSymlinks(b)
+}
+
+// evalSymlinks returns dir with its symbolic links resolved, or dir as is
+// when they can't be, as when it doesn't exist yet.
+func evalSymlinks(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+
+	return filepath.Clean(dir)
+}
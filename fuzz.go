@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// fuzzFileName returns the name of the fuzz test file that accompanies the
+// generated output file.
+func fuzzFileName(output string) string {
+	return strings.TrimSuffix(output, ".go") + "_fuzz_test.go"
+}
+
+// generateFuzz generates a test file with a native fuzz test per type. Each
+// fuzz test fills the exported scalar, slice and map fields of a value from
+// the fuzzer input, deep copies it, mutates the copy and asserts that the
+// original did not change.
+func (a *app) generateFuzz(p *packages.Package, objs []object) ([]byte, error) {
+	imports := map[string]string{
+		"fmt":     "fmt",
+		"testing": "testing",
+	}
+
+	// The tests are sorted by type name, as the methods are.
+	objs = append([]object(nil), objs...)
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].Obj().Name() < objs[j].Obj().Name()
+	})
+
+	var fns [][]byte
+	for _, obj := range objs {
+		fns = append(fns, a.fuzzFunc(p, obj, imports))
+	}
+	fns = append(fns, []byte(fuzzDataSource))
+
+	return a.generateFile(p, objectNames(objs), imports, fns)
+}
+
+func (a *app) fuzzFunc(p *packages.Package, obj object, imports map[string]string) []byte {
+	var fill, mutate bytes.Buffer
+
+	if s, ok := obj.Underlying().(*types.Struct); ok {
+		for i := 0; i < s.NumFields(); i++ {
+			field := s.Field(i)
+			if !field.Exported() {
+				continue
+			}
+
+			fuzzField("o."+field.Name(), "cp."+field.Name(), field.Type(), p.Name, imports, &fill, &mutate)
+		}
+	}
+
+	kind := obj.Obj().Name()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `// Fuzz%sDeepCopy asserts that mutating a deep copy of %s does not
+// change the original value.
+func Fuzz%sDeepCopy(f *testing.F) {
+	f.Add([]byte("deep-copy"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var o %s
+		d := deepCopyFuzzData(data)
+		_ = d
+`, kind, kind, kind, kind)
+	fill.WriteTo(&buf)
+	fmt.Fprintf(&buf, `before := fmt.Sprintf("%%#v", o)
+cp := o.%s()
+`, a.methodNameOrDefault(obj))
+	mutate.WriteTo(&buf)
+	buf.WriteString(`if after := fmt.Sprintf("%#v", o); after != before {
+	t.Fatalf("mutating the copy changed the original:\nbefore: %s\nafter:  %s", before, after)
+}
+})
+}`)
+
+	return buf.Bytes()
+}
+
+// fuzzField writes the code filling the field from the fuzzer input, and the
+// code mutating the copied field. Only basic values, and slices and maps of
+// basic values are supported; other fields are left zero.
+func fuzzField(source, sink string, t types.Type, x string, imports map[string]string, fill, mutate *bytes.Buffer) {
+	if v, ok := fuzzValue(t, x, imports); ok {
+		fmt.Fprintf(fill, "%s = %s\n", source, v)
+		fmt.Fprintf(mutate, "%s = %s\n", sink, fuzzMutation(t, sink))
+		return
+	}
+
+	switch v := t.Underlying().(type) {
+	case *types.Slice:
+		elem, ok := fuzzValue(v.Elem(), x, imports)
+		if !ok {
+			return
+		}
+
+		fmt.Fprintf(fill, `%s = make(%s, d.nextLen())
+for i := range %s {
+	%s[i] = %s
+}
+`, source, getElemType(t, x, imports), source, source, elem)
+		fmt.Fprintf(mutate, `for i := range %s {
+	%s[i] = %s
+}
+`, sink, sink, fuzzMutation(v.Elem(), sink+"[i]"))
+	case *types.Map:
+		key, ok := fuzzValue(v.Key(), x, imports)
+		if !ok {
+			return
+		}
+		elem, ok := fuzzValue(v.Elem(), x, imports)
+		if !ok {
+			return
+		}
+
+		fmt.Fprintf(fill, `%s = make(%s)
+for n := d.nextLen(); n > 0; n-- {
+	%s[%s] = %s
+}
+`, source, getElemType(t, x, imports), source, key, elem)
+		fmt.Fprintf(mutate, `for k := range %s {
+	%s[k] = %s
+}
+`, sink, sink, fuzzMutation(v.Elem(), sink+"[k]"))
+	}
+}
+
+// fuzzValue returns an expression decoding a value of the basic type t from
+// the fuzzer input.
+func fuzzValue(t types.Type, x string, imports map[string]string) (string, bool) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+
+	var next, kind string
+	switch info := b.Info(); {
+	case info&types.IsString != 0:
+		next, kind = "d.nextString()", "string"
+	case info&types.IsBoolean != 0:
+		next, kind = "d.nextBool()", "bool"
+	case info&types.IsNumeric != 0 && info&types.IsComplex == 0:
+		next, kind = "d.nextInt()", "int"
+	default:
+		return "", false
+	}
+
+	if name := getElemType(t, x, imports); name != kind {
+		return fmt.Sprintf("%s(%s)", name, next), true
+	}
+
+	return next, true
+}
+
+// fuzzMutation returns an expression producing a value of the basic type t
+// that differs from expr.
+func fuzzMutation(t types.Type, expr string) string {
+	info := t.Underlying().(*types.Basic).Info()
+	switch {
+	case info&types.IsString != 0:
+		return expr + ` + "~"`
+	case info&types.IsBoolean != 0:
+		return "!" + expr
+	default:
+		return expr + " + 1"
+	}
+}
+
+const fuzzDataSource = `// deepCopyFuzzData hands out values decoded from the fuzzer input.
+type deepCopyFuzzData []byte
+
+func (d *deepCopyFuzzData) nextByte() byte {
+	if len(*d) == 0 {
+		return 0
+	}
+	b := (*d)[0]
+	*d = (*d)[1:]
+	return b
+}
+
+func (d *deepCopyFuzzData) nextInt() int {
+	return int(int8(d.nextByte()))
+}
+
+func (d *deepCopyFuzzData) nextBool() bool {
+	return d.nextByte()&1 == 1
+}
+
+func (d *deepCopyFuzzData) nextLen() int {
+	return int(d.nextByte() % 8)
+}
+
+func (d *deepCopyFuzzData) nextString() string {
+	n := d.nextLen()
+	if n > len(*d) {
+		n = len(*d)
+	}
+	s := string((*d)[:n])
+	*d = (*d)[n:]
+	return s
+}`
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// typeParamNames returns the type parameters of the generic type t, as in
+// [K, V], or "" if t isn't generic.
+func typeParamNames(t types.Type) string {
+	n, ok := t.(*types.Named)
+	if !ok || n.TypeParams().Len() == 0 {
+		return ""
+	}
+
+	names := make([]string, n.TypeParams().Len())
+	for i := range names {
+		names[i] = n.TypeParams().At(i).Obj().Name()
+	}
+
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// typeParamDecls returns the declaration of the type parameters of the
+// generic type t along with their constraints, as in [K comparable, V any],
+// for the functions generated with -func. It is "" if t isn't generic.
+func typeParamDecls(t types.Type, x string, imports map[string]string) string {
+	n, ok := t.(*types.Named)
+	if !ok || n.TypeParams().Len() == 0 {
+		return ""
+	}
+
+	decls := make([]string, n.TypeParams().Len())
+	for i := range decls {
+		tp := n.TypeParams().At(i)
+		decls[i] = tp.Obj().Name() + " " + types.TypeString(tp.Constraint(), qualifier(x, imports))
+	}
+
+	return "[" + strings.Join(decls, ", ") + "]"
+}
+
+// copyTypeParam copies a value of the type parameter tp, whose type is only
+// known when the generic type is instantiated. The value is deep copied by
+// the method of its constraint, such as DeepCopy() T, and shallow copied
+// otherwise.
+func (a *app) copyTypeParam(source, sink, sel string, tp *types.TypeParam, w io.Writer) {
+	if method := a.constraintDeepCopy(tp); method != "" {
+		fmt.Fprintf(w, "%s = %s.%s()\n", sink, source, method)
+		return
+	}
+
+	a.comment(w, sel, "type parameter %s shallow copied", tp.Obj().Name())
+}
+
+// constraintDeepCopy returns the name of the method of the constraint of tp
+// that returns a copy of the value as a tp, or "" if there is none.
+func (a *app) constraintDeepCopy(tp *types.TypeParam) string {
+	iface, ok := tp.Constraint().Underlying().(*types.Interface)
+	if !ok {
+		return ""
+	}
+
+	for _, name := range a.reuseMethodNames() {
+		for i := 0; i < iface.NumMethods(); i++ {
+			m := iface.Method(i)
+			sig := m.Type().(*types.Signature)
+			if m.Name() == name && sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), tp) {
+				return name
+			}
+		}
+	}
+
+	return ""
+}
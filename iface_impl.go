@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// ifaceImpls are the concrete types registered for an interface with
+// -iface-impl, whose dynamic values are deep copied through a type switch.
+type ifaceImpls struct {
+	iface string
+	impls []string
+}
+
+type ifaceImplsVal []ifaceImpls
+
+func (f *ifaceImplsVal) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, i := range *f {
+		parts = append(parts, i.iface+"="+strings.Join(i.impls, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (f *ifaceImplsVal) Set(v string) error {
+	iface, impls, ok := strings.Cut(v, "=")
+	if !ok || iface == "" || impls == "" {
+		return errors.New("expected Interface=Type[,Type...]")
+	}
+
+	*f = append(*f, ifaceImpls{iface: iface, impls: strings.Split(impls, ",")})
+
+	return nil
+}
+
+// registeredImpls returns the concrete types registered for the interface t,
+// named either by its name or qualified as pkg/path.Interface. The types are
+// declared in the package of the interface, and are used as pointers when
+// only their pointer implements it.
+func (a *app) registeredImpls(t types.Type) ([]types.Type, error) {
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil || len(a.ifaceImpls) == 0 {
+		return nil, nil
+	}
+
+	iface, _ := n.Underlying().(*types.Interface)
+	pkg := n.Obj().Pkg()
+
+	var impls []types.Type
+	for _, r := range a.ifaceImpls {
+		if r.iface != n.Obj().Name() && r.iface != pkg.Path()+"."+n.Obj().Name() {
+			continue
+		}
+
+		for _, name := range r.impls {
+			tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+			if !ok {
+				return nil, resolveError{fmt.Errorf("-iface-impl %s: no type %s in %s", r.iface, name, pkg.Path())}
+			}
+
+			var impl types.Type = tn.Type()
+			switch {
+			case types.IsInterface(impl):
+				return nil, fmt.Errorf("-iface-impl %s: %s is not a concrete type", r.iface, name)
+			case types.Implements(impl, iface):
+			case types.Implements(types.NewPointer(impl), iface):
+				impl = types.NewPointer(impl)
+			default:
+				return nil, fmt.Errorf("-iface-impl %s: %s doesn't implement %s", r.iface, name, n.Obj().Name())
+			}
+			impls = append(impls, impl)
+		}
+	}
+
+	return impls, nil
+}
+
+// copyImpls writes a type switch deep copying the dynamic values of source of
+// the registered concrete types. The other values are shared, or fail the copy
+// with -with-error.
+func (a *app) copyImpls(source, sink, sel, x string, impls []types.Type, w io.Writer, imports map[string]string, skips skips, generating []object, depth int) error {
+	val := a.tempName("c", depth)
+
+	fmt.Fprintf(w, "if %s != nil {\nswitch %s := %s.(type) {\n", source, val, source)
+	for _, impl := range impls {
+		kind := getElemType(impl, x, imports)
+		fmt.Fprintf(w, "case %s:\n", kind)
+
+		var b bytes.Buffer
+		copySink := selToIdent(sink) + "_" + selToIdent(kind)
+		if err := a.walkType(val, copySink, sel, x, impl, &b, imports, skips, generating, depth); err != nil {
+			return err
+		}
+
+		if !hasCode(b.Bytes()) {
+			fmt.Fprintf(w, "%s = %s\n", sink, val)
+			continue
+		}
+		fmt.Fprintf(w, "%s := %s\n%s%s = %s\n", copySink, val, b.String(), sink, copySink)
+	}
+
+	fmt.Fprintf(w, "default:\n")
+	if a.withError {
+		a.returnError(w, x, imports, sel+": %T can't be deep copied", source)
+	} else {
+		a.comment(w, sel, "interface value shared, unless of a type registered with -iface-impl")
+		fmt.Fprintf(w, "%s = %s\n", sink, source)
+	}
+	fmt.Fprintf(w, "}\n}\n")
+
+	return nil
+}
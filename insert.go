@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// The markers delimiting the generated region of a file with -insert-markers.
+const (
+	beginMarker = "// deep-copy:begin"
+	endMarker   = "// deep-copy:end"
+)
+
+// insertGenerated splices the declarations of the generated file into the
+// existing file, replacing the region between the markers, so that the code
+// around it is preserved. Without markers, the region is appended to the
+// existing file, or written after the preamble of the generated file if the
+// existing one is empty. The imports of the generated file are added to the
+// existing ones.
+func insertGenerated(existing, generated []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	gen, err := parser.ParseFile(fset, "", generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated file: %v", err)
+	}
+
+	offset := len(generated)
+	for _, decl := range gen.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			continue
+		}
+
+		pos := decl.Pos()
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Doc != nil {
+			pos = d.Doc.Pos()
+		}
+		offset = fset.Position(pos).Offset
+		break
+	}
+
+	var region bytes.Buffer
+	region.WriteString(beginMarker + "\n\n")
+	region.Write(bytes.TrimSpace(generated[offset:]))
+	region.WriteString("\n\n" + endMarker + "\n")
+
+	if len(bytes.TrimSpace(existing)) == 0 {
+		return format.Source(append(generated[:offset:offset], region.Bytes()...))
+	}
+
+	var src []byte
+	begin, end := bytes.Index(existing, []byte(beginMarker)), bytes.Index(existing, []byte(endMarker))
+	switch {
+	case begin == -1 && end == -1:
+		src = append(bytes.TrimRight(existing, "\n"), "\n\n"...)
+		src = append(src, region.Bytes()...)
+	case begin == -1 || end == -1 || end < begin:
+		return nil, errors.New("the existing file has unbalanced deep-copy markers")
+	default:
+		src = append(src, existing[:begin]...)
+		src = append(src, region.Bytes()...)
+		src = append(src, bytes.TrimPrefix(existing[end+len(endMarker):], []byte("\n"))...)
+	}
+
+	fset = token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing existing file: %v", err)
+	}
+
+	if err := addImports(fset, f, gen); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); err != nil {
+		return nil, fmt.Errorf("formatting file: %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// addImports adds the imports of the generated file gen to the file f, as
+// long as f uses them: the functions they were imported for may have been
+// left out.
+func addImports(fset *token.FileSet, f, gen *ast.File) error {
+	for _, spec := range gen.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return fmt.Errorf("parsing import %s: %v", spec.Path.Value, err)
+		}
+
+		var name string
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if usesPackage(f, name, path) {
+			astutil.AddNamedImport(fset, f, name, path)
+		}
+	}
+
+	return nil
+}
+
+// usesPackage reports whether f refers to the package of path, imported as
+// name. Generated files only leave out the names of the packages named after
+// the last element of their path.
+func usesPackage(f *ast.File, name, path string) bool {
+	if name == "" {
+		name = path[strings.LastIndex(path, "/")+1:]
+	}
+
+	var used bool
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == name && id.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+
+	return used
+}
+
+// mergeGenerated merges the declarations of the generated file into the
+// existing file, leaving the rest of it untouched, for -output-mode append
+// and inplace. The functions that aren't declared in the existing file yet
+// are appended to it. Those that are, identified by their name and receiver
+// type, are replaced in place with replace, and kept as is otherwise.
+// Other declarations, such as interface assertions, are appended unless the
+// existing file already holds them. An empty existing file gets the generated
+// file as is.
+func mergeGenerated(existing, generated []byte, replace bool) ([]byte, error) {
+	if len(bytes.TrimSpace(existing)) == 0 {
+		return generated, nil
+	}
+
+	genSet := token.NewFileSet()
+	gen, err := parser.ParseFile(genSet, "", generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated file: %v", err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", existing, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing existing file: %v", err)
+	}
+
+	declared := map[string]*ast.FuncDecl{}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			declared[funcKey(fn)] = fn
+		}
+	}
+
+	// The declarations are taken along with the comments above them, such
+	// as the definitions quoted with -embed-source.
+	prev := gen.Name.End()
+
+	type edit struct {
+		start, end int
+		text       []byte
+	}
+	var (
+		edits    []edit
+		appended [][]byte
+	)
+	for _, decl := range gen.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.IMPORT {
+			prev = d.End()
+			continue
+		}
+		text := bytes.TrimSpace(generated[genSet.Position(prev).Offset:genSet.Position(decl.End()).Offset])
+		prev = decl.End()
+
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			if !bytes.Contains(existing, text) {
+				appended = append(appended, text)
+			}
+			continue
+		}
+
+		old, ok := declared[funcKey(fn)]
+		switch {
+		case !ok:
+			appended = append(appended, text)
+		case !replace:
+			// Kept, along with any change merged into it by hand.
+		default:
+			start := fset.Position(embeddedSourcePos(f, old)).Offset
+			edits = append(edits, edit{start: start, end: fset.Position(old.End()).Offset, text: text})
+		}
+	}
+
+	var merged bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		merged.Write(existing[last:e.start])
+		merged.Write(e.text)
+		last = e.end
+	}
+	merged.Write(bytes.TrimRight(existing[last:], "\n"))
+	for _, text := range appended {
+		merged.WriteString("\n\n")
+		merged.Write(text)
+	}
+	merged.WriteString("\n")
+	src := merged.Bytes()
+
+	fset = token.NewFileSet()
+	if f, err = parser.ParseFile(fset, "", src, parser.ParseComments); err != nil {
+		return nil, fmt.Errorf("parsing merged file: %v", err)
+	}
+	if err := addImports(fset, f, gen); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := format.Node(&b, fset, f); err != nil {
+		return nil, fmt.Errorf("formatting file: %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// funcKey identifies a function by its name, qualified by the name of the
+// type of its receiver for methods.
+func funcKey(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
+	if id, ok := recv.(*ast.Ident); ok {
+		return id.Name + "." + fn.Name.Name
+	}
+
+	return fn.Name.Name
+}
+
+// embeddedSourcePos returns the position of the function fn of the file f,
+// including its doc comment and the definition quoted above it with
+// -embed-source, which are replaced along with it.
+func embeddedSourcePos(f *ast.File, fn *ast.FuncDecl) token.Pos {
+	pos := fn.Pos()
+	if fn.Doc != nil {
+		pos = fn.Doc.Pos()
+	}
+
+	for i := len(f.Comments) - 1; i >= 0; i-- {
+		c := f.Comments[i]
+		if c.End() >= pos {
+			continue
+		}
+		if strings.HasPrefix(c.Text(), "Copied from the definition of ") {
+			pos = c.Pos()
+		}
+		break
+	}
+
+	return pos
+}
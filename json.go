@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// jsonFile is the JSON representation of the generated methods, emitted
+// instead of Go source with --output-format json.
+type jsonFile struct {
+	Package string       `json:"package"`
+	Imports []jsonImport `json:"imports"`
+	Methods []jsonMethod `json:"methods"`
+}
+
+type jsonImport struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type jsonMethod struct {
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// generateJSON generates the methods of the types, and represents them as
+// JSON, with the formatted Go source of the methods of each type. Errors are
+// handled as they are by generate.
+func (a *app) generateJSON(p *packages.Package, types typesVal, skips skipsVal) ([]byte, error) {
+	m, err := a.generateMethods(p, types, skips)
+	if m == nil {
+		return nil, err
+	}
+
+	f := jsonFile{Package: a.packageName(p), Imports: []jsonImport{}, Methods: []jsonMethod{}}
+	for name, path := range m.imports {
+		if path == "" {
+			continue
+		}
+		f.Imports = append(f.Imports, jsonImport{Name: name, Path: path})
+	}
+	sort.Slice(f.Imports, func(i, j int) bool {
+		return f.Imports[i].Path < f.Imports[j].Path
+	})
+
+	for i, obj := range m.objs {
+		body, ferr := format.Source(m.fns[i])
+		if ferr != nil {
+			return nil, fmt.Errorf("formatting method of %s: %v", obj.Obj().Name(), ferr)
+		}
+
+		f.Methods = append(f.Methods, jsonMethod{Type: obj.Obj().Name(), Body: string(body)})
+	}
+
+	b, jerr := json.MarshalIndent(f, "", "  ")
+	if jerr != nil {
+		return nil, fmt.Errorf("encoding result: %v", jerr)
+	}
+
+	return append(b, '\n'), err
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineAliasPrefix prefixes the local type aliases introduced by
+// shortenLines, numbered in each function.
+const lineAliasPrefix = "cpType"
+
+// shortenLines shortens the lines of the generated file src that are longer
+// than max characters, tabs counting as one, as line length linters do. The
+// type arguments of the make and new calls on these lines are replaced by
+// local type aliases declared at the top of the function, so that deeply
+// qualified types are only spelled out once. Generic functions are left as
+// is, as they can't declare types.
+func shortenLines(src []byte, max int) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated source: %v", err)
+	}
+
+	lines := bytes.Split(src, []byte("\n"))
+	isLong := func(pos token.Pos) bool {
+		return utf8.RuneCount(lines[fset.Position(pos).Line-1]) > max
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || isGenericFunc(fn) {
+			continue
+		}
+
+		aliases := map[string]string{}
+		var decls bytes.Buffer
+
+		// alias declares the alias of kind, or of its longest parts when
+		// the declaration would be too long itself.
+		var alias func(kind ast.Expr) string
+		alias = func(kind ast.Expr) string {
+			text := string(src[fset.Position(kind.Pos()).Offset:fset.Position(kind.End()).Offset])
+			if name, ok := aliases[text]; ok {
+				return name
+			}
+
+			parts := typeParts(kind)
+			sort.Slice(parts, func(i, j int) bool {
+				return parts[i].End()-parts[i].Pos() > parts[j].End()-parts[j].Pos()
+			})
+			aliased := text
+			for _, part := range parts {
+				partText := string(src[fset.Position(part.Pos()).Offset:fset.Position(part.End()).Offset])
+				if name, ok := aliases[partText]; ok {
+					aliased = strings.Replace(aliased, partText, name, 1)
+				} else if utf8.RuneCountInString("\ttype "+lineAliasPrefix+"00 = "+aliased) > max {
+					aliased = strings.Replace(aliased, partText, alias(part), 1)
+				}
+			}
+
+			name := fmt.Sprintf("%s%d", lineAliasPrefix, len(aliases)+1)
+			aliases[text] = name
+			fmt.Fprintf(&decls, "\ntype %s = %s", name, aliased)
+
+			return name
+		}
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 || !isLong(call.Pos()) {
+				return true
+			}
+			if id, ok := call.Fun.(*ast.Ident); !ok || id.Name != "make" && id.Name != "new" {
+				return true
+			}
+			if len(typeParts(call.Args[0])) == 0 {
+				return true
+			}
+
+			start, end := fset.Position(call.Args[0].Pos()).Offset, fset.Position(call.Args[0].End()).Offset
+			edits = append(edits, edit{start: start, end: end, text: alias(call.Args[0])})
+
+			return true
+		})
+
+		if decls.Len() > 0 {
+			lbrace := fset.Position(fn.Body.Lbrace).Offset + 1
+			edits = append(edits, edit{start: lbrace, end: lbrace, text: decls.String()})
+		}
+	}
+	if len(edits) == 0 {
+		return src, nil
+	}
+
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	out := append([]byte(nil), src...)
+	for _, e := range edits {
+		out = append(out[:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+
+	return format.Source(out)
+}
+
+// typeParts returns the types kind is composed of which can be aliased, such
+// as the elements of slices and maps, or the type arguments of generic types.
+func typeParts(kind ast.Expr) []ast.Expr {
+	var parts []ast.Expr
+	switch t := kind.(type) {
+	case *ast.ArrayType:
+		parts = []ast.Expr{t.Elt}
+	case *ast.MapType:
+		parts = []ast.Expr{t.Key, t.Value}
+	case *ast.StarExpr:
+		parts = []ast.Expr{t.X}
+	case *ast.ChanType:
+		parts = []ast.Expr{t.Value}
+	case *ast.IndexExpr:
+		parts = []ast.Expr{t.Index}
+	case *ast.IndexListExpr:
+		parts = append(parts, t.Indices...)
+	case *ast.ParenExpr:
+		parts = []ast.Expr{t.X}
+	}
+
+	// Names are as short as their aliases.
+	composite := parts[:0]
+	for _, part := range parts {
+		switch part.(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+		default:
+			composite = append(composite, part)
+		}
+	}
+
+	return composite
+}
+
+// isGenericFunc reports whether fn has type parameters, or is a method of a
+// generic type.
+func isGenericFunc(fn *ast.FuncDecl) bool {
+	if fn.Type.TypeParams != nil && len(fn.Type.TypeParams.List) > 0 {
+		return true
+	}
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return false
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch recv.(type) {
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"text/tabwriter"
+)
+
+// list describes every package-level named type of the package matching
+// path, one type per line, or only those selected by only if it isn't nil.
+// The first column holds the type name, so that the output can be used to
+// script --type selection.
+func (a *app) list(path string, only func(*types.TypeName) bool) ([]byte, error) {
+	p, err := a.loadPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+
+	scope := p.Types.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+
+		named, ok := obj.Type().(*types.Named)
+		if !ok || only != nil && !only(obj) {
+			continue
+		}
+
+		var hasMethod bool
+		if iface, ok := named.Underlying().(*types.Interface); ok {
+			hasMethod = a.hasInterfaceDeepCopy(named, iface)
+		} else {
+			hasMethod = a.declaresReuseMethod(named)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s: %s\tinterfaces: %s\n",
+			name, describeType(named.Underlying()), a.reuseMethodName(), yesNo(hasMethod), yesNo(containsInterface(named, map[types.Type]bool{})))
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// declaresReuseMethod reports whether named declares a method named after
+// -reuse-method, whatever its signature. Unlike the methods reused when
+// copying, those that can't be called, such as DeepCopy() (T, error) without
+// -with-error, are reported too, as generating the type would declare them
+// again.
+func (a *app) declaresReuseMethod(named *types.Named) bool {
+	for i := 0; i < named.NumMethods(); i++ {
+		if containsString(a.reuseMethodNames(), named.Method(i).Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyableTypes selects the struct, slice and map types, which methods are
+// usually generated for, as listed by --list-types. Unexported types are
+// only selected with all.
+func copyableTypes(all bool) func(*types.TypeName) bool {
+	return func(obj *types.TypeName) bool {
+		if !all && !obj.Exported() {
+			return false
+		}
+
+		switch obj.Type().Underlying().(type) {
+		case *types.Struct, *types.Slice, *types.Map:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// describeType returns a short summary of the kind of t.
+func describeType(t types.Type) string {
+	switch v := t.(type) {
+	case *types.Struct:
+		if v.NumFields() == 1 {
+			return "struct with 1 field"
+		}
+		return fmt.Sprintf("struct with %d fields", v.NumFields())
+	case *types.Slice:
+		return "slice"
+	case *types.Array:
+		return "array"
+	case *types.Map:
+		return "map"
+	case *types.Pointer:
+		return "pointer"
+	case *types.Chan:
+		return "chan"
+	case *types.Signature:
+		return "func"
+	case *types.Interface:
+		return "interface"
+	default:
+		return t.String()
+	}
+}
+
+// containsInterface reports whether a value of type t can hold an interface
+// value, either directly or through its fields, elements or keys.
+func containsInterface(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch v := t.Underlying().(type) {
+	case *types.Interface:
+		return true
+	case *types.Struct:
+		for i := 0; i < v.NumFields(); i++ {
+			if containsInterface(v.Field(i).Type(), seen) {
+				return true
+			}
+		}
+	case *types.Map:
+		return containsInterface(v.Key(), seen) || containsInterface(v.Elem(), seen)
+	case interface{ Elem() types.Type }:
+		return containsInterface(v.Elem(), seen)
+	}
+
+	return false
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
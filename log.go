@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// logLevel is the least severe level of the reported messages. Errors are
+// always reported.
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+func (l *logLevel) String() string {
+	return logLevelNames[*l]
+}
+
+func (l *logLevel) Set(v string) error {
+	for i, name := range logLevelNames {
+		if v == name {
+			*l = logLevel(i)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("expected one of %s", strings.Join(logLevelNames, ", "))
+}
+
+// logger reports errors, warnings, informational and debug messages on
+// STDERR, down to its level. In quiet mode, messages have no timestamp prefix
+// and only errors are reported, while verbose mode reports informational
+// messages.
+type logger struct {
+	l     *log.Logger
+	level logLevel
+}
+
+// stdLogger is used when the app has no logger of its own.
+var stdLogger = newLogger(os.Stderr, false, false)
+
+func newLogger(w io.Writer, quiet, verbose bool) *logger {
+	flags := log.LstdFlags
+	if quiet {
+		flags = 0
+	}
+
+	level := levelWarn
+	switch {
+	case quiet:
+		level = levelError
+	case verbose:
+		level = levelInfo
+	}
+
+	return &logger{l: log.New(w, "", flags), level: level}
+}
+
+// SetLevel sets the level of the reported messages.
+func (l *logger) SetLevel(level logLevel) {
+	l.level = level
+}
+
+func (l *logger) or() *logger {
+	if l == nil {
+		return stdLogger
+	}
+
+	return l
+}
+
+// Println reports an error.
+func (l *logger) Println(v ...interface{}) {
+	l.or().l.Println(v...)
+}
+
+// Fatalln reports a usage error and exits with exitUsage.
+func (l *logger) Fatalln(v ...interface{}) {
+	l.Exitln(exitUsage, v...)
+}
+
+// Fatalf reports a formatted usage error and exits with exitUsage.
+func (l *logger) Fatalf(format string, v ...interface{}) {
+	l.Exitln(exitUsage, fmt.Sprintf(format, v...))
+}
+
+// Exitln reports an error and exits with the given code.
+func (l *logger) Exitln(code int, v ...interface{}) {
+	l.Println(v...)
+	os.Exit(code)
+}
+
+// Warnf reports a warning, down to the warn level.
+func (l *logger) Warnf(format string, v ...interface{}) {
+	if l := l.or(); l.level <= levelWarn {
+		l.l.Printf("WARNING: "+format, v...)
+	}
+}
+
+// Progressf reports the progress of a phase of the generation, such as the
+// loading of the packages, down to the warn level.
+func (l *logger) Progressf(format string, v ...interface{}) {
+	if l := l.or(); l.level <= levelWarn {
+		l.l.Printf(format, v...)
+	}
+}
+
+// Infof reports an informational message, down to the info level.
+func (l *logger) Infof(format string, v ...interface{}) {
+	if l := l.or(); l.level <= levelInfo {
+		l.l.Printf(format, v...)
+	}
+}
+
+// Debugf reports details of the generation, only at the debug level.
+func (l *logger) Debugf(format string, v ...interface{}) {
+	if l := l.or(); l.level <= levelDebug {
+		l.l.Printf("DEBUG: "+format, v...)
+	}
+}
+
+// fields formats key and value pairs appended to a message, such as the
+// type or file an error concerns, as key=value. Empty values are left out.
+func fields(kv ...string) string {
+	var pairs []string
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			continue
+		}
+		v := kv[i+1]
+		if strings.ContainsAny(v, " \t\"=") {
+			v = strconv.Quote(v)
+		}
+		pairs = append(pairs, kv[i]+"="+v)
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// machineResult describes what generating a single type would produce. It is
+// emitted as JSON when --machine-output is set.
+type machineResult struct {
+	Type          string   `json:"type"`
+	OutputFile    string   `json:"output_file"`
+	Bytes         int      `json:"bytes"`
+	Imports       []string `json:"imports"`
+	FieldsWalked  int      `json:"fields_walked"`
+	FieldsSkipped int      `json:"fields_skipped"`
+	ChanPolicy    string   `json:"chan_policy,omitempty"`
+	Errors        []string `json:"errors"`
+}
+
+// machineOutput performs a dry run of the generation and describes the result
+// of each type as JSON. Errors that concern a single type are reported in its
+// result instead of failing the whole run. A single type produces a JSON
+// object, while multiple types produce an array.
+func (a *app) machineOutput(path string, types typesVal, skips skipsVal, outputFile string) ([]byte, error) {
+	p, err := a.loadPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]machineResult, len(types))
+	objs := make([]object, len(types))
+	generating := make([]object, 0, len(types))
+	for i, kind := range types {
+		results[i] = machineResult{
+			Type:       kind,
+			OutputFile: outputFile,
+			Imports:    []string{},
+			Errors:     []string{},
+		}
+
+		obj, err := locateType(p.Name, kind, p)
+		if err != nil {
+			results[i].Errors = append(results[i].Errors, fmt.Sprintf("locating type %q in %q: %v", kind, p.Name, err))
+			continue
+		}
+		objs[i] = obj
+		generating = append(generating, obj)
+	}
+
+	for i, obj := range objs {
+		kind := types[i]
+		if obj == nil {
+			continue
+		}
+
+		s, err := a.typeSkips(obj, skips, i)
+		if err != nil {
+			results[i].Errors = append(results[i].Errors, fmt.Sprintf("skipping fields: %v", err))
+			continue
+		}
+
+		imports := a.newImports(p)
+		fn, err := a.generateFunc(p, obj, imports, s, generating)
+		if werr := a.checkWarnings(); err == nil {
+			err = werr
+		}
+		if err != nil {
+			results[i].Errors = append(results[i].Errors, fmt.Sprintf("generating method: %v", err))
+			continue
+		}
+
+		results[i].FieldsWalked = a.stats.fieldsWalked
+		results[i].FieldsSkipped = a.stats.fieldsSkipped
+		if a.stats.chans > 0 {
+			results[i].ChanPolicy = string(a.chanPolicyOrDefault())
+		}
+
+		for _, path := range imports {
+			if path != "" {
+				results[i].Imports = append(results[i].Imports, path)
+			}
+		}
+		sort.Strings(results[i].Imports)
+
+		b, err := a.generateFile(p, []string{kind}, imports, [][]byte{fn})
+		if err != nil {
+			results[i].Errors = append(results[i].Errors, fmt.Sprintf("generating file content: %v", err))
+			continue
+		}
+		results[i].Bytes = len(b)
+	}
+
+	var v interface{} = results
+	if len(results) == 1 {
+		v = results[0]
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding result: %v", err)
+	}
+
+	return append(b, '\n'), nil
+}
@@ -5,25 +5,105 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"go/types"
 	"io"
+	"io/fs"
 	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"golang.org/x/tools/go/packages"
 )
 
 var (
-	pointerReceiverF = flag.Bool("pointer-receiver", false, "the generated receiver type")
-	maxDepthF        = flag.Int("maxdepth", 0, "max depth of deep copying")
-
-	typesF  typesVal
-	skipsF  skipsVal
-	outputF outputVal
+	pointerReceiverF        = flag.Bool("pointer-receiver", false, "the generated receiver type")
+	maxDepthF               = flag.Int("maxdepth", 0, "max depth of deep copying")
+	machineOutputF          = flag.Bool("machine-output", false, "print a JSON description of the generation result to STDOUT instead of writing Go source")
+	bothReceiversF          = flag.Bool("both-receivers", false, "generate a value receiver method, and a pointer receiver method delegating to it")
+	methodNameF             = flag.String("method-name", "", "the name of the generated method. Defaults to DeepCopy, or deepCopy for unexported types")
+	reuseMethodF            = flag.String("reuse-method", "", "the name of the method reused to copy nested types. Defaults to -method-name")
+	ptrMethodNameF          = flag.String("ptr-method-name", "DeepCopyPtr", "the name of the pointer receiver method generated with -both-receivers")
+	ignoreUnexportedF       = flag.Bool("ignore-unexported", false, "shallow copy all unexported fields")
+	errorOnExternalPointerF = flag.Bool("error-on-external-pointer", false, "fail when a pointer to an external type without a reusable method would be shallow copied")
+	listF                   = flag.Bool("list", false, "list the named types of the package instead of generating code")
+	listTypesF              = flag.Bool("list-types", false, "list the exported struct, slice and map types of the package instead of generating code")
+	listAllF                = flag.Bool("list-all", false, "with -list-types, also list the unexported types")
+	tagsF                   = flag.String("tags", "", "comma-separated build tags to apply when loading the package")
+	modF                    = flag.String("mod", "", "the module download mode to load the packages with: readonly, mod or vendor. Defaults to vendor when the module has a vendor directory, whatever the GOFLAGS")
+	buildFlagsF             = flag.String("buildflags", "", "space-separated build flags to apply when loading the package, such as -mod=mod or -modfile=tools.mod")
+	dirF                    = flag.String("dir", "", "the directory package paths are resolved from, in the module containing it. Defaults to the current directory")
+	includeTestsF           = flag.Bool("include-tests", false, "include the package's test files when loading it")
+	overlayF                = flag.String("overlay", "", "a JSON file replacing the contents of source files, such as unsaved editor buffers, in the format of go build -overlay")
+	inputFileF              = flag.String("input-file", "", "generate for the package of the given Go file, instead of a package path")
+	srcF                    = flag.String("src", "", "generate for the package made of a single Go file, read from STDIN when -, instead of a package path. The file can only import the standard library")
+	typesFileF              = flag.String("types-file", "", "read the packages, types and skips to generate from a file, with one entry per line")
+	strictF                 = flag.Bool("strict", false, "enable all the safety checks: treat warnings as errors, treat errors of single entries of a types file, or of qualified types, as fatal, fail on unsafe.Pointer and uintptr values that would be shared, and enable -error-on-external-pointer and -strict-skips")
+	noStrictUnsafeF         = flag.Bool("no-strict-unsafe", false, "with -strict, share unsafe.Pointer and uintptr values with a warning instead of failing")
+	noStrictExtPointerF     = flag.Bool("no-strict-external-pointer", false, "with -strict, don't enable -error-on-external-pointer")
+	noStrictSkipsF          = flag.Bool("no-strict-skips", false, "with -strict, don't enable -strict-skips")
+	withFuzzF               = flag.Bool("with-fuzz", false, "also generate a fuzz test asserting copy independence, next to the output file")
+	ignoreErrorsF           = flag.Bool("ignore-errors", false, "write the methods of the types that could be generated, and report the others")
+	skipZeroF               = flag.Bool("skip-zero", false, "zero all skipped values in the copy, instead of shallow copying them")
+	detectCodegenF          = flag.Bool("detect-codegen", false, "skip the generation, successfully, when the output file is newer than all the source files of the package")
+	maxLineLengthF          = flag.Int("max-line-length", 0, "shorten the generated lines longer than this many characters, by declaring local type aliases for the types of make and new calls. 0 leaves the lines as is")
+	fieldDocF               = flag.Bool("field-doc", false, "document the copy of each field that takes code with a comment naming the field and its type")
+	guardNilReceiverF       = flag.Bool("guard-nil-receiver", true, "with -pointer-receiver, return nil when the receiver is nil instead of panicking")
+	sortFieldsF             = flag.Bool("sort-fields", false, "copy the fields of structs in the order of their names, instead of the order they are declared in")
+	noInitialAssignF        = flag.Bool("no-initial-assign", false, "don't start the copy of structs as a shallow copy of the receiver, assign each field instead")
+	noCommentsF             = flag.Bool("no-comments", false, "don't annotate the shallow copied values in the generated code")
+	skipZeroSizeF           = flag.Bool("skip-zero-size", false, "don't generate any code for values of zero-size types, such as struct{}")
+	strictSkipsF            = flag.Bool("strict-skips", false, "fail when a skip selector matches nothing, instead of warning about it")
+	receiverF               = flag.String("receiver", "o", "the identifier of the receiver of the generated methods")
+	tempPrefixF             = flag.String("temp-prefix", "", "name the temporaries of the generated methods after this prefix, such as tmpKey and tmpVal, instead of k and v")
+	outputVarF              = flag.String("output-var", "cp", "the identifier of the copy declared by the generated methods")
+	outputFormatF           = flag.String("output-format", "go", "the format of the output: go source, or json describing the generated methods")
+	quietF                  = flag.Bool("quiet", false, "only report errors, without timestamps")
+	verboseF                = flag.Bool("v", false, "report what is being loaded and generated")
+	rawCommandF             = flag.Bool("raw-command", false, "record the command line as given in the generated header, instead of its canonical form")
+	headerTemplateF         = flag.String("header-template", "", "a text/template producing the header written above the package clause of generated files")
+	concurrentTypesF        = flag.Bool("concurrent-types", false, "generate the methods of the types in parallel")
+	funcF                   = flag.Bool("func", false, "generate a standalone DeepCopyType function per type, in the package named by -package, instead of methods")
+	packageF                = flag.String("package", "", "the name of the package the functions are generated in, with -func")
+	insertMarkersF          = flag.Bool("insert-markers", false, "replace only the region between the // deep-copy:begin and // deep-copy:end markers of the output file, keeping the rest of it")
+	noReuseF                = flag.Bool("no-reuse", false, "inline the copies of all nested types, instead of calling their existing DeepCopy methods")
+	tagFilterF              = flag.String("tag-filter", "", "only generate methods for struct types with at least one field tagged with the given key, out of the -type flags or all types of the package")
+	versionF                = flag.Bool("version", false, "print the version and build information of the generator, and exit")
+	withErrorF              = flag.Bool("with-error", false, "generate methods returning (T, error), failing on interface values that can't be deep copied instead of sharing them")
+	embedSourceF            = flag.Bool("embed-source", false, "write the definition of each type in a comment above its generated method")
+	nonNilCollectionsF      = flag.Bool("non-nil-collections", false, "copy nil slices and maps as empty ones, at every level, instead of keeping them nil")
+	withContextF            = flag.Bool("with-context", false, "generate methods taking a context.Context and returning (T, error), stopping the copy when the context is done")
+
+	typesF      typesVal
+	skipsF      skipsVal
+	skipByTagF  tagSkipsVal
+	skipPatF    skipPatternsVal
+	nonNilF     skipsVal
+	zeroF       skipsVal
+	immutableF  typesVal
+	shareTypeF  typesVal
+	forceDeepF  typesVal
+	assertIfF   typesVal
+	ifaceImplF  ifaceImplsVal
+	envF        envVal
+	chdirF      chdirVal
+	outputF     outputVal
+	requireTagF tagFilter
+	chanF       = chanEmpty
+	outputModeF = outputOverwrite
+	logLevelF   = levelWarn
 )
 
 type typesVal []string
@@ -37,6 +117,23 @@ func (f *typesVal) Set(v string) error {
 	return nil
 }
 
+// envVal holds KEY=VALUE variables, set in the environment of the go command,
+// or of the GOPACKAGESDRIVER, loading the packages.
+type envVal []string
+
+func (f *envVal) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *envVal) Set(v string) error {
+	if key, _, ok := strings.Cut(v, "="); !ok || key == "" {
+		return errors.New("expected KEY=VALUE")
+	}
+	*f = append(*f, v)
+
+	return nil
+}
+
 type skipsVal []skips
 
 func (f *skipsVal) String() string {
@@ -74,462 +171,3159 @@ func (s skips) Contains(sel string) bool {
 	return false
 }
 
-type outputVal struct {
-	file *os.File
-	name string
-}
-
-func (f *outputVal) String() string {
-	return f.name
-}
-
-func (f *outputVal) Set(v string) error {
-	if v == "-" || v == "" {
-		f.name = "stdout"
-
-		if f.file != nil {
-			_ = f.file.Close()
-		}
-		f.file = nil
+// skipPatternsVal holds the -skip-pattern regular expressions, which apply to
+// every type.
+type skipPatternsVal []*regexp.Regexp
 
-		return nil
+func (f *skipPatternsVal) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, re := range *f {
+		parts = append(parts, re.String())
 	}
 
-	file, err := os.OpenFile(v, os.O_RDWR|os.O_CREATE, 0666)
+	return strings.Join(parts, " ")
+}
+
+func (f *skipPatternsVal) Set(v string) error {
+	re, err := regexp.Compile(v)
 	if err != nil {
-		return fmt.Errorf("opening file: %v", v)
+		return err
 	}
 
-	f.name = v
-	f.file = file
+	*f = append(*f, re)
 
 	return nil
 }
 
-func (f *outputVal) Open() (io.WriteCloser, error) {
-	if f.file == nil {
-		f.file = os.Stdout
-	} else {
-		err := f.file.Truncate(0)
-		if err != nil {
-			return nil, err
+// Match reports whether any of the patterns matches sel.
+func (f skipPatternsVal) Match(sel string) bool {
+	for _, re := range f {
+		if re.MatchString(sel) {
+			return true
 		}
 	}
 
-	return f.file, nil
+	return false
 }
 
-func init() {
-	flag.Var(&typesF, "type", "the concrete type. Multiple flags can be specified")
-	flag.Var(&skipsF, "skip", "comma-separated field/slice/map selectors to shallow copy. Multiple flags can be specified")
-	flag.Var(&outputF, "o", "the output file to write to. Defaults to STDOUT")
-}
+// chanPolicy is how channels are copied.
+type chanPolicy string
 
-func main() {
-	flag.Parse()
+const (
+	// chanEmpty makes a new channel with the same capacity.
+	chanEmpty chanPolicy = "empty"
+	// chanNil leaves the channel nil in the copy.
+	chanNil chanPolicy = "nil"
+	// chanShare makes the copy refer to the same channel.
+	chanShare chanPolicy = "share"
+)
 
-	if len(typesF) == 0 || typesF[0] == "" {
-		log.Fatalln("no type given")
-	}
+func (p *chanPolicy) String() string {
+	return string(*p)
+}
 
-	if flag.NArg() != 1 {
-		log.Fatalln("No package path given")
+func (p *chanPolicy) Set(v string) error {
+	switch c := chanPolicy(v); c {
+	case chanEmpty, chanNil, chanShare:
+		*p = c
+		return nil
+	default:
+		return fmt.Errorf("unknown channel policy %q, expected nil, empty or share", v)
 	}
+}
 
-	a := &app{
-		isPtrRecv: *pointerReceiverF,
-		maxDepth:  *maxDepthF,
-	}
+// outputMode is how the generated code is written to an existing output
+// file.
+type outputMode string
+
+const (
+	// outputOverwrite replaces the content of the file.
+	outputOverwrite outputMode = "overwrite"
+	// outputAppend appends the generated functions the file doesn't
+	// declare yet, and keeps the others.
+	outputAppend outputMode = "append"
+	// outputInplace replaces the functions the file already declares, and
+	// appends the others.
+	outputInplace outputMode = "inplace"
+)
 
-	b, err := a.run(flag.Args()[0], typesF, skipsF)
-	if err != nil {
-		log.Fatalln("Error generating deep copy method:", err)
-	}
+func (m *outputMode) String() string {
+	return string(*m)
+}
 
-	output, err := outputF.Open()
-	if err != nil {
-		log.Fatalln("Error initializing output file:", err)
-	}
-	if _, err := output.Write(b); err != nil {
-		log.Fatalln("Error writing result to file:", err)
+func (m *outputMode) Set(v string) error {
+	switch o := outputMode(v); o {
+	case outputOverwrite, outputAppend, outputInplace:
+		*m = o
+		return nil
+	default:
+		return fmt.Errorf("unknown output mode %q, expected overwrite, append or inplace", v)
 	}
-	output.Close()
 }
 
-type app struct {
-	isPtrRecv bool
-	maxDepth  int
+// tagFilter matches struct fields by the presence of a tag key, and
+// optionally its value.
+type tagFilter struct {
+	key, value string
 }
 
-func (a *app) run(path string, types typesVal, skips skipsVal) ([]byte, error) {
-	packages, err := load(path)
-	if err != nil {
-		return nil, fmt.Errorf("loading package: %v", err)
-	}
-	if len(packages) == 0 {
-		return nil, errors.New("no package found")
+func (f *tagFilter) String() string {
+	if f.value == "" {
+		return f.key
 	}
 
-	imports := map[string]string{}
-	fns := [][]byte{}
+	return f.key + ":" + f.value
+}
 
-	objs := make([]object, len(types))
-	for i, kind := range types {
-		obj, err := locateType(packages[0].Name, kind, packages[0])
-		if err != nil {
-			return nil, fmt.Errorf("locating type %q in %q: %v", kind, packages[0].Name, err)
-		}
-		objs[i] = obj
+func (f *tagFilter) Set(v string) error {
+	key, value, _ := strings.Cut(v, ":")
+	if key == "" {
+		return errors.New("missing tag key")
 	}
 
-	for i, obj := range objs {
-		var s map[string]struct{}
-		if i < len(skips) {
-			s = skips[i]
-		}
+	f.key, f.value = key, value
 
-		fn, err := a.generateFunc(packages[0], obj, imports, s, objs)
-		if err != nil {
-			return nil, fmt.Errorf("generating method: %v", err)
-		}
+	return nil
+}
 
-		fns = append(fns, fn)
+// Matches reports whether the struct tag satisfies the filter. An empty filter
+// matches every tag.
+func (f tagFilter) Matches(tag string) bool {
+	if f.key == "" {
+		return true
 	}
 
-	b, err := generateFile(packages[0], imports, fns)
-	if err != nil {
-		return nil, fmt.Errorf("generating file content: %v", err)
-	}
+	v, ok := reflect.StructTag(tag).Lookup(f.key)
 
-	return b, nil
+	return ok && (f.value == "" || v == f.value)
 }
 
-func load(patterns string) ([]*packages.Package, error) {
-	return packages.Load(&packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports,
-	}, patterns)
+// outputVal is the -o flag. The file isn't opened until the result is
+// written, so that nothing is created when the tool fails before it. A path
+// that doesn't exist yet is a file, unless the types are qualified, in which
+// case main makes it a directory.
+type outputVal struct {
+	name string
+	file bool
+	dir  bool
 }
 
-func (a *app) generateFunc(p *packages.Package, obj object, imports map[string]string, skips map[string]struct{}, generating []object) ([]byte, error) {
-	var buf bytes.Buffer
+func (f *outputVal) String() string {
+	return f.name
+}
 
-	var ptr string
-	if a.isPtrRecv {
-		ptr = "*"
+func (f *outputVal) Set(v string) error {
+	if v == "-" || v == "" {
+		f.name, f.file, f.dir = "stdout", false, false
+
+		return nil
 	}
-	kind := obj.Obj().Name()
 
-	source := "o"
-	fmt.Fprintf(&buf, `// DeepCopy generates a deep copy of %s%s
-func (o %s%s) DeepCopy() %s%s {
-	var cp %s = %s%s
-`, ptr, kind, ptr, kind, ptr, kind, kind, ptr, source)
+	fi, err := os.Stat(v)
+	f.name = v
+	f.dir = err == nil && fi.IsDir()
+	f.file = !f.dir
 
-	a.walkType(source, "cp", p.Name, obj, &buf, imports, skips, generating, 0)
+	return nil
+}
 
-	if a.isPtrRecv {
-		buf.WriteString("return &cp\n}")
-	} else {
-		buf.WriteString("return cp\n}")
+// Open opens the output file, truncated, creating it and its directory as
+// needed, as it may be the first file of a new package. Without a file, the
+// result is written to STDOUT.
+func (f *outputVal) Open() (io.WriteCloser, error) {
+	if !f.file {
+		return os.Stdout, nil
 	}
 
-	return buf.Bytes(), nil
+	if err := os.MkdirAll(filepath.Dir(f.name), 0777); err != nil {
+		return nil, fmt.Errorf("creating directory: %v", err)
+	}
+
+	return os.OpenFile(f.name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 }
 
-func generateFile(p *packages.Package, imports map[string]string, fn [][]byte) ([]byte, error) {
-	var file bytes.Buffer
+// readOutput returns the content of the output file, which is empty when it
+// doesn't exist yet.
+func readOutput(name string) ([]byte, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
 
-	fmt.Fprintf(&file, "// generated by %s; DO NOT EDIT.\n\npackage %s\n\n", strings.Join(os.Args, " "), p.Name)
+	return b, err
+}
 
-	if len(imports) > 0 {
-		file.WriteString("import (\n")
-		for name, path := range imports {
-			if strings.HasSuffix(path, name) {
-				fmt.Fprintf(&file, "%q\n", path)
-			} else {
-				fmt.Fprintf(&file, "%s %q\n", name, path)
-			}
+func init() {
+	// Invalid flags exit with exitUsage, rather than the status 2 of
+	// flag.ExitOnError, which reports resolution errors.
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+
+	flag.Var(&typesF, "type", "the concrete type, optionally qualified as pkg/path.Type. Multiple flags can be specified")
+	flag.Var(&skipsF, "skip", "comma-separated field/slice/map selectors to shallow copy, or to zero when suffixed with !. Selectors can contain * and ? wildcards. Multiple flags can be specified")
+	flag.Var(&skipPatF, "skip-pattern", "a regular expression of field/slice/map selectors to shallow copy in every type, such as ^mu|Mutex$. Multiple flags can be specified")
+	flag.Var(&skipByTagF, "skip-by-tag", "skip selectors of struct tag names, in key:path[,path...] form such as json:spec.containers. Multiple flags can be specified")
+	flag.Var(&zeroF, "zero", "comma-separated field selectors set to their zero value in the copy, such as ID,CreatedAt, in the same form as -skip. Multiple flags can be specified")
+	flag.Var(&nonNilF, "assert-non-nil", "comma-separated field selectors whose values panic the copy when nil, in the same form as -skip. Multiple flags can be specified")
+	flag.Var(&immutableF, "treat-as-immutable", "a type, qualified as pkg/path.Type, whose values are shared instead of deep copied. Multiple flags can be specified")
+	flag.Var(&shareTypeF, "share-type", "a type, qualified as pkg/path.Type, whose pointers are shared with the copy, as those to regexp.Regexp or time.Location. Multiple flags can be specified")
+	flag.Var(&forceDeepF, "force-deep", "a type, qualified as pkg/path.Type, whose copies are inlined instead of calling its existing DeepCopy method. Multiple flags can be specified")
+	flag.Var(&assertIfF, "assert-interface", "an interface, qualified as pkg/path.Interface, that the generated types are asserted to implement in the generated file. Generic interfaces are instantiated with the type. Multiple flags can be specified")
+	flag.Var(&assertIfF, "assert-implements", "alias of -assert-interface")
+	flag.Var(&ifaceImplF, "iface-impl", "an interface and the concrete types of its package whose values are deep copied through a type switch, in Interface=Type[,Type...] form such as Shape=Circle,Square. Multiple flags can be specified")
+	flag.Var(&chdirF, "C", "change to this directory before doing anything else, as with the go command. Must be the first flag")
+	flag.Var(&envF, "env", "a KEY=VALUE variable set in the environment the packages are loaded with, such as GOPACKAGESDRIVER or GOFLAGS. Multiple flags can be specified")
+	flag.Var(&outputF, "o", "the output file to write to, or directory with qualified types. Defaults to STDOUT")
+	flag.IntVar(maxDepthF, "max-depth", 0, "alias of -maxdepth")
+	flag.BoolVar(skipZeroF, "zero-skipped", false, "alias of -skip-zero")
+	flag.BoolVar(quietF, "q", false, "alias of -quiet")
+	flag.Var(&logLevelF, "log-level", "the least severe messages reported: debug, info, warn or error")
+	flag.Var(&outputModeF, "output-mode", "how the output file is written: overwrite it, append the generated functions to it, or replace those it declares inplace and append the others")
+	flag.Var(&chanF, "chan", "how channels are copied: nil, a new empty channel, or share the same channel")
+	flag.Var(&requireTagF, "require-tag", "only deep copy fields with the given struct tag, in key[:value] form")
+}
+
+func main() {
+	if err := checkChdirFirst(os.Args[1:]); err != nil {
+		log.Fatalln(err)
+	}
+	start, _ := os.Getwd()
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
-		file.WriteString(")\n")
+		os.Exit(exitUsage)
 	}
 
-	for _, fn := range fn {
-		file.Write(fn)
-		file.WriteString("\n\n")
+	if *versionF {
+		info, _ := debug.ReadBuildInfo()
+		fmt.Print(buildInfo(info))
+		return
 	}
 
-	b, err := format.Source(file.Bytes())
-	if err != nil {
-		return nil, fmt.Errorf("error formatting source: %w\nsource:\n%s", err, file.String())
+	if *quietF && *verboseF {
+		log.Fatalln("-quiet can't be combined with -v")
 	}
+	if *outputFormatF != "go" && *outputFormatF != "json" {
+		log.Fatalf("Unknown -output-format %q, expected go or json", *outputFormatF)
+	}
+	lg := newLogger(os.Stderr, *quietF, *verboseF)
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "log-level" {
+			return
+		}
+		if *quietF || *verboseF {
+			lg.Fatalln("-log-level can't be combined with -quiet or -v")
+		}
+		lg.SetLevel(logLevelF)
+	})
 
-	return b, nil
-}
+	if *bothReceiversF && *pointerReceiverF {
+		lg.Fatalln("-both-receivers can't be combined with -pointer-receiver")
+	}
 
-type object interface {
-	types.Type
-	Obj() *types.TypeName
-}
+	if err := validateReceiver(*receiverF); err != nil {
+		lg.Fatalln("Invalid -receiver:", err)
+	}
+	if err := validateOutputVar(*outputVarF, *receiverF); err != nil {
+		lg.Fatalln("Invalid -output-var:", err)
+	}
+	if err := validateTempPrefix(*tempPrefixF, *receiverF, *outputVarF); err != nil {
+		lg.Fatalln("Invalid -temp-prefix:", err)
+	}
 
-type pointer interface {
-	Elem() types.Type
-}
+	for _, name := range []string{*methodNameF, *reuseMethodF, *ptrMethodNameF} {
+		if name != "" && !token.IsIdentifier(name) {
+			lg.Fatalf("Invalid method name %q", name)
+		}
+	}
+	if *bothReceiversF && *ptrMethodNameF == *methodNameF {
+		lg.Fatalln("-ptr-method-name must differ from -method-name")
+	}
 
-type methoder interface {
-	types.Type
-	Method(i int) *types.Func
-	NumMethods() int
-}
+	if !*strictF && (*noStrictUnsafeF || *noStrictExtPointerF || *noStrictSkipsF) {
+		lg.Fatalln("-no-strict-unsafe, -no-strict-external-pointer and -no-strict-skips require -strict")
+	}
 
-func locateType(x, sel string, p *packages.Package) (object, error) {
-	for _, t := range p.TypesInfo.Defs {
-		if t == nil {
-			continue
+	if (*withErrorF || *withContextF) && *withFuzzF {
+		lg.Fatalln("-with-error and -with-context can't be combined with -with-fuzz")
+	}
+
+	var funcQualifier string
+	if *funcF {
+		if !token.IsIdentifier(*packageF) {
+			lg.Fatalln("-func requires the name of the package to generate in with -package")
 		}
-		m := exprFilter(t.Type(), sel, x)
-		if m == nil {
-			continue
+		if *bothReceiversF || *withFuzzF || *listF || *listTypesF || *typesFileF != "" {
+			lg.Fatalln("-func can't be combined with -both-receivers, -with-fuzz, -list, -list-types or -types-file")
 		}
 
-		return m, nil
+		var err error
+		if typesF, funcQualifier, err = funcTypes(typesF); err != nil {
+			lg.Fatalln("Invalid -type:", err)
+		}
+	} else if *packageF != "" {
+		lg.Fatalln("-package requires -func")
 	}
 
-	return nil, errors.New("type not found")
-}
+	immutable := map[string]bool{}
+	for _, v := range immutableF {
+		if pattern, _ := splitQualifiedType(v); pattern == "" {
+			lg.Fatalf("-treat-as-immutable type %q is not qualified as pkg/path.Type", v)
+		}
+		immutable[v] = true
+	}
 
-func reducePointer(typ types.Type) (types.Type, bool) {
-	if pointer, ok := typ.(pointer); ok {
-		return pointer.Elem(), true
+	shared := map[string]bool{}
+	for _, v := range shareTypeF {
+		if pattern, _ := splitQualifiedType(v); pattern == "" {
+			lg.Fatalf("-share-type type %q is not qualified as pkg/path.Type", v)
+		}
+		shared[v] = true
 	}
-	return typ, false
-}
 
-func objFromType(typ types.Type) object {
-	typ, _ = reducePointer(typ)
+	forceDeep := map[string]bool{}
+	for _, v := range forceDeepF {
+		if pattern, _ := splitQualifiedType(v); pattern == "" {
+			lg.Fatalf("-force-deep type %q is not qualified as pkg/path.Type", v)
+		}
+		forceDeep[v] = true
+	}
 
-	m, ok := typ.(object)
-	if !ok {
-		return nil
+	a := &app{
+		isPtrRecv:        *pointerReceiverF,
+		bothReceivers:    *bothReceiversF,
+		methodName:       *methodNameF,
+		reuseMethod:      *reuseMethodF,
+		ptrMethodName:    *ptrMethodNameF,
+		funcMode:         *funcF,
+		funcPackage:      *packageF,
+		receiver:         *receiverF,
+		tempPrefix:       *tempPrefixF,
+		outputVar:        *outputVarF,
+		maxDepth:         *maxDepthF,
+		ignoreUnexported: *ignoreUnexportedF,
+		requireTag:       requireTagF,
+		chanPolicy:       chanF,
+		immutable:        immutable,
+		shared:           shared,
+		forceDeep:        forceDeep,
+		ifaceImpls:       ifaceImplF,
+		noReuse:          *noReuseF,
+		withError:        *withErrorF,
+		withContext:      *withContextF,
+
+		errorOnExternalPointer: *errorOnExternalPointerF || *strictF && !*noStrictExtPointerF,
+		strict:                 *strictF,
+		noStrictUnsafe:         *noStrictUnsafeF,
+		ignoreErrors:           *ignoreErrorsF,
+		concurrentTypes:        *concurrentTypesF,
+		strictSkips:            *strictSkipsF || *strictF && !*noStrictSkipsF,
+		skipZero:               *skipZeroF,
+		skipByTag:              skipByTagF,
+		skipPatterns:           skipPatF,
+		skipZeroSize:           *skipZeroSizeF,
+		noComments:             *noCommentsF,
+		fieldDoc:               *fieldDocF,
+		noInitialAssign:        *noInitialAssignF,
+		sortFields:             *sortFieldsF,
+		guardNilReceiver:       *guardNilReceiverF,
+		maxLineLength:          *maxLineLengthF,
+		nonNilCollections:      *nonNilCollectionsF,
+		embedSource:            *embedSourceF,
+
+		tags:         *tagsF,
+		buildFlags:   strings.Fields(*buildFlagsF),
+		mod:          *modF,
+		env:          envF,
+		includeTests: *includeTestsF,
+		dir:          *dirF,
+
+		logger: lg,
 	}
 
-	return m
-}
+	if *rawCommandF {
+		a.command = strings.Join(os.Args, " ")
+	} else if wd, err := os.Getwd(); err == nil {
+		// With -C, the command is run again from the module it was given
+		// from, and the other paths are resolved from its directory.
+		root, chdir := moduleRoot(wd), ""
+		if chdirF != "" {
+			root, chdir = wd, relativePath(wd, moduleRoot(start))
+		}
+		a.command = canonicalCommand(os.Args[1:], flag.CommandLine, root, chdir)
+	}
 
-func exprFilter(t types.Type, sel string, x string) object {
-	m := objFromType(t)
-	if m == nil {
-		return nil
+	if *overlayF != "" {
+		overlay, err := readOverlay(*overlayF)
+		if err != nil {
+			lg.Fatalln("Error reading overlay:", err, fields("file", *overlayF))
+		}
+		a.overlay = overlay
 	}
 
-	obj := m.Obj()
-	if obj.Pkg() == nil || x != obj.Pkg().Name() || sel != obj.Name() {
-		return nil
+	if *headerTemplateF != "" {
+		tmpl, err := template.New("header").Parse(*headerTemplateF)
+		if err != nil {
+			lg.Fatalln("Error parsing header template:", err)
+		}
+		a.headerTemplate = tmpl
 	}
 
-	return m
-}
+	if len(assertIfF) > 0 && *funcF {
+		lg.Fatalln("-assert-interface can't be combined with -func")
+	}
+	for _, v := range assertIfF {
+		iface, err := a.lookupInterface(v)
+		if err != nil {
+			lg.Fatalf("Invalid -assert-interface %s: %v", v, err)
+		}
+		a.assertInterfaces = append(a.assertInterfaces, iface)
+	}
 
-func (a *app) walkType(source, sink, x string, m types.Type, w io.Writer, imports map[string]string, skips skips, generating []object, depth int) {
-	initial := depth == 0
-	if m == nil {
-		return
+	if (*typesFileF != "" || hasQualifiedType(typesF)) && *outputFormatF != "go" {
+		lg.Fatalln("-output-format json can't be combined with -types-file or qualified types")
+	}
+	if (*typesFileF != "" || hasQualifiedType(typesF)) && *insertMarkersF {
+		lg.Fatalln("-insert-markers can't be combined with -types-file or qualified types")
+	}
+	if (*typesFileF != "" || hasQualifiedType(typesF)) && outputModeF != outputOverwrite {
+		lg.Fatalln("-output-mode append and inplace can't be combined with -types-file or qualified types")
 	}
 
-	if a.maxDepth > 0 {
-		sinkDepth := strings.Count(sink, ".")
-		if sinkDepth >= a.maxDepth {
-			p := strings.Split(sink, ".")
-			stoppedAt := strings.TrimSuffix(fmt.Sprintf("%s.%s", generating[0], strings.Join(p[1:len(p)-1], ".")), ".")
-			log.Printf("WARNING: reached max depth %d. stop recursion at %s", sinkDepth, stoppedAt)
-			return
-		}
+	if *srcF != "" && (*typesFileF != "" || hasQualifiedType(typesF) || flag.NArg() != 0 || *dirF != "" || *includeTestsF || *listF || *listTypesF || *machineOutputF || *withFuzzF || *insertMarkersF) {
+		lg.Fatalln("-src can't be combined with -types-file, qualified types, a package path, -dir, -include-tests, -list, -list-types, -machine-output, -with-fuzz or -insert-markers")
 	}
 
-	var needExported bool
-	switch v := m.(type) {
-	case *types.Named:
-		if v.Obj().Pkg() != nil && v.Obj().Pkg().Name() != x {
-			needExported = true
+	if *inputFileF != "" {
+		if *srcF != "" || *typesFileF != "" || hasQualifiedType(typesF) || flag.NArg() != 0 {
+			lg.Fatalln("-input-file can't be combined with -src, -types-file, qualified types or a package path")
+		}
+		if filepath.Ext(*inputFileF) != ".go" {
+			lg.Fatalf("-input-file %s is not a Go file", *inputFileF)
 		}
 	}
 
-	if v, ok := m.(methoder); ok && !initial && a.reuseDeepCopy(source, sink, v, false, generating, w) {
+	if *detectCodegenF && (!outputF.file || *srcF != "" || *typesFileF != "" || hasQualifiedType(typesF)) {
+		lg.Fatalln("-detect-codegen requires an output file, and can't be combined with -src, -types-file or qualified types")
+	}
+
+	if *typesFileF != "" {
+		if len(typesF) > 0 || len(skipsF) > 0 || len(skipByTagF) > 0 || len(nonNilF) > 0 || len(zeroF) > 0 || flag.NArg() != 0 || outputF.String() != "" {
+			lg.Fatalln("-types-file can't be combined with -type, -skip, -skip-by-tag, -assert-non-nil, -zero, -o or a package path")
+		}
+
+		runTypesFile(a, *typesFileF)
 		return
 	}
 
-	depth++
-	under := m.Underlying()
-	switch v := under.(type) {
-	case *types.Struct:
-		for i := 0; i < v.NumFields(); i++ {
-			field := v.Field(i)
-			if needExported && !field.Exported() {
-				continue
-			}
-			fname := field.Name()
-			sel := sink + "." + fname
-			sel = sel[strings.Index(sel, ".")+1:]
-			if _, ok := skips[sel]; ok {
-				continue
-			}
-			a.walkType(source+"."+fname, sink+"."+fname, x, field.Type(), w, imports, skips, generating, depth)
+	if hasQualifiedType(typesF) {
+		if *listF || *listTypesF || *machineOutputF || *withFuzzF || len(skipByTagF) > 0 || len(nonNilF) > 0 || len(zeroF) > 0 {
+			lg.Fatalln("qualified types can't be combined with -list, -list-types, -machine-output, -with-fuzz, -skip-by-tag, -assert-non-nil or -zero")
+		}
+		if flag.NArg() > 1 {
+			lg.Fatalln("Only one package path can be given")
 		}
-	case *types.Slice:
-		kind := getElemType(v.Elem(), x, imports)
 
-		idx := "i"
-		if depth > 1 {
-			idx += strconv.Itoa(depth)
+		entries, err := qualifiedEntries(typesF, skipsF, flag.Arg(0))
+		if err != nil {
+			lg.Fatalln("Error parsing types:", err)
 		}
 
-		// sel is only used for skips
-		sel := "[i]"
-		sel = sel[strings.Index(sel, ".")+1:]
-		if !initial {
-			sel = sink + sel
+		// A path that doesn't exist yet is the directory the files are
+		// written under.
+		if outputF.file {
+			if _, err := os.Stat(outputF.String()); err == nil {
+				lg.Fatalln("-o must be a directory when generating qualified types")
+			}
+			outputF.file, outputF.dir = false, true
 		}
 
-		var skipSlice bool
-		if skips.Contains(sel) {
-			skipSlice = true
+		var dir string
+		if outputF.dir {
+			dir = outputF.String()
 		}
+		runBatch(a, entries, dir)
+		return
+	}
 
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make([]%s, len(%s))
-`, source, sink, kind, source)
+	if *tagFilterF != "" && (*listF || *listTypesF || *machineOutputF) {
+		lg.Fatalln("-tag-filter can't be combined with -list, -list-types or -machine-output")
+	}
+	if *tagFilterF != "" && len(typesF) == 0 && (len(skipsF) > 0 || len(skipByTagF) > 0) {
+		lg.Fatalln("-skip and -skip-by-tag require -type flags when combined with -tag-filter")
+	}
 
-		fmt.Fprintf(w, `copy(%s, %s)
-`, sink, source)
+	if len(nonNilF) > len(typesF) {
+		lg.Fatalln("-assert-non-nil applies to the type at the same position, but there are fewer -type flags")
+	}
+	a.nonNil = make(map[string]skips, len(nonNilF))
+	for i, s := range nonNilF {
+		a.nonNil[typesF[i]] = s
+	}
 
-		var b bytes.Buffer
+	if len(zeroF) > len(typesF) {
+		lg.Fatalln("-zero applies to the type at the same position, but there are fewer -type flags")
+	}
+	a.zero = make(map[string]skips, len(zeroF))
+	for i, s := range zeroF {
+		a.zero[typesF[i]] = s
+	}
 
-		if !skipSlice {
-			baseSel := "[" + idx + "]"
-			a.walkType(source+baseSel, sink+baseSel, x, v.Elem(), &b, imports, skips, generating, depth)
-		}
+	if *listAllF && !*listTypesF {
+		lg.Fatalln("-list-all requires -list-types")
+	}
+	if *listF && *listTypesF {
+		lg.Fatalln("-list can't be combined with -list-types")
+	}
 
-		if b.Len() > 0 {
-			fmt.Fprintf(w, `    for %s := range %s {
-`, idx, source)
+	if !*listF && !*listTypesF && *tagFilterF == "" && (len(typesF) == 0 || typesF[0] == "") {
+		lg.Fatalln("no type given")
+	}
 
-			b.WriteTo(w)
+	if *srcF == "" && *inputFileF == "" && flag.NArg() != 1 {
+		lg.Fatalln("No package path given")
+	}
 
-			fmt.Fprintf(w, "}\n")
+	// The package of the input file is loaded with the file= query of the
+	// go command. The file is relative to the current directory, even when
+	// the go command runs in -dir.
+	path := flag.Arg(0)
+	if *inputFileF != "" {
+		abs, err := filepath.Abs(*inputFileF)
+		if err != nil {
+			lg.Fatalf("-input-file %s: %v", *inputFileF, err)
 		}
+		path = "file=" + abs
+	}
 
-		fmt.Fprintf(w, "}\n")
-	case *types.Pointer:
-		fmt.Fprintf(w, "if %s != nil {\n", source)
+	if *listF || *listTypesF {
+		var only func(*types.TypeName) bool
+		if *listTypesF {
+			only = copyableTypes(*listAllF)
+		}
+
+		b, err := a.list(path, only)
+		if err != nil {
+			lg.Exitln(exitResolve, "Error listing types:", err)
+		}
+		if _, err := os.Stdout.Write(b); err != nil {
+			lg.Exitln(exitWrite, "Error writing result:", err)
+		}
+		return
+	}
+
+	if *machineOutputF {
+		name := outputF.String()
+		if name == "" {
+			name = "stdout"
+		}
+
+		b, err := a.machineOutput(path, typesF, skipsF, name)
+		if err != nil {
+			lg.Exitln(exitCode(err), "Error describing deep copy methods:", err)
+		}
+		if _, err := os.Stdout.Write(b); err != nil {
+			lg.Exitln(exitWrite, "Error writing result:", err)
+		}
+		return
+	}
+
+	if outputF.dir {
+		lg.Fatalln("-o is a directory, which is only supported with qualified types")
+	}
+
+	if *outputFormatF == "json" && *withFuzzF {
+		lg.Fatalln("-with-fuzz can't be combined with -output-format json")
+	}
+
+	if *withFuzzF && !outputF.file {
+		lg.Fatalln("-with-fuzz requires an output file")
+	}
+
+	if *modF != "" && *modF != "readonly" && *modF != "mod" && *modF != "vendor" {
+		lg.Fatalf("Unknown -mod %q, expected readonly, mod or vendor", *modF)
+	}
+
+	if fi, err := os.Stat(*dirF); *dirF != "" && (err != nil || !fi.IsDir()) {
+		lg.Fatalf("-dir %s is not a directory", *dirF)
+	}
+
+	if *insertMarkersF && (!outputF.file || *outputFormatF != "go") {
+		lg.Fatalln("-insert-markers requires an output file and -output-format go")
+	}
+
+	if outputModeF != outputOverwrite && (!outputF.file || *outputFormatF != "go" || *insertMarkersF) {
+		lg.Fatalln("-output-mode append and inplace require an output file and -output-format go, and can't be combined with -insert-markers")
+	}
+
+	var p *packages.Package
+	if *srcF != "" {
+		src, err := readSource(*srcF)
+		if err != nil {
+			lg.Fatalln("Error reading source:", err, fields("file", *srcF))
+		}
+
+		if p, err = a.loadSource(src); err != nil {
+			lg.Exitln(exitResolve, "Error generating deep copy method:", err, fields("file", *srcF))
+		}
+	} else {
+		var err error
+		if p, err = a.loadPackage(path); err != nil {
+			lg.Exitln(exitResolve, "Error generating deep copy method:", err, fields("package", path))
+		}
+	}
+	if *detectCodegenF {
+		upToDate, err := outputUpToDate(outputF.String(), p.GoFiles)
+		if err != nil {
+			lg.Exitln(exitResolve, "Error detecting generated code:", err, fields("package", p.PkgPath))
+		}
+		if upToDate {
+			lg.Infof("%s is newer than the sources of %s, skipping generation", outputF.String(), p.PkgPath)
+			return
+		}
+	}
+
+	if outputF.file && *srcF == "" && *outputFormatF == "go" && !*funcF {
+		if err := outputInPackage(p, outputF.String()); err != nil {
+			lg.Exitln(exitResolve, "Error generating deep copy method:", err, fields("package", p.PkgPath))
+		}
+	}
+
+	if funcQualifier != "" && funcQualifier != p.Name {
+		lg.Exitln(exitResolve, fmt.Sprintf("The types are qualified by %s, but the package is %s", funcQualifier, p.Name))
+	}
+
+	if *tagFilterF != "" {
+		kinds, keep := taggedTypes(p, *tagFilterF, typesF)
+		if len(kinds) == 0 {
+			lg.Exitln(exitResolve, fmt.Sprintf("No type with a %q tag found", *tagFilterF))
+		}
+
+		var skips skipsVal
+		var skipByTag tagSkipsVal
+		for _, i := range keep {
+			if i < len(skipsF) {
+				skips = append(skips, skipsF[i])
+			}
+			if i < len(skipByTagF) {
+				skipByTag = append(skipByTag, skipByTagF[i])
+			}
+		}
+		typesF, skipsF, a.skipByTag = kinds, skips, skipByTag
+		lg.Infof("selected %s with -tag-filter %s", strings.Join(kinds, ", "), *tagFilterF)
+	}
+
+	generate := a.generate
+	if *outputFormatF == "json" {
+		generate = a.generateJSON
+	}
+
+	b, err := generate(p, typesF, skipsF)
+	var typeErrs typeErrors
+	if err != nil && (b == nil || !errors.As(err, &typeErrs)) {
+		lg.Exitln(exitCode(err), "Error generating deep copy method:", err, fields("package", p.PkgPath, "types", typesF.String()))
+	}
+
+	if *withFuzzF {
+		var generated typesVal
+		for _, kind := range typesF {
+			if !typeErrs.Contains(kind) {
+				generated = append(generated, kind)
+			}
+		}
+
+		objs, err := locateTypes(p, generated)
+		if err != nil {
+			lg.Exitln(exitResolve, "Error generating fuzz harness:", err, fields("package", p.PkgPath))
+		}
+
+		fb, err := a.generateFuzz(p, objs)
+		if err != nil {
+			lg.Exitln(exitGenerate, "Error generating fuzz harness:", err, fields("package", p.PkgPath, "types", generated.String()))
+		}
+
+		if err := os.WriteFile(fuzzFileName(outputF.String()), fb, 0666); err != nil {
+			lg.Exitln(exitWrite, "Error writing fuzz harness to file:", err, fields("file", fuzzFileName(outputF.String())))
+		}
+	}
+
+	if *insertMarkersF {
+		existing, err := readOutput(outputF.String())
+		if err != nil {
+			lg.Exitln(exitWrite, "Error reading output file:", err, fields("file", outputF.String()))
+		}
+
+		if b, err = insertGenerated(existing, b); err != nil {
+			lg.Exitln(exitWrite, "Error inserting into output file:", err, fields("file", outputF.String()))
+		}
+	}
+
+	if outputModeF != outputOverwrite {
+		existing, err := readOutput(outputF.String())
+		if err != nil {
+			lg.Exitln(exitWrite, "Error reading output file:", err, fields("file", outputF.String()))
+		}
+
+		if b, err = mergeGenerated(existing, b, outputModeF == outputInplace); err != nil {
+			lg.Exitln(exitWrite, "Error merging into output file:", err, fields("file", outputF.String()))
+		}
+	}
+
+	output, err := outputF.Open()
+	if err != nil {
+		lg.Exitln(exitWrite, "Error initializing output file:", err, fields("file", outputF.String()))
+	}
+	if _, err := output.Write(b); err != nil {
+		lg.Exitln(exitWrite, "Error writing result to file:", err, fields("file", outputF.String()))
+	}
+	output.Close()
+	lg.Infof("wrote %d bytes to %s", len(b), outputF.String())
+
+	if len(typeErrs) > 0 {
+		for _, err := range typeErrs {
+			lg.Println("Error generating deep copy method:", err, fields("package", p.PkgPath, "type", err.kind))
+		}
+		os.Exit(exitCode(typeErrs))
+	}
+}
+
+func runTypesFile(a *app, name string) {
+	lg := a.logger
+
+	f, err := os.Open(name)
+	if err != nil {
+		lg.Fatalln("Error opening types file:", err, fields("file", name))
+	}
+	entries, err := parseTypesFile(f)
+	f.Close()
+	if err != nil {
+		lg.Fatalln("Error parsing types file:", err, fields("file", name))
+	}
+
+	runBatch(a, entries, "")
+}
+
+// runBatch generates the entries and writes a file per package, either next
+// to the package sources or under dir. Failing entries are reported and make
+// the tool exit with an error, after the other files have been written. In
+// strict mode, nothing is written when an entry fails.
+func runBatch(a *app, entries []batchEntry, dir string) {
+	lg := a.logger
+
+	files, errs := a.generateBatch(entries)
+	for _, err := range errs {
+		lg.Println("Error generating deep copy method:", err)
+	}
+	if a.strict && len(errs) > 0 {
+		os.Exit(batchExitCode(errs))
+	}
+
+	// Packages of different modules can share a directory under dir, the file
+	// of one would then replace the other.
+	written := map[string]string{}
+	for _, f := range files {
+		path := f.outputPath(dir)
+		if other, ok := written[path]; ok {
+			lg.Exitln(exitWrite, "Error writing result to file:", fmt.Errorf("both %s and %s would be written to it", other, f.pkgPath), fields("file", path))
+		}
+		written[path] = f.pkgPath
+	}
+
+	for _, f := range files {
+		path := f.outputPath(dir)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			lg.Exitln(exitWrite, "Error creating output directory:", err, fields("file", path))
+		}
+		if err := os.WriteFile(path, f.content, 0666); err != nil {
+			lg.Exitln(exitWrite, "Error writing result to file:", err, fields("file", path))
+		}
+		lg.Infof("wrote %d bytes to %s", len(f.content), path)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(batchExitCode(errs))
+	}
+}
+
+type app struct {
+	isPtrRecv        bool
+	bothReceivers    bool
+	methodName       string
+	reuseMethod      string
+	ptrMethodName    string
+	funcMode         bool
+	funcPackage      string
+	receiver         string
+	tempPrefix       string
+	outputVar        string
+	maxDepth         int
+	ignoreUnexported bool
+	requireTag       tagFilter
+	chanPolicy       chanPolicy
+	immutable        map[string]bool
+	shared           map[string]bool
+	forceDeep        map[string]bool
+	ifaceImpls       ifaceImplsVal
+	noReuse          bool
+	nonNil           map[string]skips
+	zero             map[string]skips
+	assertInterfaces []*types.Named
+	withError        bool
+	withContext      bool
+
+	errorOnExternalPointer bool
+	strict                 bool
+	noStrictUnsafe         bool
+	ignoreErrors           bool
+	concurrentTypes        bool
+	strictSkips            bool
+	skipZero               bool
+	skipByTag              tagSkipsVal
+	skipPatterns           skipPatternsVal
+	skipZeroSize           bool
+	noComments             bool
+	fieldDoc               bool
+	noInitialAssign        bool
+	sortFields             bool
+	guardNilReceiver       bool
+	maxLineLength          int
+	nonNilCollections      bool
+	embedSource            bool
+
+	tags         string
+	buildFlags   []string
+	mod          string
+	env          []string
+	includeTests bool
+	dir          string
+	// overlay holds the contents of the files replaced with -overlay, by
+	// their absolute path.
+	overlay map[string][]byte
+
+	headerTemplate *template.Template
+	// command is recorded in the generated header.
+	command string
+
+	logger *logger
+
+	stats stats
+	// warnings are collected while generating methods, and logged once
+	// they are known to concern the generated output.
+	warnings []warning
+}
+
+// warning is collected while generating a method. With -strict, it is an
+// error, unless it is lenient: its check has a flag of its own, such as
+// -strict-skips, which already decided to only warn about it.
+type warning struct {
+	msg     string
+	lenient bool
+}
+
+// checkWarnings logs the collected warnings. With -strict, those that aren't
+// lenient are returned as an error instead.
+func (a *app) checkWarnings() error {
+	var strict []string
+	for _, w := range a.warnings {
+		if a.strict && !w.lenient {
+			strict = append(strict, w.msg)
+			continue
+		}
+		a.logger.Warnf("%s", w.msg)
+	}
+	a.warnings = nil
+
+	if len(strict) > 0 {
+		return fmt.Errorf("warnings are errors with -strict: %s", strings.Join(strict, "; "))
+	}
+
+	return nil
+}
+
+// warnOnce collects a warning, unless it was already collected.
+func (a *app) warnOnce(msg string, lenient bool) {
+	for _, w := range a.warnings {
+		if w.msg == msg {
+			return
+		}
+	}
+
+	a.warnings = append(a.warnings, warning{msg: msg, lenient: lenient})
+}
+
+// packageName returns the name of the package the code is generated in,
+// which is the package of the types, unless functions are generated.
+func (a *app) packageName(p *packages.Package) string {
+	if a.funcMode {
+		return a.funcPackage
+	}
+
+	return p.Name
+}
+
+// funcTypes strips the qualifiers of the -type flags given with -func, which
+// may name their package as in models.User, since the functions are generated
+// in another package. All qualified types must share the same qualifier, which
+// is returned to be matched against the name of the loaded package.
+func funcTypes(types typesVal) (typesVal, string, error) {
+	var qualifier string
+	kinds := make(typesVal, len(types))
+	for i, v := range types {
+		pattern, kind := splitQualifiedType(v)
+		if pattern != "" && !token.IsIdentifier(pattern) {
+			return nil, "", fmt.Errorf("%q can only be qualified by the name of its package with -func", v)
+		}
+		if pattern != "" && qualifier != "" && pattern != qualifier {
+			return nil, "", fmt.Errorf("%q is qualified by %s, other types by %s", v, pattern, qualifier)
+		}
+		if pattern != "" {
+			qualifier = pattern
+		}
+		kinds[i] = kind
+	}
+
+	return kinds, qualifier, nil
+}
+
+// methodNameOrDefault returns the name of the method generated for t. Unless
+// it is set, unexported types get an unexported method.
+func (a *app) methodNameOrDefault(t types.Type) string {
+	if a.methodName != "" {
+		return a.methodName
+	}
+	if n, ok := t.(*types.Named); ok && !n.Obj().Exported() {
+		return "deepCopy"
+	}
+
+	return "DeepCopy"
+}
+
+// reuseMethodName returns the name of the method reused to copy nested types
+// that aren't being generated. It is the name of the generated method, unless
+// a different one is set, which allows generating a Clone method that reuses
+// the DeepCopy methods of dependencies.
+func (a *app) reuseMethodName() string {
+	if a.reuseMethod != "" {
+		return a.reuseMethod
+	}
+	if a.methodName != "" {
+		return a.methodName
+	}
+
+	return "DeepCopy"
+}
+
+// reuseMethodNames returns the names of the methods reused to copy nested
+// types. Without a set name, both casings of the default are looked for, as
+// generated for exported and unexported types.
+func (a *app) reuseMethodNames() []string {
+	if a.reuseMethod == "" && a.methodName == "" {
+		return []string{"DeepCopy", "deepCopy"}
+	}
+
+	return []string{a.reuseMethodName()}
+}
+
+// chanPolicyOrDefault returns the policy channels are copied with.
+func (a *app) chanPolicyOrDefault() chanPolicy {
+	if a.chanPolicy == "" {
+		return chanEmpty
+	}
+
+	return a.chanPolicy
+}
+
+// reservedIdentRE matches the identifiers of the temporaries declared by the
+// generated methods.
+var reservedIdentRE = regexp.MustCompile(`^(cp|retV|err|ctx|[ikv]\d*|c\d+)$|^(cp|[kv]\d*)_`)
+
+// validateReceiver checks that name can be used as the receiver of the
+// generated methods without colliding with the generated temporaries.
+func validateReceiver(name string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("%q is not a valid identifier", name)
+	}
+	if reservedIdentRE.MatchString(name) {
+		return fmt.Errorf("%q collides with the identifiers of the generated code", name)
+	}
+
+	return nil
+}
+
+// validateOutputVar checks that name can be used as the copy declared by the
+// generated methods, along with the receiver. The temporaries of the copy are
+// prefixed by its name.
+func validateOutputVar(name, receiver string) error {
+	if !token.IsIdentifier(name) || name == "_" {
+		return fmt.Errorf("%q is not a valid identifier", name)
+	}
+	if name != "cp" && reservedIdentRE.MatchString(name) {
+		return fmt.Errorf("%q collides with the identifiers of the generated code", name)
+	}
+	if name == receiver || strings.HasPrefix(receiver, name+"_") {
+		return fmt.Errorf("%q collides with the receiver %s", name, receiver)
+	}
+
+	return nil
+}
+
+// tempSuffixes name the temporaries of the generated code after the
+// -temp-prefix, by their short default names.
+var tempSuffixes = map[string]string{"retV": "Ret", "i": "Idx", "k": "Key", "v": "Val", "c": "Impl"}
+
+// tempNameRE matches the identifiers of the temporaries named after prefix.
+func tempNameRE(prefix string) *regexp.Regexp {
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `(Ret|(Idx|Key|Val|Impl)\d*)$`)
+}
+
+// validateTempPrefix checks that the temporaries named after prefix don't
+// collide with the receiver, the copy or the other identifiers of the
+// generated methods. Field names can't collide, as fields are always
+// selected.
+func validateTempPrefix(prefix, receiver, outputVar string) error {
+	if prefix == "" {
+		return nil
+	}
+	if !token.IsIdentifier(prefix) || prefix == "_" {
+		return fmt.Errorf("%q is not a valid identifier", prefix)
+	}
+
+	re := tempNameRE(prefix)
+	for _, name := range []string{receiver, outputVar, "err", "ctx", "d"} {
+		if re.MatchString(name) {
+			return fmt.Errorf("the temporaries named after %q collide with %s", prefix, name)
+		}
+	}
+
+	return nil
+}
+
+// tempName returns the identifier of the temporary named short by default,
+// such as the index i of a slice, or after the -temp-prefix. Temporaries of
+// nested values are suffixed by their depth.
+func (a *app) tempName(short string, depth int) string {
+	name := short
+	if a.tempPrefix != "" {
+		name = a.tempPrefix + tempSuffixes[short]
+	}
+	if depth > 1 {
+		name += strconv.Itoa(depth)
+	}
+
+	return name
+}
+
+// outputVarName returns the identifier of the copy declared by the generated
+// methods.
+func (a *app) outputVarName() string {
+	if a.outputVar == "" {
+		return "cp"
+	}
+
+	return a.outputVar
+}
+
+// commandLine returns the command recorded in the generated header, in its
+// canonical form unless -raw-command is given.
+func (a *app) commandLine() string {
+	if a.command == "" {
+		return "deep-copy"
+	}
+
+	return a.command
+}
+
+// receiverName returns the identifier of the receiver of the generated
+// methods.
+func (a *app) receiverName() string {
+	if a.receiver == "" {
+		return "o"
+	}
+
+	return a.receiver
+}
+
+// stats holds bookkeeping about the method currently being generated.
+type stats struct {
+	fieldsWalked  int
+	fieldsSkipped int
+	chans         int
+	usedSkips     map[string]bool
+	// zero are the -zero selectors of the current type.
+	zero skips
+	// promoted are the selectors of the fields of embedded structs, along
+	// with the selectors they are promoted to.
+	promoted map[string][]string
+	// nonNil are the -assert-non-nil selectors of the current type, and
+	// whether they matched a field.
+	nonNil map[string]bool
+	// caveats are the values that aren't deeply copied, as commented.
+	caveats []string
+	// errZero is the value returned along with errors, with -with-error or
+	// -with-context.
+	errZero string
+	// trackDepth is whether the generated methods track the depth of the
+	// copied values, as with -max-depth when the types hold each other.
+	trackDepth bool
+	// unassigned is whether the next struct walked isn't a shallow copy of
+	// its source, with -no-initial-assign.
+	unassigned bool
+}
+
+func (a *app) run(path string, types typesVal, skips skipsVal) ([]byte, error) {
+	p, err := a.loadPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.generate(p, types, skips)
+}
+
+// loadPackage loads the package matching path. If the path matches several
+// packages, the first one is used. When test files are included, the variant
+// of the package compiled with its test files is preferred.
+func (a *app) loadPackage(path string) (*packages.Package, error) {
+	packages, err := a.load(path)
+	if err != nil {
+		return nil, resolveError{fmt.Errorf("loading package: %v", err)}
+	}
+	if len(packages) == 0 {
+		return nil, resolveError{errors.New("no package found")}
+	}
+
+	if a.includeTests {
+		for _, p := range packages {
+			if strings.HasSuffix(p.ID, ".test]") {
+				return p, nil
+			}
+		}
+	}
+
+	return packages[0], nil
+}
+
+// lookupInterface loads the interface qualified as pkg/path.Interface, whose
+// implementation is asserted for the generated types.
+func (a *app) lookupInterface(v string) (*types.Named, error) {
+	pattern, name := splitQualifiedType(v)
+	if pattern == "" {
+		return nil, errors.New("the interface is not qualified as pkg/path.Interface")
+	}
+
+	p, err := a.loadPackage(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	tn, ok := p.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type %s in %s", name, p.PkgPath)
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok || !types.IsInterface(named) {
+		return nil, fmt.Errorf("%s is not an interface", tn.Type())
+	}
+	if n := named.TypeParams().Len(); n > 1 {
+		return nil, fmt.Errorf("%s has %d type parameters, only interfaces with at most one are supported", name, n)
+	}
+
+	return named, nil
+}
+
+// typeError is the error of generating the method of a single type.
+type typeError struct {
+	kind string
+	err  error
+}
+
+func (e typeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.kind, e.err)
+}
+
+func (e typeError) Unwrap() error {
+	return e.err
+}
+
+// typeErrors is returned along with the generated content when errors are
+// ignored and some of the types failed.
+type typeErrors []typeError
+
+func (e typeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Contains reports whether generating the type kind failed.
+func (e typeErrors) Contains(kind string) bool {
+	for _, err := range e {
+		if err.kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generate generates the methods of the types. When errors are ignored, the
+// types that fail are left out, and their errors are returned as typeErrors,
+// along with the content generated for the remaining types, if any.
+func (a *app) generate(p *packages.Package, types typesVal, skips skipsVal) ([]byte, error) {
+	m, err := a.generateMethods(p, types, skips)
+	if m == nil {
+		return nil, err
+	}
+
+	b, ferr := a.generateFile(p, objectNames(m.objs), m.imports, m.fns)
+	if ferr != nil {
+		return nil, fmt.Errorf("generating file content: %v", ferr)
+	}
+
+	return b, err
+}
+
+// methods are the generated methods of some types, and the imports they need.
+type methods struct {
+	objs    []object
+	fns     [][]byte
+	imports map[string]string
+}
+
+// Len, Less and Swap sort the methods by type name, so that the output
+// doesn't depend on the order the types are given in.
+func (m *methods) Len() int           { return len(m.objs) }
+func (m *methods) Less(i, j int) bool { return m.objs[i].Obj().Name() < m.objs[j].Obj().Name() }
+func (m *methods) Swap(i, j int) {
+	m.objs[i], m.objs[j] = m.objs[j], m.objs[i]
+	m.fns[i], m.fns[j] = m.fns[j], m.fns[i]
+}
+
+// generateMethods generates the methods of the types. When errors are ignored,
+// the types that fail are left out, and their errors are returned as
+// typeErrors, along with the methods of the remaining types, if any.
+func (a *app) generateMethods(p *packages.Package, types typesVal, skips skipsVal) (*methods, error) {
+	var (
+		errs    typeErrors
+		objs    []object
+		objSkip []map[string]struct{}
+	)
+	if !a.funcMode {
+		if err := extendable(p); err != nil {
+			return nil, resolveError{err}
+		}
+	}
+	if a.tempPrefix != "" {
+		re := tempNameRE(a.tempPrefix)
+		for _, name := range p.Types.Scope().Names() {
+			if re.MatchString(name) {
+				return nil, fmt.Errorf("-temp-prefix %s: the temporaries of the generated methods would shadow %s, declared in %s", a.tempPrefix, name, p.PkgPath)
+			}
+		}
+	}
+
+	a.logger.Progressf("locating types %s in %s", strings.Join(types, ", "), p.PkgPath)
+	start := time.Now()
+	for i, kind := range types {
+		obj, err := locateType(p.Name, kind, p)
+		if err != nil {
+			err = resolveError{fmt.Errorf("locating type %q in %q: %v", kind, p.Name, err)}
+			if !a.ignoreErrors {
+				return nil, err
+			}
+			errs = append(errs, typeError{kind: kind, err: err})
+			continue
+		}
+
+		s, err := a.typeSkips(obj, skips, i)
+		if err != nil {
+			err = resolveError{fmt.Errorf("skipping fields of %q: %v", kind, err)}
+			if !a.ignoreErrors {
+				return nil, err
+			}
+			errs = append(errs, typeError{kind: kind, err: err})
+			continue
+		}
+		objs = append(objs, obj)
+		objSkip = append(objSkip, s)
+	}
+	a.logger.Infof("located the types in %v", time.Since(start).Round(time.Millisecond))
+	start = time.Now()
+
+	for len(objs) > 0 {
+		a.warnings = nil
+
+		generate := a.generateFuncs
+		if a.concurrentTypes {
+			generate = a.generateFuncsConcurrently
+		}
+
+		// The other types are generated again without a failed one, so
+		// that they don't reuse a method that won't exist.
+		fns, imports, failed, err := generate(p, objs, objSkip)
+		if failed != -1 {
+			err = fmt.Errorf("generating method: %w", err)
+			if !a.ignoreErrors {
+				return nil, err
+			}
+			errs = append(errs, typeError{kind: objs[failed].Obj().Name(), err: err})
+
+			objs = append(objs[:failed:failed], objs[failed+1:]...)
+			objSkip = append(objSkip[:failed:failed], objSkip[failed+1:]...)
+			continue
+		}
+
+		if err := a.checkWarnings(); err != nil {
+			return nil, err
+		}
+		a.logger.Infof("generated %s in package %s in %v", strings.Join(objectNames(objs), ", "), p.PkgPath, time.Since(start).Round(time.Millisecond))
+
+		m := &methods{objs: objs, fns: fns, imports: imports}
+		sort.Sort(m)
+		if len(errs) > 0 {
+			return m, errs
+		}
+
+		return m, nil
+	}
+
+	return nil, errs
+}
+
+// generateFuncs generates the methods of the types one after the other. The
+// index of the first type that fails is returned with its error, or -1.
+func (a *app) generateFuncs(p *packages.Package, objs []object, objSkip []map[string]struct{}) ([][]byte, map[string]string, int, error) {
+	imports := a.newImports(p)
+	fns := make([][]byte, 0, len(objs))
+	for i, obj := range objs {
+		a.logger.Progressf("generating %s (%d/%d)", obj.Obj().Name(), i+1, len(objs))
+		fn, err := a.generateFunc(p, obj, imports, objSkip[i], objs)
+		if err != nil {
+			return nil, nil, i, err
+		}
+
+		fns = append(fns, fn)
+	}
+
+	return fns, imports, -1, nil
+}
+
+// typeSkips returns the skips of the i-th type, together with the selectors
+// of its -skip-by-tag paths. The -zero selectors of the type are skipped with
+// the zero suffix.
+func (a *app) typeSkips(obj object, skips skipsVal, i int) (map[string]struct{}, error) {
+	var s map[string]struct{}
+	if i < len(skips) {
+		s = skips[i]
+	}
+	if zero := a.zero[obj.Obj().Name()]; len(zero) > 0 {
+		merged := make(map[string]struct{}, len(s)+len(zero))
+		for sel := range s {
+			merged[sel] = struct{}{}
+		}
+		for sel := range zero {
+			merged[strings.TrimSuffix(sel, zeroSuffix)+zeroSuffix] = struct{}{}
+		}
+		s = merged
+	}
+	if i >= len(a.skipByTag) {
+		return s, nil
+	}
+
+	resolved, err := a.skipByTag[i].resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+	for sel := range s {
+		resolved[sel] = struct{}{}
+	}
+
+	return resolved, nil
+}
+
+// taggedTypes returns the struct types with at least one field tagged with
+// key, out of types or, if no types are given, all the named types of the
+// package. The indices of the kept types are returned along with them. Types
+// that can't be located are kept, so that their errors are reported.
+func taggedTypes(p *packages.Package, key string, types typesVal) (typesVal, []int) {
+	candidates := types
+	if len(candidates) == 0 {
+		candidates = p.Types.Scope().Names()
+	}
+
+	var (
+		kept typesVal
+		keep []int
+	)
+	for i, kind := range candidates {
+		obj, err := locateType(p.Name, kind, p)
+		if err == nil && !hasTaggedField(obj, key) {
+			continue
+		}
+		if err != nil && len(types) == 0 {
+			continue
+		}
+
+		kept = append(kept, kind)
+		keep = append(keep, i)
+	}
+
+	return kept, keep
+}
+
+// hasTaggedField reports whether t is a struct with a field tagged with key.
+func hasTaggedField(t types.Type, key string) bool {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		if _, ok := reflect.StructTag(st.Tag(i)).Lookup(key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// methodPragmas returns the //go: directives, such as //go:nosplit, of the
+// existing declaration of the method of the type kind, if any, so that they
+// are carried over to the generated method.
+func methodPragmas(p *packages.Package, kind, method string) []string {
+	for _, f := range p.Syntax {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != method || fn.Doc == nil {
+				continue
+			}
+
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			if ident, ok := recv.(*ast.Ident); !ok || ident.Name != kind {
+				continue
+			}
+
+			var pragmas []string
+			for _, c := range fn.Doc.List {
+				if strings.HasPrefix(c.Text, "//go:") {
+					pragmas = append(pragmas, c.Text)
+				}
+			}
+
+			return pragmas
+		}
+	}
+
+	return nil
+}
+
+func locateTypes(p *packages.Package, types typesVal) ([]object, error) {
+	objs := make([]object, len(types))
+	for i, kind := range types {
+		obj, err := locateType(p.Name, kind, p)
+		if err != nil {
+			return nil, fmt.Errorf("locating type %q in %q: %v", kind, p.Name, err)
+		}
+		objs[i] = obj
+	}
+
+	return objs, nil
+}
+
+func (a *app) load(patterns string) ([]*packages.Package, error) {
+	buildFlags := append([]string(nil), a.buildFlags...)
+	if mod := a.modFlag(); mod != "" {
+		buildFlags = append(buildFlags, "-mod="+mod)
+	}
+	if a.tags != "" {
+		buildFlags = append(buildFlags, "-tags="+a.tags)
+	}
+
+	// The variables of -env override those of the environment, which is
+	// otherwise inherited as is.
+	var env []string
+	if len(a.env) > 0 {
+		env = append(os.Environ(), a.env...)
+	}
+
+	a.logger.Progressf("loading packages %s", patterns)
+	a.logger.Infof("loading %s, build flags: %v, env: %v, tests: %v, dir: %s", patterns, buildFlags, a.env, a.includeTests, a.dir)
+	start := time.Now()
+
+	// The go command, or the GOPACKAGESDRIVER of the environment, such as the
+	// one of a Bazel workspace, resolves the patterns from Dir, in its module
+	// and with the GOFLAGS of the environment.
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedDeps | packages.NeedImports | packages.NeedModule,
+		BuildFlags: buildFlags,
+		Env:        env,
+		Tests:      a.includeTests,
+		Dir:        a.dir,
+		Overlay:    a.overlay,
+	}, patterns)
+	a.logger.Infof("loaded %s in %v", patterns, time.Since(start).Round(time.Millisecond))
+
+	return pkgs, err
+}
+
+func (a *app) generateFunc(p *packages.Package, obj object, imports map[string]string, skips map[string]struct{}, generating []object) ([]byte, error) {
+	var buf bytes.Buffer
+
+	kind := obj.Obj().Name()
+	a.stats = stats{usedSkips: map[string]bool{}, nonNil: map[string]bool{}}
+	for sel := range a.nonNil[kind] {
+		a.stats.nonNil[sel] = false
+	}
+	a.stats.zero = a.zero[kind]
+
+	var ptr string
+	if a.isPtrRecv {
+		ptr = "*"
+	}
+
+	source, sink := a.receiverName(), a.outputVarName()
+	method := a.methodNameOrDefault(obj)
+	x := a.packageName(p)
+
+	// Generic types are copied by methods of the type instantiated with its
+	// own type parameters.
+	typ := kind + typeParamNames(obj)
+	if types.IsInterface(obj) && !a.funcMode {
+		return nil, fmt.Errorf("%s is an interface, methods can't be declared on it", kind)
+	}
+	if typ != kind && len(a.assertInterfaces) > 0 {
+		return nil, fmt.Errorf("%s is generic, it can't be asserted to implement -assert-interface", kind)
+	}
+
+	// The types holding values of the types being generated copy them by
+	// calling their method, the depth is tracked across these calls.
+	a.stats.trackDepth = a.maxDepth > 0 && holdsGenerating(generating)
+	if a.stats.trackDepth && a.funcMode {
+		return nil, fmt.Errorf("-max-depth can't limit the depth of %s, which holds values of the types being generated, with -func", kind)
+	}
+
+	// With -no-initial-assign, structs aren't first shallow copied, their
+	// fields are assigned one by one.
+	initial := " = " + ptr + source
+	if _, ok := obj.Underlying().(*types.Struct); ok && a.noInitialAssign {
+		if a.stats.trackDepth {
+			return nil, fmt.Errorf("-no-initial-assign can't be combined with -max-depth, %s holds values of the types being generated", kind)
+		}
+		if a.funcMode && hasUnexportedFields(obj) {
+			return nil, fmt.Errorf("%s has unexported fields, they can't be assigned from package %s with -no-initial-assign", kind, x)
+		}
+		initial = ""
+		a.stats.unassigned = true
+	}
+
+	// With -with-error or -with-context, the copy is returned along with a
+	// nil error, and the zero value along with the errors.
+	results, ret := "%s%s", "return %s%s\n}"
+	if a.returnsErrors() {
+		results, ret = "(%s%s, error)", "return %s%s, nil\n}"
+		a.stats.errZero = "nil"
+		if !a.isPtrRecv {
+			a.stats.errZero = zeroValue(obj, x, imports)
+		}
+	}
+
+	// Pointer receivers are nil-safe with -guard-nil-receiver, as the copy of
+	// a nil pointer is nil.
+	var guard string
+	if a.isPtrRecv && a.guardNilReceiver {
+		guard = fmt.Sprintf("if %s == nil {\nreturn nil\n}\n", source)
+		if a.returnsErrors() {
+			guard = fmt.Sprintf("if %s == nil {\nreturn nil, nil\n}\n", source)
+		}
+	}
+
+	if a.funcMode {
+		if x == p.Name {
+			return nil, fmt.Errorf("-package %s is the name of the package of %s", x, kind)
+		}
+		if p.Name == "main" {
+			return nil, fmt.Errorf("%s is in a main package, which can't be imported by package %s", kind, x)
+		}
+		if !obj.Obj().Exported() {
+			return nil, fmt.Errorf("%s is unexported, it can't be copied from package %s", kind, x)
+		}
+
+		qualified := getElemType(obj, x, imports)
+		fmt.Fprintf(&buf, `// %s%s generates a deep copy of %s%s
+func %s%s%s(%s%s %s%s) %s {
+	%svar %s %s%s
+`, method, kind, ptr, qualified, method, kind, typeParamDecls(obj, x, imports), a.contextParam(x, imports, ", "), source, ptr, qualified, fmt.Sprintf(results, ptr, qualified), guard, sink, qualified, initial)
+	} else {
+		fmt.Fprintf(&buf, "// %s generates a deep copy of %s%s\n", method, ptr, kind)
+		for _, pragma := range methodPragmas(p, kind, method) {
+			if pragma == "//go:noescape" {
+				// Only valid on declarations without a body.
+				a.warnOnce(fmt.Sprintf("%s of %s.%s dropped, it can't apply to the generated method", pragma, kind, method), false)
+				continue
+			}
+			fmt.Fprintln(&buf, pragma)
+		}
+		name, params := method, a.contextParam(x, imports, "")
+		if a.stats.trackDepth {
+			var ctx string
+			if a.withContext {
+				ctx = "ctx, "
+			}
+			depthMethod := depthMethodName(method)
+			fmt.Fprintf(&buf, `//
+// Values nested %d levels deep or more are shallow copied.
+func (%s %s%s) %s(%s) %s {
+	return %s.%s(%s0)
+}
+
+// %s generates a deep copy of %s%s, at depth d of the copied value.
+`, a.maxDepth, source, ptr, typ, method, a.contextParam(x, imports, ""), fmt.Sprintf(results, ptr, typ), source, depthMethod, ctx, depthMethod, ptr, kind)
+			name, params = depthMethod, a.contextParam(x, imports, ", ")+"d int"
+		}
+		fmt.Fprintf(&buf, `func (%s %s%s) %s(%s) %s {
+	%svar %s %s%s
+`, source, ptr, typ, name, params, fmt.Sprintf(results, ptr, typ), guard, sink, typ, initial)
+	}
+
+	if err := a.walkType(source, sink, "", x, obj, &buf, imports, skips, generating, 0); err != nil {
+		return nil, err
+	}
+
+	if imports[source] != "" {
+		return nil, fmt.Errorf("receiver %s of %s shadows an imported package", source, kind)
+	}
+
+	var unasserted []string
+	for sel, used := range a.stats.nonNil {
+		if !used {
+			unasserted = append(unasserted, sel)
+		}
+	}
+	if len(unasserted) > 0 {
+		sort.Strings(unasserted)
+		return nil, resolveError{fmt.Errorf("-assert-non-nil selectors of %s matched no field: %s", kind, strings.Join(unasserted, ", "))}
+	}
+
+	if unused := a.unusedSkips(skips); len(unused) > 0 {
+		if a.strictSkips {
+			return nil, resolveError{fmt.Errorf("skip selectors of %s matched nothing: %s", kind, strings.Join(unused, ", "))}
+		}
+		a.warnOnce(fmt.Sprintf("skip selectors of %s matched nothing: %s", kind, strings.Join(unused, ", ")), true)
+	}
+
+	if a.isPtrRecv {
+		fmt.Fprintf(&buf, ret, "&", sink)
+	} else {
+		fmt.Fprintf(&buf, ret, "", sink)
+	}
+
+	// The values that aren't deeply copied are listed after the first line
+	// of the doc comment, for the readers of the documentation.
+	if len(a.stats.caveats) > 0 {
+		var doc bytes.Buffer
+		doc.WriteString("//\n// Not deeply copied:\n")
+		for _, c := range a.stats.caveats {
+			fmt.Fprintf(&doc, "//   - %s\n", c)
+		}
+
+		b := buf.Bytes()
+		i := bytes.IndexByte(b, '\n') + 1
+
+		var withDoc bytes.Buffer
+		withDoc.Write(b[:i])
+		doc.WriteTo(&withDoc)
+		withDoc.Write(b[i:])
+		buf = withDoc
+	}
+
+	if a.bothReceivers && a.returnsErrors() {
+		var ctx string
+		if a.withContext {
+			ctx = "ctx"
+		}
+		fmt.Fprintf(&buf, `
+
+// %s generates a deep copy of *%s
+func (%s *%s) %s(%s) (*%s, error) {
+	if %s == nil {
+		return nil, nil
+	}
+	%s, err := %s.%s(%s)
+	if err != nil {
+		return nil, err
+	}
+	return &%s, nil
+}`, a.ptrMethodName, kind, source, typ, a.ptrMethodName, a.contextParam(x, imports, ""), typ, source, sink, source, method, ctx, sink)
+	} else if a.bothReceivers {
+		fmt.Fprintf(&buf, `
+
+// %s generates a deep copy of *%s
+func (%s *%s) %s() *%s {
+	if %s == nil {
+		return nil
+	}
+	%s := %s.%s()
+	return &%s
+}`, a.ptrMethodName, kind, source, typ, a.ptrMethodName, typ, source, sink, source, method, sink)
+	}
+
+	for _, iface := range a.assertInterfaces {
+		var target types.Type = obj
+		if a.isPtrRecv {
+			target = types.NewPointer(obj)
+		}
+
+		// The type implements generic interfaces instantiated with the
+		// type returned by its method.
+		var t types.Type = iface
+		if iface.TypeParams().Len() == 1 {
+			var err error
+			if t, err = types.Instantiate(nil, iface, []types.Type{target}, true); err != nil {
+				return nil, fmt.Errorf("instantiating %s with %s: %v", iface.Obj().Name(), kind, err)
+			}
+		}
+
+		fmt.Fprintf(&buf, "\n\nvar _ %s = (*%s)(nil)", getElemType(t, x, imports), kind)
+	}
+
+	if a.embedSource {
+		src, err := typeSource(p, kind)
+		if err != nil {
+			return nil, err
+		}
+		return append(src, buf.Bytes()...), nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// typeSource returns a comment quoting the definition of the type kind, as
+// formatted from the syntax of the package, followed by a blank line.
+func typeSource(p *packages.Package, kind string) ([]byte, error) {
+	for _, f := range p.Syntax {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); !ok || ts.Name.Name != kind {
+					continue
+				}
+
+				var def bytes.Buffer
+				if err := format.Node(&def, p.Fset, &ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{spec}}); err != nil {
+					return nil, fmt.Errorf("formatting the definition of %s: %v", kind, err)
+				}
+
+				var buf bytes.Buffer
+				fmt.Fprintf(&buf, "// Copied from the definition of %s:\n//\n", kind)
+				for _, line := range strings.Split(def.String(), "\n") {
+					fmt.Fprintf(&buf, "//\t%s\n", line)
+				}
+				buf.WriteString("\n")
+
+				return buf.Bytes(), nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// TemplateData is the data the --header-template is executed with.
+type TemplateData struct {
+	Types       []string
+	Package     string
+	Command     string
+	OptionsHash string
+	Date        string
+	Version     string
+}
+
+func (a *app) generateFile(p *packages.Package, types []string, imports map[string]string, fn [][]byte) ([]byte, error) {
+	var file bytes.Buffer
+
+	if err := a.writeHeader(&file, p, types); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return imports[names[i]] < imports[names[j]]
+	})
+
+	var specs []string
+	for _, name := range names {
+		path := imports[name]
+		switch {
+		case path == "":
+			// A name reserved by the package.
+		case strings.HasSuffix(path, name):
+			specs = append(specs, fmt.Sprintf("%q\n", path))
+		default:
+			specs = append(specs, fmt.Sprintf("%s %q\n", name, path))
+		}
+	}
+
+	if len(specs) > 0 {
+		file.WriteString("import (\n")
+		for _, spec := range specs {
+			file.WriteString(spec)
+		}
+		file.WriteString(")\n")
+	}
+
+	for _, fn := range fn {
+		file.Write(fn)
+		file.WriteString("\n\n")
+	}
+
+	// The file is formatted as a whole, without the blank lines that
+	// follow the last function.
+	src := append(bytes.TrimRight(file.Bytes(), " \t\n"), '\n')
+
+	b, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("error formatting source: %w\nsource:\n%s", err, file.String())
+	}
+
+	if a.maxLineLength > 0 {
+		return shortenLines(b, a.maxLineLength)
+	}
+
+	return b, nil
+}
+
+// writeHeader writes the file header, followed by the build constraint of the
+// types, if any, and the package clause. Without a header template, the
+// default "generated by" comment is used. The output of the template is always
+// separated from the package clause by a blank line, so that it doesn't turn
+// into the package documentation.
+func (a *app) writeHeader(w *bytes.Buffer, p *packages.Package, types []string) error {
+	expr, err := typesConstraint(p, types)
+	if err != nil {
+		return err
+	}
+	var build string
+	if expr != "" {
+		build = "//go:build " + expr + "\n\n"
+	}
+
+	if a.headerTemplate == nil {
+		// The canonical command leaves out the logging flags, so that the
+		// header doesn't change with them.
+		fmt.Fprintf(w, "// generated by %s; DO NOT EDIT.\n\n%spackage %s\n\n", a.commandLine(), build, a.packageName(p))
+		return nil
+	}
+
+	var header bytes.Buffer
+	err = a.headerTemplate.Execute(&header, TemplateData{
+		Types:       types,
+		Package:     a.packageName(p),
+		Command:     a.commandLine(),
+		OptionsHash: optionsHash(a.commandLine()),
+		Date:        time.Now().Format("2006-01-02"),
+		Version:     version(),
+	})
+	if err != nil {
+		return fmt.Errorf("executing header template: %v", err)
+	}
+
+	if h := strings.TrimRight(header.String(), "\n"); h != "" {
+		w.WriteString(h)
+		w.WriteString("\n\n")
+	}
+	fmt.Fprintf(w, "%spackage %s\n\n", build, a.packageName(p))
+
+	return nil
+}
+
+// version returns the module version deep-copy was built from.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	return "(devel)"
+}
+
+// buildInfo describes the generator build, as deep-copy v1.2.3 (commit
+// abc123, go1.21.0), followed by the module path and the build settings.
+func buildInfo(info *debug.BuildInfo) string {
+	if info == nil {
+		return "deep-copy (devel)\n"
+	}
+
+	v := info.Main.Version
+	if v == "" {
+		v = "(devel)"
+	}
+
+	var commit string
+	var settings []string
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision":
+			commit = s.Value
+			if len(commit) > 12 {
+				commit = commit[:12]
+			}
+		case s.Key == "vcs.modified" && s.Value == "true":
+			settings = append(settings, "modified")
+		case s.Value == "" || s.Key == "DefaultGODEBUG" || strings.HasPrefix(s.Key, "vcs."):
+			// Not relevant to reports of issues.
+		default:
+			settings = append(settings, s.Key+"="+s.Value)
+		}
+	}
+
+	details := info.GoVersion
+	if commit != "" {
+		details = "commit " + commit + ", " + details
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "deep-copy %s (%s)\n", v, details)
+	if info.Main.Path != "" {
+		fmt.Fprintf(&b, "module: %s\n", info.Main.Path)
+	}
+	if len(settings) > 0 {
+		fmt.Fprintf(&b, "build: %s\n", strings.Join(settings, " "))
+	}
+
+	return b.String()
+}
+
+func objectNames(objs []object) []string {
+	names := make([]string, len(objs))
+	for i, obj := range objs {
+		names[i] = obj.Obj().Name()
+	}
+
+	return names
+}
+
+type object interface {
+	types.Type
+	Obj() *types.TypeName
+}
+
+type pointer interface {
+	Elem() types.Type
+}
+
+type methoder interface {
+	types.Type
+	Method(i int) *types.Func
+	NumMethods() int
+}
+
+// locateType returns the type named sel in the package. The Defs of the
+// package are unordered, so the package level definition is preferred over
+// the local types of the same name, and then the earliest one.
+func locateType(x, sel string, p *packages.Package) (object, error) {
+	var (
+		found *types.TypeName
+		obj   object
+	)
+	for _, t := range p.TypesInfo.Defs {
+		// Constants and variables have the type too, as the values of an
+		// iota enum, only its definition is looked at.
+		tn, ok := t.(*types.TypeName)
+		if !ok {
+			continue
+		}
+		m := exprFilter(tn.Type(), sel, x)
+		if m == nil {
+			continue
+		}
+
+		if found == nil || precedes(tn, found) {
+			found, obj = tn, m
+		}
+	}
+	if found == nil {
+		return nil, errors.New("type not found")
+	}
+
+	return obj, nil
+}
+
+// precedes reports whether the type definition a is preferred over b: the
+// definition at the package level over local ones, and then the earliest.
+func precedes(a, b *types.TypeName) bool {
+	aTop := a.Parent() == a.Pkg().Scope()
+	if bTop := b.Parent() == b.Pkg().Scope(); aTop != bTop {
+		return aTop
+	}
+
+	return a.Pos() < b.Pos()
+}
+
+func reducePointer(typ types.Type) (types.Type, bool) {
+	if pointer, ok := typ.(pointer); ok {
+		return pointer.Elem(), true
+	}
+	return typ, false
+}
+
+func objFromType(typ types.Type) object {
+	typ, _ = reducePointer(typ)
+
+	m, ok := typ.(object)
+	if !ok {
+		return nil
+	}
+
+	return m
+}
+
+func exprFilter(t types.Type, sel string, x string) object {
+	m := objFromType(t)
+	if m == nil {
+		return nil
+	}
+
+	obj := m.Obj()
+	if obj.Pkg() == nil || x != obj.Pkg().Name() || sel != obj.Name() {
+		return nil
+	}
+
+	return m
+}
+
+// walkType writes the code deep copying source of type m into sink. sel is the
+// selector of source relative to the receiver, as matched against the skips.
+func (a *app) walkType(source, sink, sel, x string, m types.Type, w io.Writer, imports map[string]string, skips skips, generating []object, depth int) error {
+	initial := depth == 0
+	if m == nil {
+		return nil
+	}
+
+	if a.maxDepth > 0 {
+		sinkDepth := strings.Count(sink, ".")
+		if sinkDepth >= a.maxDepth {
+			p := strings.Split(sink, ".")
+			stoppedAt := strings.TrimSuffix(fmt.Sprintf("%s.%s", generating[0], strings.Join(p[1:len(p)-1], ".")), ".")
+			a.warnOnce(fmt.Sprintf("reached max depth %d. stop recursion at %s", sinkDepth, stoppedAt), false)
+			if holdsReferences(m, map[types.Type]bool{}) {
+				a.comment(w, sel, "depth limit reached: shallow copy")
+			}
+			return nil
+		}
+	}
+
+	a.logger.Debugf("walking %s of type %s", source, m)
+
+	var needExported bool
+	switch v := m.(type) {
+	case *types.Named:
+		if v.Obj().Pkg() != nil && v.Obj().Pkg().Name() != x {
+			needExported = true
+		}
+	}
+
+	// Values of zero-size types carry no data, copying them is a no-op.
+	if a.skipZeroSize && !initial && isZeroSize(m) {
+		return nil
+	}
+
+	if !initial && a.isImmutable(m) {
+		a.comment(w, sel, "immutable via -treat-as-immutable")
+		a.stats.fieldsSkipped++
+		return nil
+	}
+
+	if name, given := a.sharedType(m); name != "" && !initial {
+		if given {
+			a.comment(w, sel, "shared via -share-type")
+		} else {
+			a.comment(w, sel, "shared, *%s is safe to share", name)
+		}
+		a.stats.fieldsSkipped++
+		return nil
+	}
+
+	if tp, ok := m.(*types.TypeParam); ok && !initial {
+		a.copyTypeParam(source, sink, sel, tp, w)
+		return nil
+	}
+
+	if v, ok := m.(methoder); ok && !initial && a.reuseDeepCopy(source, sink, sel, x, v, false, generating, w, imports) {
+		return nil
+	}
+
+	if name := a.unnamedElem(m, x, generating); name != "" && !initial {
+		a.comment(w, sel, "shallow copied, %s is unexported", name)
+		a.stats.fieldsSkipped++
+		return nil
+	}
+
+	depth++
+	under := m.Underlying()
+	switch v := under.(type) {
+	case *types.Struct:
+		// With -no-initial-assign, the fields of the receiver, and those of
+		// its fields of struct types, are assigned one by one.
+		unassigned := a.stats.unassigned
+		a.stats.unassigned = false
+		a.promoteFields(sel, v)
+		var copies []fieldCopy
+		for i := 0; i < v.NumFields(); i++ {
+			walk := a.walkField
+			if unassigned {
+				walk = a.assignField
+			}
+			if !a.sortFields {
+				if err := walk(source, sink, sel, x, v, i, needExported, w, imports, skips, generating, depth); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var b bytes.Buffer
+			if err := walk(source, sink, sel, x, v, i, needExported, &b, imports, skips, generating, depth); err != nil {
+				return err
+			}
+			copies = append(copies, fieldCopy{name: v.Field(i).Name(), code: b.Bytes()})
+		}
+
+		// With -sort-fields, the fields are copied in the order of their
+		// names, which doesn't change when they are reordered.
+		sort.SliceStable(copies, func(i, j int) bool {
+			return copies[i].name < copies[j].name
+		})
+		for _, c := range copies {
+			w.Write(c.code)
+		}
+	case *types.Slice:
+		kind := getElemType(v.Elem(), x, imports)
+
+		idx := a.tempName("i", depth)
+
+		elemSel := joinSel(sel, "[i]")
+
+		skipSlice, zeroSlice := a.isSkipped(skips, elemSel)
+		if skipSlice {
+			if zeroSlice {
+				a.comment(w, elemSel, "zeroed via %s", a.zeroedVia(elemSel))
+			} else {
+				a.comment(w, elemSel, "skipped via -skip")
+			}
+			a.stats.fieldsSkipped++
+		}
+
+		a.openNilGuard(w, source)
+		fmt.Fprintf(w, `%s = make([]%s, len(%s))
+`, sink, kind, source)
+
+		if !zeroSlice {
+			fmt.Fprintf(w, `copy(%s, %s)
+`, sink, source)
+		}
+
+		var b bytes.Buffer
+
+		if !skipSlice {
+			literals, err := a.literalSkips(skips, sel, true)
+			if err != nil {
+				return err
+			}
+
+			// Elements of basic types, or of types defined on them such as
+			// time.Duration, are fully copied by copy.
+			var elem bytes.Buffer
+			baseSel := "[" + idx + "]"
+			if !a.copiedByValue(v.Elem(), generating) {
+				if err := a.walkType(source+baseSel, sink+baseSel, elemSel, x, v.Elem(), &elem, imports, skips, generating, depth); err != nil {
+					return err
+				}
+			}
+
+			// The elements are already shallow copied, skipped elements
+			// are left out of the loop, unless they are zeroed.
+			for _, l := range literals {
+				if !l.zero && !hasCode(elem.Bytes()) {
+					a.comment(w, l.sel, "skipped via -skip")
+					continue
+				}
+
+				fmt.Fprintf(&b, "if %s == %s {\n", idx, l.lit)
+				if l.zero {
+					a.comment(&b, l.sel, "zeroed via %s", a.zeroedVia(l.sel))
+					fmt.Fprintf(&b, "%s[%s] = %s\n", sink, idx, zeroValue(v.Elem(), x, imports))
+				} else {
+					a.comment(&b, l.sel, "skipped via -skip")
+				}
+				fmt.Fprintf(&b, "continue\n}\n")
+			}
+			elem.WriteTo(&b)
+		}
+
+		if hasCode(b.Bytes()) {
+			fmt.Fprintf(w, `    for %s := range %s {
+`, idx, source)
+			a.checkContext(w)
+
+			b.WriteTo(w)
+
+			fmt.Fprintf(w, "}\n")
+		} else {
+			b.WriteTo(w)
+		}
+
+		a.closeNilGuard(w)
+	case *types.Array:
+		// The elements are already copied by value along with the array,
+		// only those holding references are walked.
+		idx := a.tempName("i", depth)
+
+		elemSel := joinSel(sel, "[i]")
+		if skipped, zero := a.isSkipped(skips, elemSel); skipped {
+			if zero {
+				a.comment(w, elemSel, "zeroed via %s", a.zeroedVia(elemSel))
+				fmt.Fprintf(w, "%s = %s{}\n", sink, getElemType(m, x, imports))
+			} else {
+				a.comment(w, elemSel, "skipped via -skip")
+			}
+			a.stats.fieldsSkipped++
+			break
+		}
+
+		var elem bytes.Buffer
+		baseSel := "[" + idx + "]"
+		if err := a.walkType(source+baseSel, sink+baseSel, elemSel, x, v.Elem(), &elem, imports, skips, generating, depth); err != nil {
+			return err
+		}
+
+		if hasCode(elem.Bytes()) {
+			fmt.Fprintf(w, "for %s := range %s {\n", idx, source)
+			a.checkContext(w)
+			elem.WriteTo(w)
+			fmt.Fprintf(w, "}\n")
+		} else {
+			elem.WriteTo(w)
+		}
+	case *types.Pointer:
+		fmt.Fprintf(w, "if %s != nil {\n", source)
+
+		if e, ok := v.Elem().(methoder); !ok || initial || !a.reuseDeepCopy(source, sink, sel, x, e, true, generating, w, imports) {
+			if a.errorOnExternalPointer && !initial && isExternal(v.Elem(), x) {
+				return fmt.Errorf("%s points to external type %s without a %s method; skip it or add the method", source, v.Elem(), a.reuseMethodName())
+			}
+
+			kind := getElemType(v.Elem(), x, imports)
 
-		if e, ok := v.Elem().(methoder); !ok || initial || !a.reuseDeepCopy(source, sink, e, true, generating, w) {
+			fmt.Fprintf(w, `%s = new(%s)
+	*%s = *%s
+`, sink, kind, sink, source)
+
+			// Only struct fields can be selected through the pointer, other
+			// element types have to be dereferenced explicitly.
+			elemSource, elemSink := source, sink
+			if _, ok := v.Elem().Underlying().(*types.Struct); !ok {
+				elemSource, elemSink = "(*"+source+")", "(*"+sink+")"
+			}
+
+			if err := a.walkType(elemSource, elemSink, sel, x, v.Elem(), w, imports, skips, generating, depth); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(w, "}\n")
+	case *types.Basic:
+		// Raw pointers and addresses can't be followed, the memory they
+		// refer to is shared with the copy.
+		if initial || (v.Kind() != types.UnsafePointer && v.Kind() != types.Uintptr) {
+			break
+		}
+		if a.strict && !a.noStrictUnsafe {
+			return fmt.Errorf("%s is an %s, which can't be deep copied; skip it", source, v)
+		}
+		a.comment(w, sel, "WARNING: %s field shared", v)
+		a.warnOnce(fmt.Sprintf("%s: %s shared with the copy", sel, v), true)
+	case *types.Interface:
+		impls, err := a.registeredImpls(m)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case initial:
+		case len(impls) > 0:
+			return a.copyImpls(source, sink, sel, x, impls, w, imports, skips, generating, depth)
+		case a.hasInterfaceDeepCopy(m, v) && a.returnsError(v, a.reuseMethodName(), generating):
+			retV := a.tempName("retV", 0)
+			fmt.Fprintf(w, `if %s != nil {
+	%s, err := %s.%s()
+	if err != nil {
+`, source, retV, source, a.reuseMethodName())
+			a.returnError(w, x, imports, sel+": %w", "err")
+			fmt.Fprintf(w, `}
+	%s = %s
+}
+`, sink, retV)
+		case a.hasInterfaceDeepCopy(m, v):
+			fmt.Fprintf(w, `if %s != nil {
+	%s = %s.%s()
+}
+`, source, sink, source, a.reuseMethodName())
+		case a.withError:
+			// The dynamic value can't be copied, failing the copy is
+			// preferred to sharing it.
+			fmt.Fprintf(w, "if %s != nil {\n", source)
+			a.returnError(w, x, imports, sel+": %T can't be deep copied", source)
+			fmt.Fprintf(w, "}\n")
+		default:
+			a.comment(w, sel, "interface value shared")
+		}
+	case *types.Signature:
+		if !initial {
+			a.comment(w, sel, "func value shared")
+		}
+	case *types.Chan:
+		a.stats.chans++
+
+		switch a.chanPolicyOrDefault() {
+		case chanNil:
+			fmt.Fprintf(w, `if %s != nil {
+	%s = nil
+}
+`, source, sink)
+		case chanShare:
+			// The channel is already shared by the shallow copy.
+			a.comment(w, sel, "channel shared via -chan")
+		default:
 			kind := getElemType(v.Elem(), x, imports)
 
-			fmt.Fprintf(w, `%s = new(%s)
-	*%s = *%s
-`, sink, kind, sink, source)
+			fmt.Fprintf(w, `if %s != nil {
+	%s = make(chan %s, cap(%s))
+}
+`, source, sink, kind, source)
+		}
+	case *types.Map:
+		kkind := getElemType(v.Key(), x, imports)
+		vkind := getElemType(v.Elem(), x, imports)
+
+		key, val := a.tempName("k", depth), a.tempName("v", depth)
+
+		elemSel := joinSel(sel, "[k]")
+
+		var skipKey, skipValue bool
+		skipped, zero := a.isSkipped(skips, elemSel)
+		if skipped {
+			if zero {
+				a.comment(w, elemSel, "zeroed via %s", a.zeroedVia(elemSel))
+			} else {
+				a.comment(w, elemSel, "skipped via -skip")
+			}
+			skipKey, skipValue = true, true
+			a.stats.fieldsSkipped++
+		}
+
+		if zero {
+			a.openNilGuard(w, source)
+			fmt.Fprintf(w, `%s = make(map[%s]%s, len(%s))
+	for %s := range %s {
+`, sink, kkind, vkind, source, key, source)
+			a.checkContext(w)
+			fmt.Fprintf(w, `%s[%s] = %s
+	}
+`, sink, key, zeroValue(v.Elem(), x, imports))
+			a.closeNilGuard(w)
+			break
+		}
+
+		// Interface keys are compared by their dynamic values, copying
+		// them could make the key refer to a different entry.
+		if _, ok := v.Key().Underlying().(*types.Interface); ok {
+			skipKey = true
+		}
+
+		a.openNilGuard(w, source)
+		fmt.Fprintf(w, `%s = make(map[%s]%s, len(%s))
+	for %s, %s := range %s {
+`, sink, kkind, vkind, source, key, val, source)
+		a.checkContext(w)
+
+		ksink, vsink := key, val
+
+		if !skipValue {
+			literals, err := a.literalSkips(skips, sel, false)
+			if err != nil {
+				return err
+			}
+			if _, ok := v.Key().Underlying().(*types.Basic); len(literals) > 0 && !ok {
+				return fmt.Errorf("skip selector %s: only maps with basic keys can be selected by a literal key", literals[0].sel)
+			}
+
+			for _, l := range literals {
+				fmt.Fprintf(w, "if %s == %s {\n", key, l.lit)
+				if l.zero {
+					a.comment(w, l.sel, "zeroed via %s", a.zeroedVia(l.sel))
+					fmt.Fprintf(w, "%s[%s] = %s\n", sink, key, zeroValue(v.Elem(), x, imports))
+				} else {
+					a.comment(w, l.sel, "skipped via -skip")
+					fmt.Fprintf(w, "%s[%s] = %s\n", sink, key, val)
+				}
+				fmt.Fprintf(w, "continue\n}\n")
+			}
+		}
+
+		var b bytes.Buffer
+
+		if !skipKey {
+			copyKSink := selToIdent(sink) + "_" + key
+			if err := a.walkType(key, copyKSink, elemSel, x, v.Key(), &b, imports, skips, generating, depth); err != nil {
+				return err
+			}
+
+			if hasCode(b.Bytes()) {
+				ksink = copyKSink
+				fmt.Fprintf(w, "var %s %s\n", ksink, kkind)
+			}
+			b.WriteTo(w)
+		}
+
+		b.Reset()
+
+		if !skipValue {
+			copyVSink := selToIdent(sink) + "_" + val
+			if err := a.walkType(val, copyVSink, elemSel, x, v.Elem(), &b, imports, skips, generating, depth); err != nil {
+				return err
+			}
+
+			if hasCode(b.Bytes()) {
+				vsink = copyVSink
+				fmt.Fprintf(w, "var %s %s\n", vsink, vkind)
+			}
+			b.WriteTo(w)
+		}
+
+		fmt.Fprintf(w, "%s[%s] = %s", sink, ksink, vsink)
+
+		fmt.Fprintf(w, "}\n")
+		a.closeNilGuard(w)
+	}
+
+	return nil
+}
+
+// fieldCopy is the code copying a field of a struct, with -sort-fields.
+type fieldCopy struct {
+	name string
+	code []byte
+}
+
+// walkField walks the field i of the struct v, copied from source to sink.
+// The fields of other packages are only walked if exported, as told by
+// needExported.
+func (a *app) walkField(source, sink, sel, x string, v *types.Struct, i int, needExported bool, w io.Writer, imports map[string]string, skips skips, generating []object, depth int) error {
+	field := v.Field(i)
+	fname := field.Name()
+	fieldSel := joinSel(sel, fname)
+	if needExported && !field.Exported() {
+		// The field can't be read from the generated code, whether
+		// it is set is only known when generating.
+		if a.withError && a.strict && holdsReferences(field.Type(), map[types.Type]bool{}) {
+			if skipped, _ := a.isSkipped(skips, fieldSel); !skipped {
+				return fmt.Errorf("%s.%s is unexported, it would be shared with the copy; skip it", source, fname)
+			}
+		}
+		return nil
+	}
+	if err := a.assertNonNil(source+"."+fname, fieldSel, field.Type(), w); err != nil {
+		return err
+	}
+	if a.ignoreUnexported && !field.Exported() {
+		if holdsReferences(field.Type(), map[types.Type]bool{}) {
+			a.comment(w, fieldSel, "shallow copied via -ignore-unexported")
+		}
+		a.stats.fieldsSkipped++
+		return nil
+	}
+	if !a.requireTag.Matches(v.Tag(i)) {
+		if holdsReferences(field.Type(), map[types.Type]bool{}) {
+			a.comment(w, fieldSel, "shallow copied, no %s tag", a.requireTag.String())
+		}
+		a.stats.fieldsSkipped++
+		return nil
+	}
+	if skipped, zero := a.isSkipped(skips, fieldSel); skipped {
+		if zero {
+			a.comment(w, fieldSel, "zeroed via %s", a.zeroedVia(fieldSel))
+			fmt.Fprintf(w, "%s.%s = %s\n", sink, fname, zeroValue(field.Type(), x, imports))
+		} else {
+			a.comment(w, fieldSel, "skipped via -skip")
+		}
+		a.stats.fieldsSkipped++
+		return nil
+	}
+	if a.skipZeroSize && isZeroSize(field.Type()) {
+		a.stats.fieldsSkipped++
+		return nil
+	}
+	a.stats.fieldsWalked++
+	if !a.fieldDoc || a.noComments {
+		return a.walkType(source+"."+fname, sink+"."+fname, fieldSel, x, field.Type(), w, imports, skips, generating, depth)
+	}
+
+	// Only the fields whose copy takes code are documented, the
+	// others are copied along with the struct.
+	var b bytes.Buffer
+	if err := a.walkType(source+"."+fname, sink+"."+fname, fieldSel, x, field.Type(), &b, imports, skips, generating, depth); err != nil {
+		return err
+	}
+	if hasCode(b.Bytes()) {
+		writeFieldDoc(w, field, x, imports)
+	}
+	w.Write(b.Bytes())
+
+	return nil
+}
+
+// openNilGuard opens the block copying the slice or map source when it isn't
+// nil. With -non-nil-collections, nil ones are copied as empty ones instead.
+func (a *app) openNilGuard(w io.Writer, source string) {
+	if !a.nonNilCollections {
+		fmt.Fprintf(w, "if %s != nil {\n", source)
+	}
+}
+
+// closeNilGuard closes the block opened by openNilGuard.
+func (a *app) closeNilGuard(w io.Writer) {
+	if !a.nonNilCollections {
+		fmt.Fprintf(w, "}\n")
+	}
+}
+
+// unnamedElem returns the first unexported type of another package that the
+// copy of a pointer, slice, map or channel of type t would have to name, as
+// when generating functions in another package, or "" if there is none.
+// Pointers to types whose method is reused don't name their element.
+func (a *app) unnamedElem(t types.Type, x string, generating []object) string {
+	switch v := t.Underlying().(type) {
+	case *types.Pointer:
+		if e, ok := v.Elem().(methoder); ok {
+			if method, _ := a.hasDeepCopy(e, generating); method != "" && !a.isForcedDeep(e, generating) {
+				return ""
+			}
+		}
+		return foreignUnexported(v.Elem(), x)
+	case *types.Slice:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Chan:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Map:
+		if name := foreignUnexported(v.Key(), x); name != "" {
+			return name
+		}
+		return foreignUnexported(v.Elem(), x)
+	default:
+		return ""
+	}
+}
+
+// foreignUnexported returns the first unexported named type of a package
+// other than x in the type expression t, or "".
+func foreignUnexported(t types.Type, x string) string {
+	switch v := t.(type) {
+	case *types.Named:
+		if obj := v.Obj(); !obj.Exported() && obj.Pkg() != nil && obj.Pkg().Name() != x {
+			return obj.Pkg().Name() + "." + obj.Name()
+		}
+		return ""
+	case *types.Pointer:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Slice:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Array:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Chan:
+		return foreignUnexported(v.Elem(), x)
+	case *types.Map:
+		if name := foreignUnexported(v.Key(), x); name != "" {
+			return name
+		}
+		return foreignUnexported(v.Elem(), x)
+	case *types.Struct:
+		for i := 0; i < v.NumFields(); i++ {
+			if name := foreignUnexported(v.Field(i).Type(), x); name != "" {
+				return name
+			}
+		}
+		return ""
+	default:
+		return ""
+	}
+}
+
+// isImmutable reports whether t, or the type t points to, was passed to
+// -treat-as-immutable, by its fully qualified name.
+func (a *app) isImmutable(t types.Type) bool {
+	if len(a.immutable) == 0 {
+		return false
+	}
+
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return false
+	}
+
+	return a.immutable[n.Obj().Pkg().Path()+"."+n.Obj().Name()]
+}
+
+// comment writes a comment describing the disposition of the value at sel,
+// unless comments are disabled.
+func (a *app) comment(w io.Writer, sel, format string, args ...interface{}) {
+	if sel == "" {
+		return
+	}
+
+	caveat := sel + ": " + fmt.Sprintf(format, args...)
+	a.logger.Debugf("%s", caveat)
+	if a.noComments {
+		return
+	}
+
+	if !containsString(a.stats.caveats, caveat) {
+		a.stats.caveats = append(a.stats.caveats, caveat)
+	}
+
+	fmt.Fprintf(w, "// %s\n", caveat)
+}
+
+// writeFieldDoc writes the comment documenting the copy of field with
+// -field-doc. Its type is named as in the generated code, without importing
+// the packages only the comment refers to.
+func writeFieldDoc(w io.Writer, field *types.Var, x string, imports map[string]string) {
+	names := make(map[string]string, len(imports))
+	for name, path := range imports {
+		names[name] = path
+	}
+
+	fmt.Fprintf(w, "// copying field: %s (type: %s)\n", field.Name(), getElemType(field.Type(), x, names))
+}
+
+// copiedByValue reports whether values of type t, a basic type or a type
+// defined on one, are fully copied by assignment. unsafe.Pointer and uintptr
+// values, which refer to memory, and values with a method to reuse or marked
+// immutable, are left to walkType.
+func (a *app) copiedByValue(t types.Type, generating []object) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok || b.Kind() == types.UnsafePointer || b.Kind() == types.Uintptr || a.isImmutable(t) {
+		return false
+	}
+	if v, ok := t.(methoder); ok {
+		if method, _ := a.hasDeepCopy(v, generating); method != "" || a.hasDeepCopyInto(v, generating) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasCode reports whether the generated code has anything but comments.
+func hasCode(b []byte) bool {
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "//") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// holdsReferences reports whether values of type t can refer to other memory,
+// which a shallow copy would share.
+func holdsReferences(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
 
-			a.walkType(source, sink, x, v.Elem(), w, imports, skips, generating, depth)
+	switch v := t.Underlying().(type) {
+	case *types.Basic:
+		return v.Kind() == types.UnsafePointer
+	case *types.Struct:
+		for i := 0; i < v.NumFields(); i++ {
+			if holdsReferences(v.Field(i).Type(), seen) {
+				return true
+			}
 		}
+		return false
+	case *types.Array:
+		return holdsReferences(v.Elem(), seen)
+	default:
+		return true
+	}
+}
 
-		fmt.Fprintf(w, "}\n")
-	case *types.Chan:
-		kind := getElemType(v.Elem(), x, imports)
+// zeroSuffix marks a skip selector whose value is zeroed in the copy, instead
+// of being shallow copied.
+const zeroSuffix = "!"
 
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make(chan %s, cap(%s))
+// isSkipped reports whether sel, or a selector it is promoted to through
+// embedded structs, is skipped.
+func (a *app) isSkipped(skips skips, sel string) (skipped, zero bool) {
+	if skipped, zero = a.matchSkip(skips, sel); skipped {
+		return skipped, zero
+	}
+
+	for _, promoted := range a.promotedSels(sel) {
+		if skipped, zero = a.matchSkip(skips, promoted); skipped {
+			return skipped, zero
+		}
+	}
+
+	return false, false
 }
-`, source, sink, kind, source)
-	case *types.Map:
-		kkind := getElemType(v.Key(), x, imports)
-		vkind := getElemType(v.Elem(), x, imports)
 
-		key, val := "k", "v"
+// promoteFields records the selectors of the fields promoted to the struct
+// st at sel through its embedded structs, such as Timeout for
+// Config.Timeout, as long as they aren't shadowed by the other fields.
+func (a *app) promoteFields(sel string, st *types.Struct) {
+	seen := map[*types.Struct]bool{st: true}
+
+	var visit func(path string, s *types.Struct)
+	visit = func(path string, s *types.Struct) {
+		for i := 0; i < s.NumFields(); i++ {
+			field := s.Field(i)
+			fieldPath := joinSel(path, field.Name())
+			if path != "" {
+				if obj, _, _ := types.LookupFieldOrMethod(st, true, field.Pkg(), field.Name()); obj == field {
+					if a.stats.promoted == nil {
+						a.stats.promoted = map[string][]string{}
+					}
+					full := joinSel(sel, fieldPath)
+					a.stats.promoted[full] = append(a.stats.promoted[full], joinSel(sel, field.Name()))
+				}
+			}
 
-		if depth > 1 {
-			key += strconv.Itoa(depth)
-			val += strconv.Itoa(depth)
+			if !field.Embedded() {
+				continue
+			}
+			t, _ := reducePointer(field.Type())
+			if es, ok := t.Underlying().(*types.Struct); ok && !seen[es] {
+				seen[es] = true
+				visit(fieldPath, es)
+			}
 		}
+	}
+	visit("", st)
+}
 
-		// Sel is only used for skips
-		sel := "[k]"
-		if !initial {
-			sel = sink + sel
+// promotedSels returns the sorted selectors sel is promoted to, by replacing
+// a promoted field it starts with.
+func (a *app) promotedSels(sel string) []string {
+	var sels []string
+	for full, promoted := range a.stats.promoted {
+		if !strings.HasPrefix(sel, full) || len(sel) > len(full) && sel[len(full)] != '.' && sel[len(full)] != '[' {
+			continue
+		}
+		for _, p := range promoted {
+			sels = append(sels, p+sel[len(full):])
 		}
-		sel = sel[strings.Index(sel, ".")+1:]
+	}
+	sort.Strings(sels)
 
-		var skipKey, skipValue bool
-		if skips.Contains(sel) {
-			skipKey, skipValue = true, true
+	return sels
+}
+
+// matchSkip reports whether sel is skipped, and whether it is zeroed in the
+// copy, either because its selector ends with the zero suffix or because all
+// skipped values are zeroed. The use of the matching selector is recorded.
+// Selectors matching a -skip-pattern are skipped too.
+func (a *app) matchSkip(skips skips, sel string) (skipped, zero bool) {
+	switch {
+	case skips.Contains(sel + zeroSuffix):
+		sel, zero = sel+zeroSuffix, true
+	case skips.Contains(sel):
+		zero = a.skipZero
+	default:
+		glob, ok := matchGlobSkip(skips, sel)
+		if !ok {
+			skipped = a.skipPatterns.Match(sel)
+			return skipped, skipped && a.skipZero
 		}
+		sel, zero = glob, a.skipZero || strings.HasSuffix(glob, zeroSuffix)
+	}
 
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make(map[%s]%s, len(%s))
-	for %s, %s := range %s {
-`, source, sink, kkind, vkind, source, key, val, source)
+	if a.stats.usedSkips != nil {
+		a.stats.usedSkips[sel] = true
+	}
 
-		ksink, vsink := key, val
+	return true, zero
+}
 
-		var b bytes.Buffer
+// zeroedVia returns the flag selecting sel to be zeroed, either -zero or
+// -skip with the zero suffix.
+func (a *app) zeroedVia(sel string) string {
+	if _, ok := matchGlobSkip(a.stats.zero, sel); ok || a.stats.zero.Contains(sel) {
+		return "-zero"
+	}
 
-		if !skipKey {
-			copyKSink := selToIdent(sink) + "_" + key
-			a.walkType(key, copyKSink, x, v.Key(), &b, imports, skips, generating, depth)
+	return "-skip"
+}
 
-			if b.Len() > 0 {
-				ksink = copyKSink
-				fmt.Fprintf(w, "var %s %s\n", ksink, kkind)
-				b.WriteTo(w)
-			}
+// assertNonNil writes a guard panicking when source is nil, if its selector
+// matches an -assert-non-nil selector of the current type.
+func (a *app) assertNonNil(source, sel string, t types.Type, w io.Writer) error {
+	match := sel
+	if _, ok := a.stats.nonNil[sel]; !ok {
+		if match, ok = matchGlobSkip(a.nonNilSkips(), sel); !ok {
+			return nil
 		}
+	}
 
-		b.Reset()
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+	default:
+		return fmt.Errorf("-assert-non-nil %s: %s of type %s can't be nil", match, sel, t)
+	}
+	a.stats.nonNil[match] = true
 
-		if !skipValue {
-			copyVSink := selToIdent(sink) + "_" + val
-			a.walkType(val, copyVSink, x, v.Elem(), &b, imports, skips, generating, depth)
+	fmt.Fprintf(w, "if %s == nil {\npanic(%q)\n}\n", source, "deep copy: "+sel+" is nil")
 
-			if b.Len() > 0 {
-				vsink = copyVSink
-				fmt.Fprintf(w, "var %s %s\n", vsink, vkind)
-				b.WriteTo(w)
+	return nil
+}
+
+// nonNilSkips returns the -assert-non-nil selectors of the current type as
+// skips, to be matched like them.
+func (a *app) nonNilSkips() skips {
+	s := make(skips, len(a.stats.nonNil))
+	for sel := range a.stats.nonNil {
+		s[sel] = struct{}{}
+	}
+
+	return s
+}
+
+// matchGlobSkip returns the first skip selector, in sorted order, with * or ?
+// wildcards that matches sel. Wildcards don't match across fields, slice
+// elements or map entries. Globs of a single field name, such as cached*,
+// match fields of that name in every struct that is walked.
+func matchGlobSkip(skips skips, sel string) (string, bool) {
+	var globs []string
+	for s := range skips {
+		if strings.ContainsAny(s, "*?") {
+			globs = append(globs, s)
+		}
+	}
+	sort.Strings(globs)
+
+	for _, glob := range globs {
+		pattern, target := strings.TrimSuffix(glob, zeroSuffix), sel
+		if !strings.ContainsAny(pattern, ".[") {
+			target = target[strings.LastIndex(target, ".")+1:]
+			if strings.HasSuffix(target, "]") {
+				continue
 			}
 		}
 
-		fmt.Fprintf(w, "%s[%s] = %s", sink, ksink, vsink)
+		pattern = globEscaper.Replace(pattern)
+		if ok, _ := path.Match(pattern, strings.ReplaceAll(target, ".", "/")); ok {
+			return glob, true
+		}
+	}
+
+	return "", false
+}
+
+// globEscaper turns a selector glob into a path.Match pattern, so that
+// wildcards stop at field boundaries and [i] and [k] are matched literally.
+var globEscaper = strings.NewReplacer(".", "/", "[", `\[`, "]", `\]`)
+
+// literalSkip is a skip selector of a single slice element or map entry, by
+// its literal index or key.
+type literalSkip struct {
+	sel  string
+	lit  string
+	zero bool
+}
+
+// literalSkips returns the skips of the elements of the slice or map at sel
+// that are selected by a literal, such as Layers[0] or Labels["app"]. Slice
+// elements can only be selected by an integer index.
+func (a *app) literalSkips(skips skips, sel string, index bool) ([]literalSkip, error) {
+	prefix := joinSel(sel, "[")
+
+	var literals []literalSkip
+	for s := range skips {
+		trimmed := strings.TrimSuffix(s, zeroSuffix)
+		if !strings.HasPrefix(trimmed, prefix) || !strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+
+		expr, err := parser.ParseExpr(trimmed[len(prefix) : len(trimmed)-1])
+		if err != nil {
+			continue
+		}
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		if index && lit.Kind != token.INT {
+			return nil, resolveError{fmt.Errorf("skip selector %s: slice elements are selected by an integer index", s)}
+		}
+
+		if a.stats.usedSkips != nil {
+			a.stats.usedSkips[s] = true
+		}
+		a.stats.fieldsSkipped++
+
+		literals = append(literals, literalSkip{sel: trimmed, lit: lit.Value, zero: a.skipZero || trimmed != s})
+	}
+	sort.Slice(literals, func(i, j int) bool {
+		return literals[i].sel < literals[j].sel
+	})
+
+	return literals, nil
+}
 
-		fmt.Fprintf(w, "}\n}\n")
+// unusedSkips returns the sorted skip selectors that matched nothing while
+// generating the current method.
+func (a *app) unusedSkips(skips skips) []string {
+	var unused []string
+	for sel := range skips {
+		if sel != "" && !a.stats.usedSkips[sel] {
+			unused = append(unused, sel)
+		}
+	}
+	sort.Strings(unused)
+
+	return unused
+}
+
+// zeroValue returns an expression of the zero value of t.
+func zeroValue(t types.Type, x string, imports map[string]string) string {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + getElemType(t, x, imports) + ")"
+	}
+
+	switch v := t.Underlying().(type) {
+	case *types.Basic:
+		switch info := v.Info(); {
+		case info&types.IsString != 0:
+			return `""`
+		case info&types.IsBoolean != 0:
+			return "false"
+		case info&types.IsNumeric != 0:
+			return "0"
+		default:
+			return "nil"
+		}
+	case *types.Struct, *types.Array:
+		return getElemType(t, x, imports) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+// joinSel appends a field name, or an index such as [i] or [k], to the
+// selector sel.
+func joinSel(sel, elem string) string {
+	if sel == "" || strings.HasPrefix(elem, "[") {
+		return sel + elem
+	}
+
+	return sel + "." + elem
+}
+
+// gcSizes are the sizes used to detect zero-size types. Whether a type has
+// zero size doesn't depend on the architecture.
+var gcSizes = types.SizesFor("gc", "amd64")
+
+// isZeroSize reports whether values of type t occupy no memory.
+func isZeroSize(t types.Type) bool {
+	return gcSizes.Sizeof(t) == 0
+}
+
+// isExternal reports whether t is a named type declared outside of package x.
+func isExternal(t types.Type, x string) bool {
+	n, ok := t.(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Name() != x
+}
+
+// newImports returns the imports of a file generated for package p. The names
+// declared by the package are reserved with an empty path, so that packages
+// of the same name are imported with an alias instead of colliding with them.
+// Functions are generated in another package, whose names are unknown.
+func (a *app) newImports(p *packages.Package) map[string]string {
+	imports := map[string]string{}
+	if p.Types == nil || a.funcMode {
+		return imports
+	}
+
+	for _, name := range p.Types.Scope().Names() {
+		imports[name] = ""
 	}
 
+	return imports
 }
 
 var importSanitizerRE = regexp.MustCompile(`\W`)
 
 func getElemType(t types.Type, x string, imports map[string]string) string {
-	kind := types.TypeString(t, func(p *types.Package) string {
-		name := p.Name()
+	// Generic types are only named by the generated code within their own
+	// declarations, instantiated with their type parameters.
+	if n, ok := t.(*types.Named); ok && n.TypeParams().Len() > 0 && n.TypeArgs().Len() == 0 {
+		name := n.Obj().Name()
+		if q := qualifier(x, imports)(n.Obj().Pkg()); q != "" {
+			name = q + "." + name
+		}
+		return name + typeParamNames(n)
+	}
+
+	kind := types.TypeString(t, qualifier(x, imports))
+
+	// Older versions of go/types render the empty interface with a space.
+	return strings.ReplaceAll(kind, "interface {}", "interface{}")
+}
+
+// qualifier names the packages of the types used in the generated code of
+// package x, adding them to the imports.
+func qualifier(x string, imports map[string]string) types.Qualifier {
+	return func(p *types.Package) string {
+		name, pkgPath := p.Name(), unvendoredPath(p.Path())
 		if name != x {
-			if path, ok := imports[name]; ok && path != p.Path() {
-				name = importSanitizerRE.ReplaceAllString(p.Path(), "_")
+			if path, ok := imports[name]; ok && path != pkgPath {
+				name = importSanitizerRE.ReplaceAllString(pkgPath, "_")
 			}
-			imports[name] = p.Path()
+			// Paths of a single element, as those of the standard
+			// library, are sanitized to the name itself.
+			for path, ok := imports[name]; ok && path != pkgPath; path, ok = imports[name] {
+				name += "_"
+			}
+			imports[name] = pkgPath
 			return name
 		}
 		return ""
-	})
+	}
+}
+
+var (
+	fmtPackage     = types.NewPackage("fmt", "fmt")
+	contextPackage = types.NewPackage("context", "context")
+)
+
+// depthMethodName returns the name of the unexported method tracking the
+// depth of the copy, that method delegates to.
+func depthMethodName(method string) string {
+	return strings.ToLower(method[:1]) + method[1:] + "Depth"
+}
+
+// holdsGenerating reports whether any of the types being generated holds
+// values of one of them, copied by calling its method.
+func holdsGenerating(generating []object) bool {
+	for _, g := range generating {
+		if reachesGenerating(g.Underlying(), generating, map[types.Type]bool{}) {
+			return true
+		}
+	}
 
-	return kind
+	return false
 }
 
-func (a *app) hasDeepCopy(v methoder, generating []object) (hasMethod, isPointer bool) {
-	for _, t := range generating {
-		if types.Identical(v, t) {
-			return true, a.isPtrRecv
+// reachesGenerating reports whether the values of type t are, or hold, values
+// of the types being generated.
+func reachesGenerating(t types.Type, generating []object, seen map[types.Type]bool) bool {
+	if isGenerating(t, generating) {
+		return true
+	}
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch v := t.Underlying().(type) {
+	case *types.Pointer:
+		return reachesGenerating(v.Elem(), generating, seen)
+	case *types.Slice:
+		return reachesGenerating(v.Elem(), generating, seen)
+	case *types.Array:
+		return reachesGenerating(v.Elem(), generating, seen)
+	case *types.Map:
+		return reachesGenerating(v.Key(), generating, seen) || reachesGenerating(v.Elem(), generating, seen)
+	case *types.Struct:
+		for i := 0; i < v.NumFields(); i++ {
+			if reachesGenerating(v.Field(i).Type(), generating, seen) {
+				return true
+			}
 		}
 	}
 
+	return false
+}
+
+// returnsErrors reports whether the generated methods return an error along
+// with the copy.
+func (a *app) returnsErrors() bool {
+	return a.withError || a.withContext
+}
+
+// contextParam returns the context parameter of the generated methods,
+// followed by sep, or "" without -with-context.
+func (a *app) contextParam(x string, imports map[string]string, sep string) string {
+	if !a.withContext {
+		return ""
+	}
+
+	return "ctx " + qualifier(x, imports)(contextPackage) + ".Context" + sep
+}
+
+// checkContext writes the check of the context of the generated method,
+// returning its error once it is done, with -with-context.
+func (a *app) checkContext(w io.Writer) {
+	if a.withContext {
+		fmt.Fprintf(w, `if err := ctx.Err(); err != nil {
+	return %s, err
+}
+`, a.stats.errZero)
+	}
+}
+
+// returnError writes the return of an error of the generated method, built by
+// fmt.Errorf from format and the expressions args.
+func (a *app) returnError(w io.Writer, x string, imports map[string]string, format string, args ...string) {
+	fmt.Fprintf(w, "return %s, %s.Errorf(%q, %s)\n", a.stats.errZero, qualifier(x, imports)(fmtPackage), format, strings.Join(args, ", "))
+}
+
+// hasDeepCopy returns the name of the method deep copying v, if any, and
+// whether it returns a pointer. Types being generated use the generated
+// method, while other types are searched for the reused method. Named
+// interfaces have none, their dynamic values are copied by walkType.
+func (a *app) hasDeepCopy(v methoder, generating []object) (method string, isPointer bool) {
+	if types.IsInterface(v) {
+		return "", false
+	}
+	if isGenerating(v, generating) {
+		return a.methodNameOrDefault(v), a.isPtrRecv
+	}
+
 	for i := 0; i < v.NumMethods(); i++ {
 		m := v.Method(i)
-		if m.Name() != "DeepCopy" {
+		name := m.Name()
+		if !containsString(a.reuseMethodNames(), name) {
+			continue
+		}
+
+		// Unexported methods can only be called from their own package.
+		if !m.Exported() && (a.funcMode || len(generating) > 0 && m.Pkg() != generating[0].Obj().Pkg()) {
 			continue
 		}
 
@@ -538,7 +3332,7 @@ func (a *app) hasDeepCopy(v methoder, generating []object) (hasMethod, isPointer
 			continue
 		}
 
-		if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		if sig.Params().Len() != 0 || !a.copyResults(sig.Results()) {
 			continue
 		}
 
@@ -547,35 +3341,231 @@ func (a *app) hasDeepCopy(v methoder, generating []object) (hasMethod, isPointer
 		sigType, _ := reducePointer(sig.Recv().Type())
 
 		if !types.Identical(retType, sigType) {
-			return false, false
+			return "", false
 		}
 
-		return true, retPointer
+		return name, retPointer
 	}
 
-	return false, false
+	return "", false
+}
+
+// copyResults reports whether the results of a method are those of a copy:
+// the copy alone, or with -with-error, the copy and an error.
+func (a *app) copyResults(results *types.Tuple) bool {
+	switch results.Len() {
+	case 1:
+		return true
+	case 2:
+		return a.returnsErrors() && types.Identical(results.At(1).Type(), types.Universe.Lookup("error").Type())
+	default:
+		return false
+	}
 }
 
-func (a *app) reuseDeepCopy(source, sink string, v methoder, pointer bool, generating []object, w io.Writer) bool {
-	hasMethod, isPointer := a.hasDeepCopy(v, generating)
+// returnsError reports whether the method of v returns an error along with
+// the copy. The methods being generated do with -with-error or -with-context.
+func (a *app) returnsError(v methoder, method string, generating []object) bool {
+	if isGenerating(v, generating) {
+		return a.returnsErrors()
+	}
 
-	if hasMethod {
-		if pointer == isPointer {
-			fmt.Fprintf(w, "%s = %s.DeepCopy()\n", sink, source)
-		} else if pointer {
-			fmt.Fprintf(w, `retV := %s.DeepCopy()
-	%s = &retV
-`, source, sink)
+	for i := 0; i < v.NumMethods(); i++ {
+		if m := v.Method(i); m.Name() == method {
+			sig, ok := m.Type().(*types.Signature)
+			return ok && sig.Results().Len() == 2
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasInterfaceDeepCopy reports whether the interface declares the reused
+// method, returning the interface type itself.
+func (a *app) hasInterfaceDeepCopy(t types.Type, iface *types.Interface) bool {
+	name := a.reuseMethodName()
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if m.Name() != name {
+			continue
+		}
+
+		sig, ok := m.Type().(*types.Signature)
+		if !ok || sig.Params().Len() != 0 || !a.copyResults(sig.Results()) {
+			return false
+		}
+
+		return types.Identical(sig.Results().At(0).Type(), t)
+	}
+
+	return false
+}
+
+func (a *app) reuseDeepCopy(source, sink, sel, x string, v methoder, pointer bool, generating []object, w io.Writer, imports map[string]string) bool {
+	method, isPointer := a.hasDeepCopy(v, generating)
+	var into string
+	if method == "" {
+		into = a.hasDeepCopyInto(v, generating)
+	}
+	if (method != "" || into != "") && a.isForcedDeep(v, generating) {
+		if a.noReuse {
+			a.warnOnce(fmt.Sprintf("not reusing %s.%s, inlining its copy via -no-reuse", v, method+into), false)
+		}
+		return false
+	}
+
+	// Types with only a method copying into a pointer, as the DeepCopyInto
+	// methods of Kubernetes types, are copied into the sink.
+	if into != "" {
+		if pointer {
+			fmt.Fprintf(w, `%s = new(%s)
+	%s.%s(%s)
+`, sink, getElemType(v, x, imports), source, into, sink)
 		} else {
-			fmt.Fprintf(w, `{
-	retV := %s.DeepCopy()
-	%s = *retV
+			fmt.Fprintf(w, "%s.%s(&%s)\n", source, into, sink)
+		}
+		return true
+	}
+
+	if method == "" {
+		return false
+	}
+
+	// The context is passed on to the methods being generated, and so is
+	// the depth of the value, counted in fields from the receiver. They are
+	// only called below the max depth.
+	var args []string
+	if a.withContext && isGenerating(v, generating) {
+		args = append(args, "ctx")
+	}
+	if a.stats.trackDepth && isGenerating(v, generating) {
+		depth := strings.Count(sel, ".") + 1
+		method = depthMethodName(method)
+		args = append(args, fmt.Sprintf("d+%d", depth))
+		fmt.Fprintf(w, "if d+%d < %d {\n", depth, a.maxDepth)
+		defer fmt.Fprintf(w, "}\n")
+	}
+
+	call := fmt.Sprintf("%s.%s(%s)", source, method, strings.Join(args, ", "))
+	if n, ok := v.(*types.Named); ok && a.funcMode && isGenerating(n, generating) {
+		// Generated functions take the value or the pointer explicitly.
+		arg := source
+		if isPointer && !pointer {
+			arg = "&" + source
+		} else if !isPointer && pointer {
+			arg = "*" + source
+		}
+		call = fmt.Sprintf("%s%s(%s)", method, n.Obj().Name(), strings.Join(append(args, arg), ", "))
+	}
+
+	retV := a.tempName("retV", 0)
+	if a.returnsError(v, method, generating) {
+		ret := retV
+		if pointer && !isPointer {
+			ret = "&" + retV
+		} else if !pointer && isPointer {
+			ret = "*" + retV
+		}
+		fmt.Fprintf(w, `{
+	%s, err := %s
+	if err != nil {
+`, retV, call)
+		a.returnError(w, x, imports, sel+": %w", "err")
+		fmt.Fprintf(w, `}
+	%s = %s
 }
-`, source, sink)
+`, sink, ret)
+	} else if pointer == isPointer {
+		fmt.Fprintf(w, "%s = %s\n", sink, call)
+	} else if pointer {
+		fmt.Fprintf(w, `%s := %s
+	%s = &%s
+`, retV, call, sink, retV)
+	} else {
+		fmt.Fprintf(w, `{
+	%s := %s
+	%s = *%s
+}
+`, retV, call, sink, retV)
+	}
+
+	return true
+}
+
+// hasDeepCopyInto returns the name of the method of v copying its value into
+// a pointer to another value, such as DeepCopyInto(out *T), if any. The types
+// being generated use their generated method instead.
+func (a *app) hasDeepCopyInto(v methoder, generating []object) string {
+	if isGenerating(v, generating) {
+		return ""
+	}
+
+	for i := 0; i < v.NumMethods(); i++ {
+		m := v.Method(i)
+		name := m.Name()
+		if !strings.HasSuffix(name, "Into") || !containsString(a.reuseMethodNames(), strings.TrimSuffix(name, "Into")) {
+			continue
+		}
+
+		// Unexported methods can only be called from their own package.
+		if !m.Exported() && (a.funcMode || len(generating) > 0 && m.Pkg() != generating[0].Obj().Pkg()) {
+			continue
+		}
+
+		sig, ok := m.Type().(*types.Signature)
+		if !ok || sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+			continue
+		}
+
+		if !types.Identical(sig.Params().At(0).Type(), types.NewPointer(v)) {
+			continue
+		}
+
+		return name
+	}
+
+	return ""
+}
+
+// isGenerating reports whether t is one of the types being generated.
+func isGenerating(t types.Type, generating []object) bool {
+	for _, g := range generating {
+		if types.Identical(t, g) {
+			return true
 		}
 	}
 
-	return hasMethod
+	return false
+}
+
+// isForcedDeep reports whether the existing method of v is not reused, with
+// -no-reuse or -force-deep. The methods of the types being generated are
+// always used.
+func (a *app) isForcedDeep(v methoder, generating []object) bool {
+	if isGenerating(v, generating) {
+		return false
+	}
+
+	if a.noReuse {
+		return true
+	}
+
+	n, ok := v.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return false
+	}
+
+	return a.forceDeep[n.Obj().Pkg().Path()+"."+n.Obj().Name()]
 }
 
 func selToIdent(sel string) string {
@@ -585,6 +3575,8 @@ func selToIdent(sel string) string {
 		switch r {
 		case '[', '.':
 			return '_'
+		case '(', ')', '*':
+			return -1
 		default:
 			return r
 		}
@@ -2,10 +2,31 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
 )
 
 func Test_run(t *testing.T) {
@@ -16,15 +37,58 @@ func Test_run(t *testing.T) {
 		pointer  bool
 		skips    skipsVal
 		maxdepth int
-		want     []byte
+
+		bothReceivers bool
+		methodName    string
+		reuseMethod   string
+		ptrMethodName string
+		funcPackage   string
+		receiver      string
+		tempPrefix    string
+		outputVar     string
+		chanPolicy    chanPolicy
+		immutable     map[string]bool
+		shared        map[string]bool
+		forceDeep     map[string]bool
+		noReuse       bool
+		nonNil        map[string]skips
+		zero          map[string]skips
+		withError     bool
+		withContext   bool
+		ifaceImpls    ifaceImplsVal
+
+		ignoreUnexported       bool
+		requireTag             tagFilter
+		errorOnExternalPointer bool
+		strict                 bool
+		noStrictUnsafe         bool
+		strictSkips            bool
+		skipZero               bool
+		skipByTag              tagSkipsVal
+		skipPatterns           skipPatternsVal
+		skipZeroSize           bool
+		noComments             bool
+		fieldDoc               bool
+		noInitialAssign        bool
+		sortFields             bool
+		guardNilReceiver       bool
+		nonNilCollections      bool
+		embedSource            bool
+		maxLineLength          int
+
+		want    []byte
+		wantErr string
 	}{
 		{name: "foo", types: typesVal{"Foo"}, path: "./testdata", want: []byte(FooFile)},
 		{name: "foo - pointer", types: typesVal{"Foo"}, pointer: true, path: "./testdata", want: []byte(FooPointerFile)},
-		{name: "foo - pointer, skip slice", types: typesVal{"Foo"}, pointer: true, skips: skipsVal{{"Slice": struct{}{}}}, path: "./testdata", want: []byte(FooPointerSkipSliceFile)},
+		{name: "foo - pointer, nil guard", types: typesVal{"Foo"}, pointer: true, guardNilReceiver: true, path: "./testdata", want: []byte(FooPointerGuardFile)},
+		{name: "foo - pointer, skip slice", types: typesVal{"Foo"}, pointer: true, skips: skipsVal{{"Map[k].Slice": struct{}{}}}, path: "./testdata", want: []byte(FooPointerSkipSliceFile)},
 		{name: "foo, skip map member", types: typesVal{"Foo"}, skips: skipsVal{{"Map[k]": struct{}{}}}, path: "./testdata", want: []byte(FooSkipMapFile)},
 		{name: "alpha - with DeepCopy method", types: typesVal{"Alpha"}, path: "./testdata", want: []byte(AlphaPointer)},
 		{name: "slicepointer, skip slice member", types: typesVal{"SlicePointer"}, skips: skipsVal{{"[i]": struct{}{}}}, path: "./testdata", want: []byte(SlicePointer)},
 		{name: "foo, alpha, skips", types: typesVal{"Foo", "Alpha"}, skips: skipsVal{{"Map[k]": struct{}{}, "ch": struct{}{}}, {"D": struct{}{}, "E": struct{}{}}}, path: "./testdata", want: []byte(FooAlphaSkips)},
+		{name: "alpha, foo, skips", types: typesVal{"Alpha", "Foo"}, skips: skipsVal{{"D": struct{}{}, "E": struct{}{}}, {"Map[k]": struct{}{}, "ch": struct{}{}}}, path: "./testdata", want: []byte(FooAlphaSkips)},
+		{name: "foo, alpha, skip patterns", types: typesVal{"Foo", "Alpha"}, skipPatterns: skipPatternsVal{regexp.MustCompile(`^ch$|^Map\[k\]$`), regexp.MustCompile(`^[DE]$`)}, strictSkips: true, path: "./testdata", want: []byte(FooAlphaSkips)},
 		{name: "issue 3, struct with slice of simple structs", types: typesVal{"I3WithSlice"}, pointer: true, path: "./testdata", want: []byte(Issue3SliceSimpleStruct)},
 		{name: "issue 3, struct with map of simple struct keys", types: typesVal{"I3WithMap"}, pointer: true, path: "./testdata", want: []byte(Issue3MapSimpleStructKey)},
 		{name: "issue 3, struct with map of simple struct values", types: typesVal{"I3WithMapVal"}, path: "./testdata", want: []byte(Issue3MapSimpleStructVal)},
@@ -36,21 +100,171 @@ func Test_run(t *testing.T) {
 		{name: "issue 12, map with slice value", types: typesVal{"I12StructWithMapOfSlices"}, path: "./testdata", want: []byte(Issue12MapWithSliceValues)},
 		{name: "issue 15, parent has child value, value receiver", types: typesVal{"ParentHasChildValue", "Child"}, path: "./testdata", want: []byte(I15ParentHasChildValueValueRecv)},
 		{name: "issue 15, parent has child pointer, value receiver", types: typesVal{"ParentHasChildPointer", "Child"}, path: "./testdata", want: []byte(I15ParentHasChildPointerValueRecv)},
+		{name: "issue 15, child has parent value, value receiver", types: typesVal{"Child", "ParentHasChildValue"}, path: "./testdata", want: []byte(I15ParentHasChildValueValueRecv)},
 		{name: "issue 15, parent has child value, pointer receiver", pointer: true, types: typesVal{"ParentHasChildValue", "Child"}, path: "./testdata", want: []byte(I15ParentHasChildValuePointerRecv)},
 		{name: "issue 15, parent has child pointer, pointer receiver", pointer: true, types: typesVal{"ParentHasChildPointer", "Child"}, path: "./testdata", want: []byte(I15ParentHasChildPointerPointerRecv)},
 		{name: "issue 17, with maxdepth", types: typesVal{"Depth1"}, pointer: true, maxdepth: 2, path: "./testdata", want: []byte(Issue17MaxDepth)},
+		{name: "issue 17, with maxdepth 1", types: typesVal{"Depth1"}, pointer: true, maxdepth: 1, path: "./testdata", want: []byte(Issue17MaxDepth1)},
 		{name: "alias import", types: typesVal{"Data"}, path: "./testdata/import_alias", want: []byte(AliasImport)},
+		{name: "map of interfaces with DeepCopy", types: typesVal{"Drawing"}, path: "./testdata/iface_map", want: []byte(IfaceMapDeepCopy)},
+		{name: "unexported fields, without ignore-unexported", types: typesVal{"WithCache"}, path: "./testdata", want: []byte(WithCacheFile)},
+		{name: "unexported fields, with ignore-unexported", types: typesVal{"WithCache"}, ignoreUnexported: true, path: "./testdata", want: []byte(WithCacheIgnoreUnexported)},
+		{name: "map of slice pointers", types: typesVal{"MapOfSlicePointers"}, path: "./testdata", want: []byte(MapOfSlicePointers)},
+		{name: "recursive tree", types: typesVal{"Node"}, path: "./testdata", want: []byte(TreeFile)},
+		{name: "recursive tree - pointer", types: typesVal{"Node"}, pointer: true, path: "./testdata", want: []byte(TreePointerFile)},
+		{name: "interface map keys", types: typesVal{"InterfaceKeys"}, path: "./testdata", want: []byte(InterfaceKeysFile)},
+		{name: "require tag key", types: typesVal{"RequireTag"}, requireTag: tagFilter{key: "copy"}, path: "./testdata", want: []byte(RequireTagKey)},
+		{name: "require tag key and value", types: typesVal{"RequireTag"}, requireTag: tagFilter{key: "copy", value: "true"}, path: "./testdata", want: []byte(RequireTagKeyValue)},
+		{name: "recursive tree - both receivers", types: typesVal{"Node"}, bothReceivers: true, ptrMethodName: "DeepCopyPtr", path: "./testdata", want: []byte(TreeBothReceiversFile)},
+		{name: "both receivers, custom name", types: typesVal{"Bar"}, bothReceivers: true, ptrMethodName: "ClonePtr", path: "./testdata", want: []byte(BarBothReceiversFile)},
+		{name: "skip nested fields and elements", types: typesVal{"SkipNested"}, skips: skipsVal{{"Items[i].Cache": struct{}{}, "M[k][k]": struct{}{}, "Deep[k][i].Cache": struct{}{}}}, path: "./testdata", want: []byte(SkipNestedFieldsFile)},
+		{name: "skip nested elements", types: typesVal{"SkipNested"}, skips: skipsVal{{"Items[i]": struct{}{}, "M[k]": struct{}{}, "Deep[k][i]": struct{}{}}}, path: "./testdata", want: []byte(SkipNestedElementsFile)},
+		{name: "skip nested fields, strict skips", types: typesVal{"SkipNested"}, skips: skipsVal{{"Items[i].Cache": struct{}{}, "M[k][k]": struct{}{}, "Deep[k][i].Cache": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(SkipNestedFieldsFile)},
+		{name: "unused skips, strict skips", types: typesVal{"Foo"}, skips: skipsVal{{"Map[k]": struct{}{}, "OldField": struct{}{}, "Slice": struct{}{}}}, strictSkips: true, path: "./testdata", wantErr: "skip selectors of Foo matched nothing: OldField, Slice"},
+		{name: "skip zero, per selector", types: typesVal{"SkipZero"}, skips: skipsVal{{"Creds!": struct{}{}, "Token!": struct{}{}, "Secret!": struct{}{}, "Conns[i]!": struct{}{}, "Handles[k]!": struct{}{}, "Shared": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(SkipZeroSelectorsFile)},
+		{name: "skip zero, all skips", types: typesVal{"SkipZero"}, skips: skipsVal{{"Creds": struct{}{}, "Shared": struct{}{}}}, skipZero: true, path: "./testdata", want: []byte(SkipZeroAllFile)},
+		{name: "skip zero, zero values of each kind", types: typesVal{"SkipZeroKinds"}, skips: skipsVal{{"Name": struct{}{}, "Enabled": struct{}{}, "Count": struct{}{}, "Ratio": struct{}{}, "Creds": struct{}{}, "Tags": struct{}{}, "Labels": struct{}{}, "Value": struct{}{}, "Events": struct{}{}, "Hook": struct{}{}, "Secret": struct{}{}}}, skipZero: true, path: "./testdata", want: []byte(SkipZeroKindsFile)},
+		{name: "max line length", types: typesVal{"Inventory"}, maxLineLength: 80, path: "./testdata/long_lines", want: []byte(LongLinesFile)},
+		{name: "registered interface impls", types: typesVal{"Drawing"}, ifaceImpls: ifaceImplsVal{{iface: "Shape", impls: []string{"Circle", "Square", "Triangle"}}}, path: "./testdata/iface_impl", want: []byte(IfaceImplFile)},
+		{name: "registered interface impls, slice of another package", types: typesVal{"Canvas"}, ifaceImpls: ifaceImplsVal{{iface: "Shape", impls: []string{"Circle", "Square"}}}, path: "./testdata/iface_impl/canvas", want: []byte(IfaceImplCanvasFile)},
+		{name: "registered interface impls, qualified interface", types: typesVal{"Drawing"}, ifaceImpls: ifaceImplsVal{{iface: "github.com/texazcowboy/deep-copy/testdata/iface_impl.Shape", impls: []string{"Circle", "Square", "Triangle"}}}, path: "./testdata/iface_impl", want: []byte(IfaceImplFile)},
+		{name: "registered interface impls, unknown type", types: typesVal{"Drawing"}, ifaceImpls: ifaceImplsVal{{iface: "Shape", impls: []string{"Circle", "Hexagon"}}}, path: "./testdata/iface_impl", wantErr: "-iface-impl Shape: no type Hexagon in"},
+		{name: "registered interface impls, not implementing", types: typesVal{"Drawing"}, ifaceImpls: ifaceImplsVal{{iface: "Shape", impls: []string{"Drawing"}}}, path: "./testdata/iface_impl", wantErr: "-iface-impl Shape: Drawing doesn't implement Shape"},
+		{name: "zero-size fields", types: typesVal{"ZeroSize"}, path: "./testdata", want: []byte(ZeroSizeFile)},
+		{name: "zero-size fields, skip zero-size", types: typesVal{"ZeroSize"}, skipZeroSize: true, path: "./testdata", want: []byte(ZeroSizeSkippedFile)},
+		{name: "annotated shallow copies", types: typesVal{"Annotated"}, skips: skipsVal{{"Count": struct{}{}}}, path: "./testdata", want: []byte(AnnotatedFile)},
+		{name: "generic types", types: typesVal{"Container", "Pair"}, path: "./testdata/generic", want: []byte(GenericFile)},
+		{name: "maps of byte slices", types: typesVal{"ByteMaps"}, path: "./testdata", want: []byte(ByteMapsFile)},
+		{name: "field doc", types: typesVal{"Foo"}, fieldDoc: true, path: "./testdata", want: []byte(FieldDocFile)},
+		{name: "field doc, no comments", types: typesVal{"Foo"}, fieldDoc: true, noComments: true, path: "./testdata", want: []byte(FooFile)},
+		{name: "no initial assign", types: typesVal{"Inventory"}, skips: skipsVal{{"Owner": struct{}{}}}, noInitialAssign: true, path: "./testdata", want: []byte(NoInitialAssignFile)},
+		{name: "no initial assign, max depth", types: typesVal{"Node"}, maxdepth: 3, noInitialAssign: true, path: "./testdata", wantErr: "-no-initial-assign can't be combined with -max-depth, Node holds values of the types being generated"},
+		{name: "map of pointer slices", types: typesVal{"MapOfPointerSlices"}, path: "./testdata", want: []byte(MapOfPointerSlicesFile)},
+		{name: "named interfaces", types: typesVal{"Labeled"}, path: "./testdata", want: []byte(NamedInterfaceFile)},
+		{name: "named interfaces, generating the interface", types: typesVal{"Labeled", "Stringer"}, path: "./testdata", wantErr: "Stringer is an interface, methods can't be declared on it"},
+		{name: "share type", types: typesVal{"Foo"}, shared: map[string]bool{"github.com/texazcowboy/deep-copy/testdata.Bar": true}, path: "./testdata", want: []byte(ShareTypeFile)},
+		{name: "sorted fields", types: typesVal{"Foo"}, sortFields: true, path: "./testdata", want: []byte(SortedFieldsFile)},
+		{name: "annotated shallow copies, no comments", types: typesVal{"Annotated"}, skips: skipsVal{{"Count": struct{}{}}}, noComments: true, path: "./testdata", want: []byte(AnnotatedNoCommentsFile)},
+		{name: "channels - nil", types: typesVal{"ChanPolicy"}, chanPolicy: chanNil, path: "./testdata", want: []byte(ChanPolicyNilFile)},
+		{name: "channels - share", types: typesVal{"ChanPolicy"}, chanPolicy: chanShare, path: "./testdata", want: []byte(ChanPolicyShareFile)},
+		{name: "clone reusing DeepCopy", types: typesVal{"Alpha"}, methodName: "Clone", reuseMethod: "DeepCopy", path: "./testdata", want: []byte(AlphaCloneReuseDeepCopy)},
+		{name: "clone reusing clone", types: typesVal{"Alpha"}, methodName: "Clone", path: "./testdata", want: []byte(AlphaCloneFile)},
+		{name: "anonymous structs with foreign types", types: typesVal{"Anonymous"}, path: "./testdata/anon_struct", want: []byte(AnonymousStructFile)},
+		{name: "skip by json tag", types: typesVal{"Pod"}, skipByTag: tagSkipsVal{{key: "json", paths: []string{"spec.containers.securityContext", "labels", "spec.volumes!", "spec.containers.Args"}}}, strictSkips: true, path: "./testdata", want: []byte(SkipByTagFile)},
+		{name: "skip by json tag, with skips", types: typesVal{"Pod"}, skips: skipsVal{{"Spec.Internal": struct{}{}}}, skipByTag: tagSkipsVal{{key: "json", paths: []string{"labels"}}}, strictSkips: true, path: "./testdata", want: []byte(SkipByTagWithSkipsFile)},
+		{name: "skip by json tag, ambiguous", types: typesVal{"AmbiguousTags"}, skipByTag: tagSkipsVal{{key: "json", paths: []string{"a"}}}, path: "./testdata", wantErr: `json name "a" is ambiguous between A and B`},
+		{name: "skip by json tag, ignored field", types: typesVal{"Pod"}, skipByTag: tagSkipsVal{{key: "json", paths: []string{"spec.Internal"}}}, path: "./testdata", wantErr: `has no field named "Internal"`},
+		{name: "maps with enum keys and values", types: typesVal{"EnumMap"}, path: "./testdata", want: []byte(EnumMapFile)},
+		{name: "skip literal indices and keys", types: typesVal{"Image"}, skips: skipsVal{{"Layers[0]": struct{}{}, "Tags[1]!": struct{}{}, `Annotations["managed-by"]`: struct{}{}, "Ports[80]!": struct{}{}, "Nested[i][2]": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(SkipLiteralFile)},
+		{name: "skip literal key of a slice", types: typesVal{"Image"}, skips: skipsVal{{`Layers["a"]`: struct{}{}}}, path: "./testdata", wantErr: "slice elements are selected by an integer index"},
+		{name: "type shadowing an imported package", types: typesVal{"time"}, path: "./testdata/shadowed_import", want: []byte(ShadowedImportFile)},
+		{name: "immutable types", types: typesVal{"Compiler"}, immutable: map[string]bool{"github.com/texazcowboy/deep-copy/testdata.SymbolTable": true, "github.com/texazcowboy/deep-copy/testdata.FrozenConfig": true}, path: "./testdata", want: []byte(ImmutableFile)},
+		{name: "skip globs", types: typesVal{"SkipGlob"}, skips: skipsVal{{"cached*": struct{}{}, "Internal*!": struct{}{}, "Items[i].Values": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(SkipGlobFile)},
+		{name: "skip globs, exact selectors first", types: typesVal{"SkipGlob"}, skips: skipsVal{{"cached*": struct{}{}, "cachedNames!": struct{}{}, "Inner.*": struct{}{}, "Items[i].Internal?tate!": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(SkipGlobExactFile)},
+		{name: "force deep copy of a type with DeepCopy", types: typesVal{"Alpha"}, forceDeep: map[string]bool{"github.com/texazcowboy/deep-copy/testdata.Beta": true}, path: "./testdata", want: []byte(AlphaForceDeepFile)},
+		{name: "no reuse of DeepCopy methods", types: typesVal{"Alpha"}, noReuse: true, path: "./testdata", want: []byte(AlphaNoReuseFile)},
+		{name: "pragmas of an existing method", types: typesVal{"Pragma"}, path: "./testdata/pragma", want: []byte(PragmaFile)},
+		{name: "standalone functions", types: typesVal{"Foo", "Bar"}, funcPackage: "copies", receiver: "src", path: "./testdata", want: []byte(FuncFile)},
+		{name: "standalone functions, pointer", types: typesVal{"Node"}, funcPackage: "copies", pointer: true, path: "./testdata", want: []byte(FuncPointerFile)},
+		{name: "standalone functions in the same package", types: typesVal{"Node"}, funcPackage: "testdata", path: "./testdata", wantErr: "-package testdata is the name of the package of Node"},
+		{name: "assert non-nil", types: typesVal{"Pod"}, nonNil: map[string]skips{"Pod": {"Meta.Labels": struct{}{}, "Spec.Containers[i].Security*": struct{}{}}}, path: "./testdata", want: []byte(AssertNonNilFile)},
+		{name: "assert non-nil, non-nilable field", types: typesVal{"Container"}, nonNil: map[string]skips{"Container": {"Name": struct{}{}}}, path: "./testdata", wantErr: "-assert-non-nil Name: Name of type string can't be nil"},
+		{name: "assert non-nil, unmatched", types: typesVal{"Pod"}, nonNil: map[string]skips{"Pod": {"Spec.Removed": struct{}{}}}, path: "./testdata", wantErr: "-assert-non-nil selectors of Pod matched no field: Spec.Removed"},
+		{name: "standalone functions in a helper package", types: typesVal{"User", "Team"}, funcPackage: "clones", path: "./testdata/models", want: []byte(FuncModelsFile)},
+		{name: "unexported types", types: typesVal{"cacheShard", "shardStats"}, path: "./testdata", want: []byte(UnexportedTypeFile)},
+		{name: "unexported types, method name", types: typesVal{"shardStats"}, methodName: "Clone", path: "./testdata", want: []byte(UnexportedTypeMethodNameFile)},
+		{name: "command package", types: typesVal{"Options", "config"}, path: "./testdata/command", want: []byte(CommandFile)},
+		{name: "standalone functions of a command package", types: typesVal{"Options"}, funcPackage: "copies", path: "./testdata/command", wantErr: "Options is in a main package, which can't be imported by package copies"},
+		{name: "unsafe pointers", types: typesVal{"UnsafeFields"}, path: "./testdata", want: []byte(UnsafeFieldsFile)},
+		{name: "unsafe pointers, skipped", types: typesVal{"UnsafeFields"}, skips: skipsVal{{"Data": struct{}{}, "Addr": struct{}{}, "Handle": struct{}{}, "Refs[i]": struct{}{}}}, strict: true, path: "./testdata", want: []byte(UnsafeFieldsSkippedFile)},
+		{name: "unsafe pointers, strict", types: typesVal{"UnsafeFields"}, strict: true, path: "./testdata", wantErr: "o.Data is an unsafe.Pointer, which can't be deep copied; skip it"},
+		{name: "unsafe pointers, strict, no-strict-unsafe", types: typesVal{"UnsafeFields"}, strict: true, noStrictUnsafe: true, path: "./testdata", want: []byte(UnsafeFieldsFile)},
+		{name: "with error", types: typesVal{"Job", "Step"}, withError: true, path: "./testdata", want: []byte(WithErrorFile)},
+		{name: "with error, both receivers", types: typesVal{"Step"}, withError: true, bothReceivers: true, ptrMethodName: "DeepCopyPtr", path: "./testdata", want: []byte(WithErrorBothReceiversFile)},
+		{name: "with error, strict, unexported field of another package", types: typesVal{"Account"}, withError: true, strict: true, funcPackage: "clones", path: "./testdata/models", wantErr: "o.sessions is unexported, it would be shared with the copy; skip it"},
+		{name: "with error, strict, skipped unexported field of another package", types: typesVal{"Account"}, withError: true, strict: true, funcPackage: "clones", skips: skipsVal{{"sessions": struct{}{}}}, path: "./testdata/models", want: []byte(WithErrorSkippedFile)},
+		{name: "with context", types: typesVal{"Job", "Step"}, withContext: true, path: "./testdata", want: []byte(WithContextFile)},
+		{name: "with context, both receivers", types: typesVal{"Step"}, withContext: true, bothReceivers: true, ptrMethodName: "DeepCopyPtr", path: "./testdata", want: []byte(WithContextBothReceiversFile)},
+		{name: "with context, standalone functions", types: typesVal{"User", "Team"}, withContext: true, funcPackage: "clones", path: "./testdata/models", want: []byte(WithContextFuncFile)},
+		{name: "max depth, recursive tree", types: typesVal{"Node"}, maxdepth: 3, path: "./testdata", want: []byte(MaxDepthTreeFile)},
+		{name: "max depth, recursive tree, pointer receiver, with context", types: typesVal{"Node"}, pointer: true, withContext: true, maxdepth: 2, path: "./testdata", want: []byte(MaxDepthTreeContextFile)},
+		{name: "max depth, recursive tree, standalone functions", types: typesVal{"Node"}, maxdepth: 3, funcPackage: "clones", path: "./testdata", wantErr: "-max-depth can't limit the depth of Node"},
+		{name: "non-nil collections", types: typesVal{"Foo", "I12StructWithMapOfSlices"}, nonNilCollections: true, path: "./testdata", want: []byte(NonNilCollectionsFile)},
+		{name: "embed source", types: typesVal{"Node", "Annotated"}, embedSource: true, path: "./testdata", want: []byte(EmbedSourceFile)},
+		{name: "DeepCopyInto methods", types: typesVal{"KubeHolder"}, path: "./testdata", want: []byte(KubeHolderFile)},
+		{name: "output var", types: typesVal{"Foo"}, outputVar: "clone", path: "./testdata", want: []byte(OutputVarFile)},
+		{name: "temp prefix", types: typesVal{"Foo", "I12NestedSlices"}, tempPrefix: "tmp", path: "./testdata", want: []byte(TempPrefixFile)},
+		{name: "temp prefix, shadowed declaration", types: typesVal{"Foo"}, tempPrefix: "I3WithMap", path: "./testdata", wantErr: "would shadow I3WithMapVal"},
+		{name: "output var, both receivers", types: typesVal{"I12NestedSlices"}, outputVar: "dst", bothReceivers: true, ptrMethodName: "DeepCopyPtr", path: "./testdata", want: []byte(OutputVarBothReceiversFile)},
+		{name: "zero", types: typesVal{"Record"}, zero: map[string]skips{"Record": {"ID": struct{}{}, "Tags": struct{}{}, "Owner.ID": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(ZeroFile)},
+		{name: "zero, with skips", types: typesVal{"Record"}, skips: skipsVal{{"Owner.Email": struct{}{}}}, zero: map[string]skips{"Record": {"CreatedAt": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(ZeroWithSkipsFile)},
+		{name: "zero, unmatched", types: typesVal{"Record"}, zero: map[string]skips{"Record": {"UpdatedAt": struct{}{}}}, strictSkips: true, path: "./testdata", wantErr: "skip selectors of Record matched nothing: UpdatedAt!"},
+		{name: "promoted field skips", types: typesVal{"Server"}, skips: skipsVal{{"Timeout": struct{}{}, "Hosts[i]": struct{}{}, "TLS.CertFile": struct{}{}, "Name": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(PromotedSkipsFile)},
+		{name: "embedded field skips", types: typesVal{"Server"}, skips: skipsVal{{"ServerConfig.Timeout": struct{}{}, "ServerConfig.Name": struct{}{}}}, strictSkips: true, path: "./testdata", want: []byte(EmbeddedSkipsFile)},
+		{name: "func fields", types: typesVal{"Router"}, path: "./testdata", want: []byte(RouterFile)},
+		{name: "custom receiver", types: typesVal{"Bar"}, receiver: "b", bothReceivers: true, ptrMethodName: "DeepCopyPtr", path: "./testdata", want: []byte(BarCustomReceiverFile)},
+		{name: "receiver shadowing an import", types: typesVal{"Holder"}, receiver: "item", path: "./testdata/external_pointer", wantErr: "receiver item of Holder shadows an imported package"},
+		{name: "external pointer", types: typesVal{"Holder"}, path: "./testdata/external_pointer", want: []byte(ExternalPointer)},
+		{name: "external pointer, with error-on-external-pointer", types: typesVal{"Holder"}, errorOnExternalPointer: true, path: "./testdata/external_pointer", wantErr: "o.Item points to external type"},
+		{name: "external pointer, with error-on-external-pointer and skip", types: typesVal{"Holder"}, errorOnExternalPointer: true, skips: skipsVal{{"Item": struct{}{}}}, path: "./testdata/external_pointer", want: []byte(ExternalPointerSkipped)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &app{
-				isPtrRecv: tt.pointer,
-				maxDepth:  tt.maxdepth,
+				isPtrRecv:        tt.pointer,
+				bothReceivers:    tt.bothReceivers,
+				methodName:       tt.methodName,
+				reuseMethod:      tt.reuseMethod,
+				ptrMethodName:    tt.ptrMethodName,
+				funcMode:         tt.funcPackage != "",
+				funcPackage:      tt.funcPackage,
+				receiver:         tt.receiver,
+				tempPrefix:       tt.tempPrefix,
+				outputVar:        tt.outputVar,
+				chanPolicy:       tt.chanPolicy,
+				immutable:        tt.immutable,
+				shared:           tt.shared,
+				forceDeep:        tt.forceDeep,
+				noReuse:          tt.noReuse,
+				nonNil:           tt.nonNil,
+				zero:             tt.zero,
+				withError:        tt.withError,
+				withContext:      tt.withContext,
+				ifaceImpls:       tt.ifaceImpls,
+				maxDepth:         tt.maxdepth,
+				ignoreUnexported: tt.ignoreUnexported,
+				requireTag:       tt.requireTag,
+
+				errorOnExternalPointer: tt.errorOnExternalPointer,
+				strict:                 tt.strict,
+				noStrictUnsafe:         tt.noStrictUnsafe,
+				strictSkips:            tt.strictSkips,
+				skipZero:               tt.skipZero,
+				skipByTag:              tt.skipByTag,
+				skipPatterns:           tt.skipPatterns,
+				skipZeroSize:           tt.skipZeroSize,
+				noComments:             tt.noComments,
+				fieldDoc:               tt.fieldDoc,
+				noInitialAssign:        tt.noInitialAssign,
+				sortFields:             tt.sortFields,
+				guardNilReceiver:       tt.guardNilReceiver,
+				nonNilCollections:      tt.nonNilCollections,
+				embedSource:            tt.embedSource,
+				maxLineLength:          tt.maxLineLength,
 			}
 			got, err := a.run(tt.path, tt.types, tt.skips)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatal(err)
 			}
+			if bytes.HasSuffix(got, []byte("\n\n")) {
+				t.Errorf("run() output ends with a blank line")
+			}
 			got = normalizeComment(got)
 			if diff := cmp.Diff(got, tt.want); diff != "" {
 				t.Errorf("generateFile() diff = %s", diff)
@@ -59,238 +273,2103 @@ func Test_run(t *testing.T) {
 	}
 }
 
-var re = regexp.MustCompile(`generated by .*deep-copy.*; DO NOT EDIT.`)
+func Test_machineOutput(t *testing.T) {
+	a := &app{}
+	got, err := a.machineOutput("./testdata", typesVal{"Foo", "Missing"}, skipsVal{{"ch": struct{}{}}}, "foo_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-func normalizeComment(in []byte) []byte {
-	return re.ReplaceAll(bytes.TrimSpace(in), []byte("generated by deep-copy; DO NOT EDIT."))
-}
+	var results []machineResult
+	if err := json.Unmarshal(got, &results); err != nil {
+		t.Fatalf("unmarshaling %s: %v", got, err)
+	}
 
-const (
-	FooFile = `// generated by deep-copy; DO NOT EDIT.
+	want := []machineResult{
+		{Type: "Foo", OutputFile: "foo_deepcopy.go", Imports: []string{}, FieldsWalked: 6, FieldsSkipped: 1, Errors: []string{}},
+		{Type: "Missing", OutputFile: "foo_deepcopy.go", Imports: []string{}, Errors: []string{`locating type "Missing" in "testdata": type not found`}},
+	}
+	if len(results) == len(want) {
+		want[0].Bytes = results[0].Bytes
+	}
+	if diff := cmp.Diff(results, want); diff != "" {
+		t.Errorf("machineOutput() diff = %s", diff)
+	}
+	if results[0].Bytes == 0 {
+		t.Errorf("machineOutput() bytes = 0, want > 0")
+	}
 
-package testdata
+	a.chanPolicy = chanNil
+	got, err = a.machineOutput("./testdata", typesVal{"ChanPolicy"}, nil, "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-// DeepCopy generates a deep copy of Foo
-func (o Foo) DeepCopy() Foo {
-	var cp Foo = o
-	if o.Map != nil {
-		cp.Map = make(map[string]*Bar, len(o.Map))
-		for k2, v2 := range o.Map {
-			var cp_Map_v2 *Bar
-			if v2 != nil {
-				cp_Map_v2 = new(Bar)
-				*cp_Map_v2 = *v2
-				if v2.Slice != nil {
-					cp_Map_v2.Slice = make([]string, len(v2.Slice))
-					copy(cp_Map_v2.Slice, v2.Slice)
-				}
-			}
-			cp.Map[k2] = cp_Map_v2
-		}
+	var result machineResult
+	if err := json.Unmarshal(got, &result); err != nil {
+		t.Fatalf("unmarshaling %s: %v", got, err)
 	}
-	if o.ch != nil {
-		cp.ch = make(chan float32, cap(o.ch))
+	if result.ChanPolicy != "nil" {
+		t.Errorf("machineOutput() chan policy = %q, want nil", result.ChanPolicy)
 	}
-	if o.baz.StringPointer != nil {
-		cp.baz.StringPointer = new(string)
-		*cp.baz.StringPointer = *o.baz.StringPointer
+}
+
+func Test_unusedSkips(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := locateTypes(p, typesVal{"Foo"})
+	if err != nil {
+		t.Fatal(err)
 	}
-	return cp
-}`
-	FooPointerFile = `// generated by deep-copy; DO NOT EDIT.
 
-package testdata
+	s := skips{"Map[k]": struct{}{}, "ch": struct{}{}, "Map[k].Slice": struct{}{}, "OldField": struct{}{}}
+	if _, err := a.generateFunc(p, objs[0], map[string]string{}, s, objs); err != nil {
+		t.Fatal(err)
+	}
 
-// DeepCopy generates a deep copy of *Foo
-func (o *Foo) DeepCopy() *Foo {
-	var cp Foo = *o
-	if o.Map != nil {
-		cp.Map = make(map[string]*Bar, len(o.Map))
-		for k2, v2 := range o.Map {
-			var cp_Map_v2 *Bar
-			if v2 != nil {
-				cp_Map_v2 = new(Bar)
-				*cp_Map_v2 = *v2
-				if v2.Slice != nil {
-					cp_Map_v2.Slice = make([]string, len(v2.Slice))
-					copy(cp_Map_v2.Slice, v2.Slice)
-				}
-			}
-			cp.Map[k2] = cp_Map_v2
-		}
+	// Map[k].Slice is unused, since the whole map member is skipped.
+	if diff := cmp.Diff(a.unusedSkips(s), []string{"Map[k].Slice", "OldField"}); diff != "" {
+		t.Errorf("unusedSkips() diff = %s", diff)
 	}
-	if o.ch != nil {
-		cp.ch = make(chan float32, cap(o.ch))
+	if diff := cmp.Diff(a.warnings, []warning{{msg: "skip selectors of Foo matched nothing: Map[k].Slice, OldField", lenient: true}}, cmp.AllowUnexported(warning{})); diff != "" {
+		t.Errorf("generateFunc() warnings diff = %s", diff)
 	}
-	if o.baz.StringPointer != nil {
-		cp.baz.StringPointer = new(string)
-		*cp.baz.StringPointer = *o.baz.StringPointer
+}
+
+func Test_locateType(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata/shadowed")
+	if err != nil {
+		t.Fatal(err)
 	}
-	return &cp
-}`
-	FooPointerSkipSliceFile = `// generated by deep-copy; DO NOT EDIT.
 
-package testdata
+	// The definitions are unordered, the lookup is repeated to catch a
+	// local type being picked some of the time.
+	for i := 0; i < 50; i++ {
+		obj, err := locateType(p.Name, "Config", p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, ok := obj.Underlying().(*types.Struct); !ok || s.Field(0).Name() != "Values" {
+			t.Fatalf("locateType() = %s, want the package level Config", obj.Underlying())
+		}
+	}
+}
 
-// DeepCopy generates a deep copy of *Foo
-func (o *Foo) DeepCopy() *Foo {
-	var cp Foo = *o
-	if o.Map != nil {
-		cp.Map = make(map[string]*Bar, len(o.Map))
-		for k2, v2 := range o.Map {
-			var cp_Map_v2 *Bar
-			if v2 != nil {
-				cp_Map_v2 = new(Bar)
-				*cp_Map_v2 = *v2
-			}
-			cp.Map[k2] = cp_Map_v2
+func Test_chanPolicy(t *testing.T) {
+	for _, v := range []string{"nil", "empty", "share"} {
+		var p chanPolicy
+		if err := p.Set(v); err != nil || p.String() != v {
+			t.Errorf("Set(%q) = %v, policy %q", v, err, p)
 		}
 	}
-	if o.ch != nil {
-		cp.ch = make(chan float32, cap(o.ch))
+
+	var p chanPolicy
+	if err := p.Set("close"); err == nil {
+		t.Error("Set() expected an error for an unknown policy")
 	}
-	if o.baz.StringPointer != nil {
-		cp.baz.StringPointer = new(string)
-		*cp.baz.StringPointer = *o.baz.StringPointer
+}
+
+func Test_generateJSON(t *testing.T) {
+	a := &app{ignoreErrors: true}
+	p, err := a.loadPackage("./testdata/anon_struct")
+	if err != nil {
+		t.Fatal(err)
 	}
-	return &cp
-}`
-	FooSkipMapFile = `// generated by deep-copy; DO NOT EDIT.
 
-package testdata
+	got, err := a.generateJSON(p, typesVal{"Anonymous", "Missing"}, nil)
+	var errs typeErrors
+	if !errors.As(err, &errs) || !errs.Contains("Missing") {
+		t.Errorf("generateJSON() error = %v, want an error for Missing", err)
+	}
 
-// DeepCopy generates a deep copy of Foo
-func (o Foo) DeepCopy() Foo {
-	var cp Foo = o
-	if o.Map != nil {
-		cp.Map = make(map[string]*Bar, len(o.Map))
-		for k2, v2 := range o.Map {
-			cp.Map[k2] = v2
-		}
+	var f jsonFile
+	if err := json.Unmarshal(got, &f); err != nil {
+		t.Fatalf("unmarshaling %s: %v", got, err)
 	}
-	if o.ch != nil {
-		cp.ch = make(chan float32, cap(o.ch))
+
+	wantImports := []jsonImport{{Name: "item", Path: "github.com/texazcowboy/deep-copy/testdata/import_alias/item"}}
+	if f.Package != "anon_struct" || !cmp.Equal(f.Imports, wantImports) {
+		t.Errorf("generateJSON() package = %s, imports = %v", f.Package, f.Imports)
 	}
-	if o.baz.StringPointer != nil {
-		cp.baz.StringPointer = new(string)
-		*cp.baz.StringPointer = *o.baz.StringPointer
+	if len(f.Methods) != 1 || f.Methods[0].Type != "Anonymous" {
+		t.Fatalf("generateJSON() methods = %v, want one for Anonymous", f.Methods)
 	}
-	return cp
-}`
-	AlphaPointer = `// generated by deep-copy; DO NOT EDIT.
-
-package testdata
+	if !strings.HasSuffix(AnonymousStructFile, "\n\n"+f.Methods[0].Body) {
+		t.Errorf("generateJSON() body = %s, want the method of %s", f.Methods[0].Body, AnonymousStructFile)
+	}
+}
 
-// DeepCopy generates a deep copy of Alpha
-func (o Alpha) DeepCopy() Alpha {
-	var cp Alpha = o
-	if o.B != nil {
-		cp.B = o.B.DeepCopy()
+func Test_tagFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		tag   string
+		want  bool
+	}{
+		{name: "empty filter", tag: `json:"a"`, want: true},
+		{name: "key present", value: "copy", tag: `copy:"false"`, want: true},
+		{name: "key missing", value: "copy", tag: `json:"a"`, want: false},
+		{name: "value matches", value: "copy:true", tag: `json:"a" copy:"true"`, want: true},
+		{name: "value differs", value: "copy:true", tag: `copy:"false"`, want: false},
 	}
-	cp.G = o.G.DeepCopy()
-	if o.D != nil {
-		retV := o.D.DeepCopy()
-		cp.D = &retV
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f tagFilter
+			if tt.value != "" {
+				if err := f.Set(tt.value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := f.Matches(tt.tag); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
 	}
-	{
-		retV := o.E.DeepCopy()
-		cp.E = *retV
+}
+
+func Test_tagSkipsVal(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    tagSkips
+		wantErr bool
+	}{
+		{name: "single path", value: "json:spec.containers", want: tagSkips{key: "json", paths: []string{"spec.containers"}}},
+		{name: "multiple paths", value: "yaml:a.b,c!", want: tagSkips{key: "yaml", paths: []string{"a.b", "c!"}}},
+		{name: "missing key", value: ":a", wantErr: true},
+		{name: "missing paths", value: "json", wantErr: true},
 	}
-	return cp
-}`
-	SlicePointer = `// generated by deep-copy; DO NOT EDIT.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f tagSkipsVal
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(f, tagSkipsVal{tt.want}, cmp.AllowUnexported(tagSkips{})); diff != "" {
+				t.Errorf("Set(%q) diff = %s", tt.value, diff)
+			}
+		})
+	}
+}
 
-package testdata
+func Test_ignoreErrors(t *testing.T) {
+	types := typesVal{"Wrapper", "Holder", "Missing"}
+	skips := skipsVal{{"H.Item": struct{}{}}}
 
-// DeepCopy generates a deep copy of SlicePointer
-func (o SlicePointer) DeepCopy() SlicePointer {
-	var cp SlicePointer = o
-	if o != nil {
-		cp = make([]*int, len(o))
-		copy(cp, o)
+	a := &app{errorOnExternalPointer: true}
+	if got, err := a.run("./testdata/external_pointer", types, skips); err == nil || got != nil {
+		t.Fatalf("run() = %q, %v, want an error", got, err)
 	}
-	return cp
-}`
-	FooAlphaSkips = `// generated by deep-copy; DO NOT EDIT.
 
-package testdata
+	a.ignoreErrors = true
+	got, err := a.run("./testdata/external_pointer", types, skips)
 
-// DeepCopy generates a deep copy of Foo
-func (o Foo) DeepCopy() Foo {
-	var cp Foo = o
-	if o.Map != nil {
-		cp.Map = make(map[string]*Bar, len(o.Map))
-		for k2, v2 := range o.Map {
-			cp.Map[k2] = v2
-		}
+	var errs typeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("run() error = %v, want typeErrors", err)
 	}
-	if o.baz.StringPointer != nil {
-		cp.baz.StringPointer = new(string)
-		*cp.baz.StringPointer = *o.baz.StringPointer
+	if len(errs) != 2 || !errs.Contains("Missing") || !errs.Contains("Holder") || errs.Contains("Wrapper") {
+		t.Errorf("run() errors = %v, want errors for Missing and Holder", errs)
 	}
-	return cp
-}
 
-// DeepCopy generates a deep copy of Alpha
-func (o Alpha) DeepCopy() Alpha {
-	var cp Alpha = o
-	if o.B != nil {
-		cp.B = o.B.DeepCopy()
+	// Wrapper no longer reuses the method of Holder, which wasn't generated.
+	if diff := cmp.Diff(string(normalizeComment(got)), IgnoreErrorsFile); diff != "" {
+		t.Errorf("run() diff = %s", diff)
 	}
-	cp.G = o.G.DeepCopy()
-	return cp
-}`
 
-	Issue3SliceSimpleStruct = `// generated by deep-copy; DO NOT EDIT.
+	if got, err := a.run("./testdata/external_pointer", typesVal{"Holder"}, nil); got != nil || !errors.As(err, &errs) {
+		t.Errorf("run() = %q, %v, want no content and typeErrors", got, err)
+	}
+}
 
-package testdata
+func Test_concurrentTypes(t *testing.T) {
+	types := typesVal{"Foo", "Alpha", "Node", "SkipGlob", "Compiler", "Annotated"}
+	skips := skipsVal{nil, nil, nil, {"cached*": struct{}{}, "Unused": struct{}{}}}
 
-// DeepCopy generates a deep copy of *I3WithSlice
-func (o *I3WithSlice) DeepCopy() *I3WithSlice {
-	var cp I3WithSlice = *o
-	if o.a != nil {
-		cp.a = make([]I3SimpleStruct, len(o.a))
-		copy(cp.a, o.a)
+	var seqLog, conLog bytes.Buffer
+	seq := &app{logger: newLogger(&seqLog, false, false)}
+	seq.logger.l.SetFlags(0)
+	want, err := seq.run("./testdata", types, skips)
+	if err != nil {
+		t.Fatal(err)
 	}
-	return &cp
-}`
-	Issue3MapSimpleStructKey = `// generated by deep-copy; DO NOT EDIT.
 
-package testdata
+	a := &app{concurrentTypes: true, logger: newLogger(&conLog, false, false)}
+	a.logger.l.SetFlags(0)
+	got, err := a.run("./testdata", types, skips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), string(want)); diff != "" {
+		t.Errorf("run() with concurrent types diff = %s", diff)
+	}
+	if !strings.Contains(conLog.String(), "matched nothing: Unused") || conLog.String() != seqLog.String() {
+		t.Errorf("run() with concurrent types logged %q, want %q", conLog.String(), seqLog.String())
+	}
 
-// DeepCopy generates a deep copy of *I3WithMap
-func (o *I3WithMap) DeepCopy() *I3WithMap {
-	var cp I3WithMap = *o
-	if o.a != nil {
-		cp.a = make(map[I3SimpleStruct]string, len(o.a))
-		for k2, v2 := range o.a {
-			cp.a[k2] = v2
-		}
+	a.ignoreErrors, a.errorOnExternalPointer = true, true
+	got, err = a.run("./testdata/external_pointer", typesVal{"Wrapper", "Holder"}, skipsVal{{"H.Item": struct{}{}}})
+
+	var errs typeErrors
+	if !errors.As(err, &errs) || !errs.Contains("Holder") || errs.Contains("Wrapper") {
+		t.Fatalf("run() error = %v, want an error of Holder only", err)
 	}
-	return &cp
-}`
-	Issue3MapSimpleStructVal = `// generated by deep-copy; DO NOT EDIT.
+	if !bytes.Contains(got, []byte("func (o Wrapper) DeepCopy()")) {
+		t.Errorf("run() = %s, want the method of Wrapper", got)
+	}
+}
 
-package testdata
+func Test_noReuseWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	a := &app{noReuse: true, logger: newLogger(&buf, false, false)}
+	if _, err := a.run("./testdata", typesVal{"Alpha", "Beta"}, nil); err != nil {
+		t.Fatal(err)
+	}
 
-// DeepCopy generates a deep copy of I3WithMapVal
-func (o I3WithMapVal) DeepCopy() I3WithMapVal {
-	var cp I3WithMapVal = o
-	if o.a != nil {
-		cp.a = make(map[string]I3SimpleStruct, len(o.a))
-		for k2, v2 := range o.a {
-			cp.a[k2] = v2
+	got := buf.String()
+	for _, kind := range []string{"Gamma", "Delta", "Epsilon"} {
+		if want := "not reusing github.com/texazcowboy/deep-copy/testdata." + kind + ".DeepCopy"; strings.Count(got, want) != 1 {
+			t.Errorf("warnings %q don't contain %q once", got, want)
 		}
 	}
-	return cp
-}`
+	if strings.Contains(got, "testdata.Beta.DeepCopy") {
+		t.Errorf("warnings %q contain the generated Beta.DeepCopy", got)
+	}
+}
 
-	Issue7ShadowedMapVars = `// generated by deep-copy; DO NOT EDIT.
+func Test_strictWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		app  *app
+		kind string
+		want string
+	}{
+		{name: "no reuse", app: &app{noReuse: true}, kind: "Alpha", want: "not reusing github.com/texazcowboy/deep-copy/testdata.Gamma.DeepCopy"},
+		{name: "max depth", app: &app{maxDepth: 1}, kind: "Foo", want: "reached max depth 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.app.logger = newLogger(&buf, false, false)
+			if _, err := tt.app.run("./testdata", typesVal{tt.kind}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("warnings %q don't contain %q", buf.String(), tt.want)
+			}
 
-package testdata
+			tt.app.strict = true
+			got, err := tt.app.run("./testdata", typesVal{tt.kind}, nil)
+			if got != nil || err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("run() with -strict = %q, %v, want an error containing %q", got, err, tt.want)
+			}
+		})
+	}
+}
 
-// DeepCopy generates a deep copy of SomeStruct2
-func (o SomeStruct2) DeepCopy() SomeStruct2 {
+func Test_logger(t *testing.T) {
+	tests := []struct {
+		name           string
+		quiet, verbose bool
+		level          string
+		want           []string
+		dontWant       []string
+	}{
+		{name: "default", want: []string{"WARNING: stop", "loading"}, dontWant: []string{"info", "DEBUG"}},
+		{name: "quiet", quiet: true, dontWant: []string{"WARNING", "loading", "info", "DEBUG"}},
+		{name: "verbose", verbose: true, want: []string{"WARNING: stop", "loading", "info"}, dontWant: []string{"DEBUG"}},
+		{name: "debug level", level: "debug", want: []string{"WARNING: stop", "loading", "info", "DEBUG: walk"}},
+		{name: "error level", level: "error", dontWant: []string{"WARNING", "loading", "info", "DEBUG"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := newLogger(&buf, tt.quiet, tt.verbose)
+			if tt.level != "" {
+				var level logLevel
+				if err := level.Set(tt.level); err != nil {
+					t.Fatal(err)
+				}
+				l.SetLevel(level)
+			}
+			l.Debugf("walk")
+			l.Progressf("loading")
+			l.Warnf("stop")
+			l.Infof("info")
+			l.Println("error")
+
+			got := buf.String()
+			for _, w := range append(tt.want, "error") {
+				if !strings.Contains(got, w) {
+					t.Errorf("logger output %q doesn't contain %q", got, w)
+				}
+			}
+			for _, w := range tt.dontWant {
+				if strings.Contains(got, w) {
+					t.Errorf("logger output %q contains %q", got, w)
+				}
+			}
+			if tt.quiet && got != "error\n" {
+				t.Errorf("quiet logger output = %q, want only the error", got)
+			}
+		})
+	}
+}
+
+func Test_fields(t *testing.T) {
+	got := fields("type", "Foo", "file", "out dir/gen.go", "package", "")
+	if want := `type=Foo file="out dir/gen.go"`; got != want {
+		t.Errorf("fields() = %q, want %q", got, want)
+	}
+
+	var level logLevel
+	if err := level.Set("verbose"); err == nil {
+		t.Error("logLevel.Set(verbose) succeeded")
+	}
+}
+
+func Test_headerCommand(t *testing.T) {
+	a := &app{logger: newLogger(io.Discard, true, false)}
+	got, err := a.run("./testdata", typesVal{"Bar"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "// generated by deep-copy; DO NOT EDIT.\n"; !strings.HasPrefix(string(got), want) {
+		t.Errorf("run() header = %q, want %q", strings.SplitN(string(got), "\n", 2)[0], want)
+	}
+
+	a.logger = newLogger(io.Discard, false, true)
+	a.command = "deep-copy -o=./testdata/foo_gen.go -type=Bar ./testdata"
+	got, err = a.run("./testdata", typesVal{"Bar"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "// generated by " + a.command + ";"; !strings.HasPrefix(string(got), want) {
+		t.Errorf("run() verbose header = %q, want prefix %q", strings.SplitN(string(got), "\n", 2)[0], want)
+	}
+}
+
+func Test_canonicalCommand(t *testing.T) {
+	fs := flag.NewFlagSet("deep-copy", flag.ContinueOnError)
+	fs.Var(&typesVal{}, "type", "")
+	fs.String("o", "", "")
+	fs.Bool("pointer-receiver", false, "")
+	fs.Bool("v", false, "")
+	fs.String("header-template", "", "")
+	fs.String("C", "", "")
+
+	root := filepath.FromSlash("/src/module")
+	tests := []struct {
+		name  string
+		args  []string
+		chdir string
+		want  string
+	}{
+		{name: "sorted", args: []string{"-type", "Foo", "-pointer-receiver", "-o", "foo_gen.go", "."}, want: "deep-copy -o=foo_gen.go -pointer-receiver -type=Foo ."},
+		{name: "repeated flags keep their order", args: []string{"--type=Foo", "-type", "Bar", "./pkg"}, want: "deep-copy -type=Foo -type=Bar ./pkg"},
+		{name: "absolute paths", args: []string{"-o", filepath.FromSlash("/src/module/pkg/foo_gen.go"), "-type", "Foo", filepath.FromSlash("/src/module/pkg")}, want: "deep-copy -o=./pkg/foo_gen.go -type=Foo ./pkg"},
+		{name: "outside of root", args: []string{"-o", filepath.FromSlash("/tmp/foo_gen.go"), "-type", "Foo", "example.com/pkg"}, want: "deep-copy -o=/tmp/foo_gen.go -type=Foo example.com/pkg"},
+		{name: "logging flags", args: []string{"-v", "-type", "Foo", "-v=false", "."}, want: "deep-copy -type=Foo ."},
+		{name: "working directory", args: []string{"-C", "pkg", "-type", "Foo", "."}, chdir: "./pkg", want: "deep-copy -C=./pkg -type=Foo ."},
+		{name: "working directory, output", args: []string{"-C", "../module/pkg", "-o", "foo_gen.go", "-type", "Foo", "."}, chdir: "./pkg", want: "deep-copy -C=./pkg -o=foo_gen.go -type=Foo ."},
+		{name: "quoted", args: []string{"-header-template", "// {{.Package}}", "-type", "Foo", "--", "-pkg"}, want: `deep-copy -header-template="// {{.Package}}" -type=Foo -pkg`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canonicalCommand(tt.args, fs, root, tt.chdir); got != tt.want {
+				t.Errorf("canonicalCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if optionsHash("deep-copy -type=Foo .") == optionsHash("deep-copy -type=Bar .") {
+		t.Error("optionsHash() is the same for different commands")
+	}
+}
+
+func Test_buildInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info *debug.BuildInfo
+		want string
+	}{
+		{name: "no build info", want: "deep-copy (devel)\n"},
+		{
+			name: "release",
+			info: &debug.BuildInfo{
+				GoVersion: "go1.21.0",
+				Main:      debug.Module{Path: "github.com/texazcowboy/deep-copy", Version: "v1.2.3"},
+				Settings: []debug.BuildSetting{
+					{Key: "CGO_ENABLED", Value: "0"},
+					{Key: "CGO_CFLAGS", Value: ""},
+					{Key: "vcs.revision", Value: "abc123def4567890"},
+					{Key: "vcs.modified", Value: "true"},
+					{Key: "GOOS", Value: "linux"},
+				},
+			},
+			want: "deep-copy v1.2.3 (commit abc123def456, go1.21.0)\nmodule: github.com/texazcowboy/deep-copy\nbuild: CGO_ENABLED=0 modified GOOS=linux\n",
+		},
+		{
+			name: "development build",
+			info: &debug.BuildInfo{GoVersion: "go1.21.0", Main: debug.Module{Path: "github.com/texazcowboy/deep-copy"}},
+			want: "deep-copy (devel) (go1.21.0)\nmodule: github.com/texazcowboy/deep-copy\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildInfo(tt.info); got != tt.want {
+				t.Errorf("buildInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateReceiver(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{name: "o"},
+		{name: "foo"},
+		{name: "idx"},
+		{name: "cpy"},
+		{name: "", wantErr: true},
+		{name: "_", wantErr: true},
+		{name: "1o", wantErr: true},
+		{name: "cp", wantErr: true},
+		{name: "retV", wantErr: true},
+		{name: "err", wantErr: true},
+		{name: "ctx", wantErr: true},
+		{name: "i", wantErr: true},
+		{name: "i3", wantErr: true},
+		{name: "k2", wantErr: true},
+		{name: "v", wantErr: true},
+		{name: "cp_Map_v", wantErr: true},
+		{name: "v2_k3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReceiver(tt.name); (err != nil) != tt.wantErr {
+				t.Errorf("validateReceiver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateOutputVar(t *testing.T) {
+	tests := []struct {
+		name     string
+		receiver string
+		wantErr  bool
+	}{
+		{name: "cp", receiver: "o"},
+		{name: "clone", receiver: "o"},
+		{name: "dst", receiver: "src"},
+		{name: "", receiver: "o", wantErr: true},
+		{name: "_", receiver: "o", wantErr: true},
+		{name: "o", receiver: "o", wantErr: true},
+		{name: "src", receiver: "src_v", wantErr: true},
+		{name: "err", receiver: "o", wantErr: true},
+		{name: "k2", receiver: "o", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateOutputVar(tt.name, tt.receiver); (err != nil) != tt.wantErr {
+				t.Errorf("validateOutputVar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateTempPrefix(t *testing.T) {
+	tests := []struct {
+		prefix    string
+		receiver  string
+		outputVar string
+		wantErr   bool
+	}{
+		{prefix: "", receiver: "o", outputVar: "cp"},
+		{prefix: "tmp", receiver: "o", outputVar: "cp"},
+		{prefix: "tmp-", receiver: "o", outputVar: "cp", wantErr: true},
+		{prefix: "_", receiver: "o", outputVar: "cp", wantErr: true},
+		{prefix: "o", receiver: "oKey", outputVar: "cp", wantErr: true},
+		{prefix: "c", receiver: "o", outputVar: "cImpl2", wantErr: true},
+		{prefix: "e", receiver: "o", outputVar: "cp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if err := validateTempPrefix(tt.prefix, tt.receiver, tt.outputVar); (err != nil) != tt.wantErr {
+				t.Errorf("validateTempPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_getElemType(t *testing.T) {
+	empty := types.NewInterfaceType(nil, nil).Complete()
+	tests := []struct {
+		name string
+		typ  types.Type
+		want string
+	}{
+		{name: "empty interface", typ: empty, want: "interface{}"},
+		{name: "map with empty interface key", typ: types.NewMap(empty, types.Typ[types.String]), want: "map[interface{}]string"},
+		{name: "slice of empty interfaces", typ: types.NewSlice(empty), want: "[]interface{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getElemType(tt.typ, "testdata", map[string]string{}); got != tt.want {
+				t.Errorf("getElemType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTypesFile(t *testing.T) {
+	in := `# packages of the monorepo
+./testdata Foo Map[k],ch
+
+./testdata/iface_map Drawing
+./testdata Alpha D E
+`
+	got, err := parseTypesFile(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []batchEntry{
+		{line: 2, pattern: "./testdata", kind: "Foo", skips: skips{"Map[k]": struct{}{}, "ch": struct{}{}}},
+		{line: 4, pattern: "./testdata/iface_map", kind: "Drawing", skips: skips{}},
+		{line: 5, pattern: "./testdata", kind: "Alpha", skips: skips{"D": struct{}{}, "E": struct{}{}}},
+	}
+	if diff := cmp.Diff(got, want, cmp.AllowUnexported(batchEntry{})); diff != "" {
+		t.Errorf("parseTypesFile() diff = %s", diff)
+	}
+
+	if _, err := parseTypesFile(strings.NewReader("./testdata\n")); err == nil {
+		t.Error("parseTypesFile() expected an error for a line without a type")
+	}
+}
+
+func Test_generateBatch(t *testing.T) {
+	entries := []batchEntry{
+		{line: 1, pattern: "./testdata", kind: "Foo", skips: skips{"Map[k]": struct{}{}, "ch": struct{}{}}},
+		{line: 2, pattern: "./testdata/iface_map", kind: "Drawing", skips: skips{}},
+		{line: 3, pattern: "./testdata", kind: "Missing", skips: skips{}},
+		{line: 4, pattern: "./testdata", kind: "Alpha", skips: skips{"D": struct{}{}, "E": struct{}{}}},
+	}
+
+	a := &app{}
+	files, errs := a.generateBatch(entries)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `line 3: ./testdata Missing: type not found`) {
+		t.Errorf("generateBatch() errors = %v", errs)
+	}
+	if len(files) != 2 {
+		t.Fatalf("generateBatch() generated %d files, want 2", len(files))
+	}
+
+	want := []struct {
+		path    string
+		content string
+	}{
+		{path: filepath.Join("testdata", batchOutput), content: FooAlphaSkips},
+		{path: filepath.Join("testdata", "iface_map", batchOutput), content: IfaceMapDeepCopy},
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(files[i].path, string(filepath.Separator)+w.path) {
+			t.Errorf("generateBatch() file %d path = %s, want suffix %s", i, files[i].path, w.path)
+		}
+		if diff := cmp.Diff(string(normalizeComment(files[i].content)), w.content); diff != "" {
+			t.Errorf("generateBatch() file %d diff = %s", i, diff)
+		}
+	}
+
+	a.strict = true
+	files, errs = a.generateBatch(entries)
+	if len(files) != 0 || len(errs) != 1 {
+		t.Errorf("generateBatch() in strict mode = %d files, %v errors, want none and one", len(files), errs)
+	}
+}
+
+func Test_exitCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		app   app
+		path  string
+		types typesVal
+		skips skipsVal
+		want  int
+	}{
+		{name: "missing package", path: "./testdata/missing", types: typesVal{"Foo"}, want: exitResolve},
+		{name: "missing type", path: "./testdata", types: typesVal{"Missing"}, want: exitResolve},
+		{name: "unused skips", app: app{strictSkips: true}, path: "./testdata", types: typesVal{"Foo"}, skips: skipsVal{{"OldField": struct{}{}}}, want: exitResolve},
+		{name: "ambiguous tag skip", app: app{skipByTag: tagSkipsVal{{key: "json", paths: []string{"a"}}}}, path: "./testdata", types: typesVal{"AmbiguousTags"}, want: exitResolve},
+		{name: "literal key of a slice", path: "./testdata", types: typesVal{"Image"}, skips: skipsVal{{`Layers["a"]`: struct{}{}}}, want: exitResolve},
+		{name: "unsafe pointers", app: app{strict: true}, path: "./testdata", types: typesVal{"UnsafeFields"}, want: exitGenerate},
+		{name: "ignored errors, missing types", app: app{ignoreErrors: true}, path: "./testdata", types: typesVal{"Foo", "Missing", "Gone"}, want: exitResolve},
+		{name: "ignored errors, unsafe pointers", app: app{ignoreErrors: true, strict: true}, path: "./testdata", types: typesVal{"Missing", "UnsafeFields"}, want: exitGenerate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.app.run(tt.path, tt.types, tt.skips)
+			if err == nil {
+				t.Fatal("run() succeeded")
+			}
+			if got := exitCode(err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+
+	_, errs := (&app{}).generateBatch([]batchEntry{
+		{line: 1, pattern: "./testdata", kind: "Missing", skips: skips{}},
+		{line: 2, pattern: "./testdata/missing", kind: "Foo", skips: skips{}},
+	})
+	if got := batchExitCode(errs); got != exitResolve {
+		t.Errorf("batchExitCode(%v) = %d, want %d", errs, got, exitResolve)
+	}
+}
+
+func Test_qualifiedEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   typesVal
+		skips   skipsVal
+		path    string
+		want    []batchEntry
+		wantErr string
+	}{
+		{
+			name:  "qualified",
+			types: typesVal{"./testdata.Foo", "github.com/texazcowboy/deep-copy/testdata/iface_map.Drawing"},
+			skips: skipsVal{{"ch": struct{}{}}},
+			want: []batchEntry{
+				{pattern: "./testdata", kind: "Foo", skips: skips{"ch": struct{}{}}},
+				{pattern: "github.com/texazcowboy/deep-copy/testdata/iface_map", kind: "Drawing", skips: skips{}},
+			},
+		},
+		{
+			name:  "mixed with package path",
+			types: typesVal{"Foo", "./testdata/iface_map.Drawing"},
+			path:  "./testdata",
+			want: []batchEntry{
+				{pattern: "./testdata", kind: "Foo", skips: skips{}},
+				{pattern: "./testdata/iface_map", kind: "Drawing", skips: skips{}},
+			},
+		},
+		{name: "mixed without package path", types: typesVal{"Foo", "./testdata/iface_map.Drawing"}, wantErr: `type "Foo" is not qualified`},
+		{name: "missing type name", types: typesVal{"./testdata."}, wantErr: "has no type name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := qualifiedEntries(tt.types, tt.skips, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("qualifiedEntries() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want, cmp.AllowUnexported(batchEntry{})); diff != "" {
+				t.Errorf("qualifiedEntries() diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_generateBatchQualified(t *testing.T) {
+	entries, err := qualifiedEntries(typesVal{"./testdata.Bar", "./testdata/iface_map.Drawing", "./testdata/missing.Foo"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{}
+	files, errs := a.generateBatch(entries)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "./testdata/missing Foo: ") {
+		t.Errorf("generateBatch() errors = %v", errs)
+	}
+	if len(files) != 2 {
+		t.Fatalf("generateBatch() generated %d files, want 2", len(files))
+	}
+
+	wantPaths := []string{
+		filepath.Join("out", "testdata", batchOutput),
+		filepath.Join("out", "testdata", "iface_map", batchOutput),
+	}
+	for i, want := range wantPaths {
+		if got := files[i].outputPath("out"); got != want {
+			t.Errorf("outputPath() = %s, want %s", got, want)
+		}
+		if got := files[i].outputPath(""); got != files[i].path {
+			t.Errorf("outputPath() without a directory = %s, want %s", got, files[i].path)
+		}
+	}
+}
+
+func Test_outputVal(t *testing.T) {
+	tmp := t.TempDir()
+
+	var dir outputVal
+	if err := dir.Set(tmp); err != nil {
+		t.Fatal(err)
+	}
+	if !dir.dir || dir.file {
+		t.Errorf("Set(%s) dir = %v, file = %v, want a directory", tmp, dir.dir, dir.file)
+	}
+
+	name := filepath.Join(tmp, "models", "models_gen.go")
+	var file outputVal
+	if err := file.Set(name); err != nil {
+		t.Fatal(err)
+	}
+	if file.dir || !file.file {
+		t.Errorf("Set(%s) dir = %v, file = %v, want a file", name, file.dir, file.file)
+	}
+	if _, err := os.Stat(filepath.Dir(name)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Set(%s) created its directory, error = %v", name, err)
+	}
+
+	w, err := file.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "package models\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if got, err := readOutput(name); err != nil || string(got) != "package models\n" {
+		t.Errorf("readOutput(%s) = %q, %v", name, got, err)
+	}
+	if got, err := readOutput(filepath.Join(tmp, "missing.go")); err != nil || got != nil {
+		t.Errorf("readOutput() of a missing file = %q, %v, want nothing", got, err)
+	}
+}
+
+func Test_headerTemplate(t *testing.T) {
+	tmpl, err := template.New("header").Parse(`// Copyright Acme Inc.
+// Code generated for {{.Package}}: {{range $i, $t := .Types}}{{if $i}}, {{end}}{{$t}}{{end}}; DO NOT EDIT.
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{headerTemplate: tmpl}
+	got, err := a.run("./testdata", typesVal{"Bar", "Baz"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `// Copyright Acme Inc.
+// Code generated for testdata: Bar, Baz; DO NOT EDIT.
+
+package testdata
+
+`
+	if !strings.HasPrefix(string(got), want) {
+		t.Errorf("run() header = %q, want prefix %q", got, want)
+	}
+
+	a.headerTemplate = template.Must(template.New("header").Parse("{{.Missing}}"))
+	if _, err := a.run("./testdata", typesVal{"Bar"}, nil); err == nil || !strings.Contains(err.Error(), "executing header template") {
+		t.Errorf("run() error = %v, want a template execution error", err)
+	}
+}
+
+func Test_taggedTypes(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		types    typesVal
+		want     typesVal
+		wantKeep []int
+	}{
+		{name: "all types", key: "copy", want: typesVal{"RequireTag"}},
+		{name: "given types", key: "json", types: typesVal{"Foo", "Pod", "Missing", "RequireTag"}, want: typesVal{"Pod", "Missing", "RequireTag"}, wantKeep: []int{1, 2, 3}},
+		{name: "no tagged types", key: "deepcopy", types: typesVal{"Foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep := taggedTypes(p, tt.key, tt.types)
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("taggedTypes() diff = %s", diff)
+			}
+			if tt.types != nil {
+				if diff := cmp.Diff(keep, tt.wantKeep); diff != "" {
+					t.Errorf("taggedTypes() keep diff = %s", diff)
+				}
+			}
+		})
+	}
+}
+
+func Test_insertGenerated(t *testing.T) {
+	a := &app{}
+	generated, err := a.run("./testdata/external_pointer", typesVal{"Holder"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := []byte(`package external_pointer
+
+import "fmt"
+
+// String is written by hand.
+func (h Holder) String() string {
+	return fmt.Sprint(h.Item)
+}
+
+// deep-copy:begin
+stale content
+// deep-copy:end
+
+func after() {}
+`)
+
+	got, err := insertGenerated(existing, generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), InsertedFile); diff != "" {
+		t.Errorf("insertGenerated() diff = %s", diff)
+	}
+
+	again, err := insertGenerated(got, generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(again), string(got)); diff != "" {
+		t.Errorf("insertGenerated() isn't idempotent, diff = %s", diff)
+	}
+
+	appended, err := insertGenerated([]byte("package external_pointer\n\nfunc before() {}\n"), generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(appended, []byte("func before() {}\n\n// deep-copy:begin")) || !bytes.Contains(appended, []byte("func (o Holder) DeepCopy() Holder")) {
+		t.Errorf("insertGenerated() = %s, want the region appended", appended)
+	}
+
+	// The output file doesn't exist yet, the whole file is generated with
+	// its markers.
+	missing, err := readOutput(filepath.Join(t.TempDir(), "holder_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := insertGenerated(missing, generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(created, []byte("// generated by deep-copy")) || !bytes.Contains(created, []byte("// deep-copy:begin\n")) || !bytes.Contains(created, []byte("func (o Holder) DeepCopy() Holder")) {
+		t.Errorf("insertGenerated() into a missing file = %s, want the generated file with its markers", created)
+	}
+
+	if _, err := insertGenerated([]byte("package external_pointer\n\n// deep-copy:end\n"), generated); err == nil {
+		t.Error("insertGenerated() with unbalanced markers succeeded")
+	}
+}
+
+func Test_mergeGenerated(t *testing.T) {
+	a := &app{}
+	generated, err := a.run("./testdata/external_pointer", typesVal{"Holder"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	existing := []byte(`package external_pointer
+
+import "fmt"
+
+// String is written by hand.
+func (h Holder) String() string {
+	return fmt.Sprint(h.Item)
+}
+
+// DeepCopy is stale.
+func (h Holder) DeepCopy() Holder {
+	return h
+}
+
+func after() {}
+`)
+
+	got, err := mergeGenerated(existing, generated, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), MergedFile); diff != "" {
+		t.Errorf("mergeGenerated() inplace diff = %s", diff)
+	}
+
+	again, err := mergeGenerated(got, generated, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(again), string(got)); diff != "" {
+		t.Errorf("mergeGenerated() inplace isn't idempotent, diff = %s", diff)
+	}
+
+	kept, err := mergeGenerated(existing, generated, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := format.Source(existing); !bytes.Equal(kept, want) {
+		t.Errorf("mergeGenerated() append = %s, want the declared method and imports kept", kept)
+	}
+
+	appended, err := mergeGenerated([]byte("package external_pointer\n\nfunc before() {}\n"), generated, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(appended, []byte("package external_pointer\n\nimport \"github.com/texazcowboy/deep-copy/testdata/import_alias/item\"\n\nfunc before() {}\n\n// DeepCopy generates")) {
+		t.Errorf("mergeGenerated() append = %s, want the method appended", appended)
+	}
+
+	if got, err := mergeGenerated(nil, generated, false); err != nil || !bytes.Equal(got, generated) {
+		t.Errorf("mergeGenerated() into an empty file = %s, %v, want the generated file", got, err)
+	}
+}
+
+func Test_mergeGeneratedAppend(t *testing.T) {
+	a := &app{}
+	foo, err := a.run("./testdata", typesVal{"Foo"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, types := range []typesVal{{"Bar"}, {"Foo", "Bar"}} {
+		t.Run(strings.Join(types, ", "), func(t *testing.T) {
+			generated, err := a.run("./testdata", types, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := mergeGenerated(foo, generated, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, method := range []string{"func (o Foo) DeepCopy() Foo {", "func (o Bar) DeepCopy() Bar {"} {
+				if n := strings.Count(string(got), method); n != 1 {
+					t.Errorf("mergeGenerated() declares %q %d times, want once:\n%s", method, n, got)
+				}
+			}
+			if !bytes.HasPrefix(got, foo) {
+				t.Errorf("mergeGenerated() = %s, want the existing file kept as is", got)
+			}
+		})
+	}
+}
+
+func Test_list(t *testing.T) {
+	tests := []struct {
+		name string
+		tags string
+		only func(*types.TypeName) bool
+		want string
+	}{
+		{name: "list", want: ListFile},
+		{name: "list with tags", tags: "listextra", want: ListTaggedFile},
+		{name: "list types", only: copyableTypes(false), want: ListTypesFile},
+		{name: "list all types", only: copyableTypes(true), want: ListAllTypesFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &app{tags: tt.tags}
+			got, err := a.list("./testdata/list", tt.only)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), tt.want); diff != "" {
+				t.Errorf("list() diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_dir(t *testing.T) {
+	a := &app{dir: "./testdata"}
+	got, err := a.run("./external_pointer", typesVal{"Holder"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), ExternalPointer); diff != "" {
+		t.Errorf("run() diff = %s", diff)
+	}
+
+	// Packages of another module are resolved in it.
+	mod := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/other\n\ngo 1.19\n",
+		"pkg/other.go": "package pkg\n\ntype Other struct {\n\tNames []string\n}\n",
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(mod, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(mod, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a = &app{dir: mod}
+	p, err := a.loadPackage("./pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PkgPath != "example.com/other/pkg" {
+		t.Errorf("loadPackage() = %s, want example.com/other/pkg", p.PkgPath)
+	}
+	if _, err := a.generate(p, typesVal{"Other"}, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_packagesDriver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake driver is a shell script")
+	}
+
+	source, err := filepath.Abs("./testdata/driver/driven.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := json.Marshal(map[string]interface{}{
+		"Roots": []string{"example.com/driven"},
+		"Packages": []map[string]interface{}{{
+			"ID":              "example.com/driven",
+			"Name":            "driven",
+			"PkgPath":         "example.com/driven",
+			"GoFiles":         []string{source},
+			"CompiledGoFiles": []string{source},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The driver records the request it is given, and resolves every pattern
+	// to the package of driven.go, which the go command couldn't find.
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "response.json"), response, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	driver := filepath.Join(tmp, "driver.sh")
+	script := fmt.Sprintf("#!/bin/sh\ncat > %q\ncat %q\n", filepath.Join(tmp, "request.json"), filepath.Join(tmp, "response.json"))
+	if err := os.WriteFile(driver, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{
+		env:        []string{"GOPACKAGESDRIVER=" + driver, "DEEP_COPY_TEST=driver"},
+		buildFlags: []string{"-mod=mod"},
+		tags:       "integration",
+	}
+	got, err := a.run("//driven:go_default_library", typesVal{"Driven"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "func (o Driven) DeepCopy() Driven {") || !strings.Contains(string(got), "cp.Index = make(map[string]int, len(o.Index))") {
+		t.Errorf("run() = %s, want the package resolved by the driver copied", got)
+	}
+
+	b, err := os.ReadFile(filepath.Join(tmp, "request.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		Env        []string `json:"env"`
+		BuildFlags []string `json:"build_flags"`
+	}
+	if err := json.Unmarshal(b, &request); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(request.BuildFlags, []string{"-mod=mod", "-tags=integration"}); diff != "" {
+		t.Errorf("driver build flags diff = %s", diff)
+	}
+	if n := len(request.Env); n < 2 || request.Env[n-1] != "DEEP_COPY_TEST=driver" {
+		t.Errorf("driver env = %v, want the environment along with -env", request.Env)
+	}
+}
+
+func Test_outputUpToDate(t *testing.T) {
+	tmp := t.TempDir()
+	source, generated, empty := filepath.Join(tmp, "foo.go"), filepath.Join(tmp, "foo_gen.go"), filepath.Join(tmp, "empty.go")
+	for _, name := range []string{source, generated, empty} {
+		if err := os.WriteFile(name, []byte("package foo\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		output  string
+		sources []string
+		source  time.Time
+		want    bool
+		wantErr bool
+	}{
+		{name: "older source", output: generated, sources: []string{source, generated}, source: now.Add(-time.Hour), want: true},
+		{name: "newer source", output: generated, sources: []string{source, generated}, source: now.Add(time.Hour)},
+		{name: "same mtime", output: generated, sources: []string{source}, source: now},
+		{name: "new output", output: empty, sources: []string{source}, source: now.Add(-time.Hour)},
+		{name: "missing output", output: filepath.Join(tmp, "missing.go"), sources: []string{source}, source: now.Add(-time.Hour)},
+		{name: "missing source", output: generated, sources: []string{filepath.Join(tmp, "missing.go")}, source: now.Add(-time.Hour), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chtimes(source, tt.source, tt.source); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(generated, now, now); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(empty, now, now); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := outputUpToDate(tt.output, tt.sources)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outputUpToDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("outputUpToDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_extendable(t *testing.T) {
+	readOnly := t.TempDir()
+	if err := os.WriteFile(filepath.Join(readOnly, "dep.go"), []byte("package dep\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(readOnly, 0o755) })
+
+	tests := []struct {
+		name    string
+		p       *packages.Package
+		wantErr string
+	}{
+		{name: "package of the module", p: &packages.Package{PkgPath: "github.com/texazcowboy/deep-copy/testdata", GoFiles: []string{"testdata/foo.go"}}},
+		{name: "source without files", p: &packages.Package{PkgPath: "foo"}},
+		{name: "standard library", p: &packages.Package{PkgPath: "time", GoFiles: []string{filepath.Join(build.Default.GOROOT, "src", "time", "time.go")}}, wantErr: "time is part of the standard library"},
+		{name: "read-only directory", p: &packages.Package{PkgPath: "example.com/dep", GoFiles: []string{filepath.Join(readOnly, "dep.go")}}, wantErr: "example.com/dep is in the read-only directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := extendable(tt.p)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("extendable() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_outputInPackage(t *testing.T) {
+	abs, err := filepath.Abs("testdata/foo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &packages.Package{PkgPath: "github.com/texazcowboy/deep-copy/testdata", GoFiles: []string{abs}}
+
+	tests := []struct {
+		name    string
+		output  string
+		wantErr bool
+	}{
+		{name: "next to the package", output: "testdata/foo_deepcopy.go"},
+		{name: "absolute path", output: filepath.Join(filepath.Dir(abs), "foo_deepcopy.go")},
+		{name: "another package", output: "testdata/models/foo_deepcopy.go", wantErr: true},
+		{name: "another directory", output: filepath.Join(t.TempDir(), "foo_deepcopy.go"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := outputInPackage(p, tt.output); (err != nil) != tt.wantErr {
+				t.Errorf("outputInPackage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_workspace(t *testing.T) {
+	// The modules of the workspace don't require each other, the imports
+	// are only resolved through go.work.
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOFLAGS", "")
+
+	tests := []struct {
+		name string
+		dir  string
+		path string
+	}{
+		{name: "workspace root", dir: "./testdata/workspace", path: "example.com/one"},
+		{name: "module of the workspace", dir: "./testdata/workspace/one", path: "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &app{dir: tt.dir}
+			got, err := a.run(tt.path, typesVal{"A"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), "cp.B.Tags = make(map[string]string, len(o.B.Tags))") {
+				t.Errorf("run() = %s, want the embedded type of the other module copied", got)
+			}
+		})
+	}
+}
+
+func Test_checkChdirFirst(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "first", args: []string{"-C", "pkg", "-type", "Foo", "."}},
+		{name: "first with value", args: []string{"--C=pkg", "-type", "Foo", "."}},
+		{name: "none", args: []string{"-type", "Foo", "."}},
+		{name: "after another flag", args: []string{"-type", "Foo", "-C", "pkg", "."}, wantErr: true},
+		{name: "after --", args: []string{"-type", "Foo", "--", "-C"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkChdirFirst(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("checkChdirFirst() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_vendored(t *testing.T) {
+	// The go command would download the vendored module, which doesn't
+	// exist, with -mod=mod.
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	a := &app{dir: "./testdata/vendored"}
+	if mod := a.modFlag(); mod != "vendor" {
+		t.Errorf("modFlag() = %q, want vendor", mod)
+	}
+	got, err := a.run(".", typesVal{"Service"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"\t\"example.com/dep\"\n", "cp.Backups = make([]*dep.Config, len(o.Backups))", "cp.Config.Hosts = make([]string, len(o.Config.Hosts))"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("run() = %s, want it to contain %q", got, want)
+		}
+	}
+
+	if mod := (&app{dir: "./testdata/vendored", buildFlags: []string{"-mod=readonly"}}).modFlag(); mod != "" {
+		t.Errorf("modFlag() with -buildflags -mod=readonly = %q, want none", mod)
+	}
+	if mod := (&app{dir: "./testdata"}).modFlag(); mod != "" {
+		t.Errorf("modFlag() without a vendor directory = %q, want none", mod)
+	}
+
+	for path, want := range map[string]string{
+		"example.com/dep":                        "example.com/dep",
+		"example.com/app/vendor/example.com/dep": "example.com/dep",
+		"vendor/golang.org/x/net/http2/hpack":    "golang.org/x/net/http2/hpack",
+	} {
+		if got := unvendoredPath(path); got != want {
+			t.Errorf("unvendoredPath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func Test_buildConstraint(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   typesVal
+		want    string
+		wantErr string
+	}{
+		{name: "file name", types: typesVal{"Config", "Mount"}, want: ConstrainedFile},
+		{name: "go:build line", types: typesVal{"Tuning"}, want: "//go:build linux && (amd64 || arm64)\n"},
+		{name: "no constraint", types: typesVal{"Portable"}},
+		{name: "conflicting constraints", types: typesVal{"Config", "Tuning"}, wantErr: `Config is declared under the build constraint "linux", and Tuning under the build constraint "linux && (amd64 || arm64)"`},
+		{name: "constraint and none", types: typesVal{"Portable", "Config"}, wantErr: `Config is declared under the build constraint "linux", and Portable under no build constraint`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The files constrained to linux are loaded on every platform.
+			a := &app{env: []string{"GOOS=linux", "GOARCH=amd64"}}
+			got, err := a.run("./testdata/constrained", tt.types, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case tt.want == "":
+				if bytes.Contains(got, []byte("//go:build")) {
+					t.Errorf("run() = %s, want no build constraint", got)
+				}
+			case strings.HasPrefix(tt.want, "//go:build"):
+				if !bytes.Contains(got, []byte("\n\n"+tt.want+"\npackage constrained\n")) {
+					t.Errorf("run() = %s, want the build constraint %q", got, tt.want)
+				}
+			default:
+				if diff := cmp.Diff(string(normalizeComment(got)), tt.want); diff != "" {
+					t.Errorf("run() diff = %s", diff)
+				}
+			}
+		})
+	}
+
+	for name, want := range map[string]string{
+		"config.go":                "",
+		"linux.go":                 "",
+		"config_linux.go":          "linux",
+		"config_linux_test.go":     "linux",
+		"config_linux_arm64.go":    "linux && arm64",
+		"config_amd64.go":          "amd64",
+		"config_amd64_linux.go":    "linux",
+		"config_unix.go":           "",
+		"path/to/config_darwin.go": "darwin",
+	} {
+		var got string
+		if e := nameConstraint(name); e != nil {
+			got = e.String()
+		}
+		if got != want {
+			t.Errorf("nameConstraint(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_overlay(t *testing.T) {
+	foo, err := os.ReadFile("./testdata/foo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp := t.TempDir()
+	buffer := filepath.Join(tmp, "foo.go")
+	unsaved := bytes.Replace(foo, []byte("\tSlice []string\n"), []byte("\tSlice []string\n\tNames map[string]string\n"), 1)
+	if err := os.WriteFile(buffer, unsaved, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, err := filepath.Abs("./testdata/foo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := json.Marshal(overlayFile{Replace: map[string]string{abs: buffer}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(tmp, "overlay.json")
+	if err := os.WriteFile(name, config, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := readOverlay(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &app{overlay: overlay}
+	got, err := a.run("./testdata", typesVal{"Bar"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "cp.Names = make(map[string]string, len(o.Names))") {
+		t.Errorf("run() = %s, want the field of the overlay copied", got)
+	}
+
+	if err := os.WriteFile(name, []byte(`{"Replace": {"foo.go": ""}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readOverlay(name); err == nil || !strings.Contains(err.Error(), "foo.go is deleted") {
+		t.Errorf("readOverlay() of a deleted file error = %v, want it unsupported", err)
+	}
+}
+
+func Test_inputFile(t *testing.T) {
+	abs, err := filepath.Abs("./testdata/iface_impl/iface_impl.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := &app{dir: "./testdata/long_lines"}
+	p, err := a.loadPackage("file=" + abs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "iface_impl" {
+		t.Errorf("loadPackage() = %s, want the package of the file, iface_impl", p.Name)
+	}
+
+	if _, err := a.loadPackage("file=" + filepath.Join(filepath.Dir(abs), "missing.go")); err == nil || exitCode(err) != exitResolve {
+		t.Errorf("loadPackage() of a missing file error = %v, want a resolution error", err)
+	}
+}
+
+func Test_loadSource(t *testing.T) {
+	a := &app{dir: "./testdata"}
+	p, err := a.loadSource([]byte("package shapes\n\ntype Shape struct {\n\tPoints []int\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PkgPath != srcModule || a.dir != "./testdata" {
+		t.Errorf("loadSource() = %s, dir = %s, want %s and the dir left as is", p.PkgPath, a.dir, srcModule)
+	}
+
+	got, err := a.generate(p, typesVal{"Shape"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "package shapes\n"; !strings.Contains(string(got), want) || !strings.Contains(string(got), "copy(cp.Points, o.Points)") {
+		t.Errorf("generate() = %s, want the method of Shape in package shapes", got)
+	}
+
+	if _, err := a.loadSource([]byte("package shapes\n\nimport \"example.com/geo\"\n\ntype Shape struct {\n\tP geo.Point\n}\n")); err == nil || !strings.Contains(err.Error(), "only the standard library") || exitCode(err) != exitResolve {
+		t.Errorf("loadSource() with an external import error = %v, want a resolution error", err)
+	}
+}
+
+func Test_shortenLines(t *testing.T) {
+	src := []byte(`package p
+
+func (o Box[T]) DeepCopy() Box[T] {
+	cp := o
+	cp.Items = make(map[string][]map[string][]T, len(o.Items))
+	return cp
+}
+`)
+	got, err := shortenLines(src, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), string(src)); diff != "" {
+		t.Errorf("shortenLines() of a generic method diff = %s", diff)
+	}
+
+	got, err = shortenLines(bytes.Replace(src, []byte("Box[T]"), []byte("Box"), 2), 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+func (o Box) DeepCopy() Box {
+	type cpType1 = []map[string][]T
+	type cpType2 = map[string]cpType1
+	cp := o
+	cp.Items = make(cpType2, len(o.Items))
+	return cp
+}
+`
+	if diff := cmp.Diff(string(got), want); diff != "" {
+		t.Errorf("shortenLines() diff = %s", diff)
+	}
+}
+
+func Test_generateFuzz(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs, err := locateTypes(p, typesVal{"FuzzTarget"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := a.generateFuzz(p, objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = normalizeComment(got)
+	if diff := cmp.Diff(string(got), FuzzTargetFuzzFile); diff != "" {
+		t.Errorf("generateFuzz() diff = %s", diff)
+	}
+}
+
+func Test_roundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		types         typesVal
+		path          string
+		bothReceivers bool
+		funcMode      bool
+		withError     bool
+		withContext   bool
+		maxDepth      int
+		nonNil        bool
+		noInitial     bool
+		guardNil      bool
+		ifaceImpls    ifaceImplsVal
+		program       string
+	}{
+		{name: "map of interfaces with DeepCopy", types: typesVal{"Drawing"}, path: "./testdata/iface_map", program: IfaceMapProgram},
+		{name: "registered interface impls", types: typesVal{"Drawing"}, ifaceImpls: ifaceImplsVal{{iface: "Shape", impls: []string{"Circle", "Square"}}}, path: "./testdata/iface_impl", program: IfaceImplProgram},
+		{name: "map of slice pointers", types: typesVal{"MapOfSlicePointers"}, path: "./testdata", program: MapOfSlicePointersProgram},
+		{name: "map of pointer slices", types: typesVal{"MapOfPointerSlices"}, path: "./testdata", program: MapOfPointerSlicesProgram},
+		{name: "nil and empty slices", types: typesVal{"I12NestedSlices", "I12StructWithMapOfSlices"}, path: "./testdata", program: NilSlicesProgram},
+		{name: "generic types", types: typesVal{"Container", "Pair"}, path: "./testdata/generic", program: GenericProgram},
+		{name: "generic types, standalone functions", types: typesVal{"Container", "Pair"}, funcMode: true, path: "./testdata/generic", program: GenericFuncProgram},
+		{name: "maps of nil, empty and non-empty byte slices", types: typesVal{"ByteMaps"}, path: "./testdata", program: ByteMapsProgram},
+		{name: "non-nil collections", types: typesVal{"I12NestedSlices", "I12StructWithMapOfSlices"}, nonNil: true, path: "./testdata", program: NonNilCollectionsProgram},
+		{name: "no initial assign", types: typesVal{"Inventory"}, noInitial: true, path: "./testdata", program: NoInitialAssignProgram},
+		{name: "recursive tree", types: typesVal{"Node"}, path: "./testdata", program: TreeProgram},
+		{name: "DeepCopyInto methods", types: typesVal{"KubeHolder"}, path: "./testdata", program: KubeHolderProgram},
+		{name: "recursive tree, max depth", types: typesVal{"Node"}, maxDepth: 3, path: "./testdata", program: MaxDepthTreeProgram},
+		{name: "both receivers", types: typesVal{"Node"}, bothReceivers: true, path: "./testdata", program: BothReceiversProgram},
+		{name: "nil receiver guard", types: typesVal{"Node"}, guardNil: true, path: "./testdata", program: GuardNilReceiverProgram},
+		{name: "standalone functions", types: typesVal{"Node"}, funcMode: true, path: "./testdata", program: FuncProgram},
+		{name: "standalone functions in a helper package", types: typesVal{"User", "Team"}, funcMode: true, path: "./testdata/models", program: FuncModelsProgram},
+		{name: "command package", types: typesVal{"Options", "config"}, path: "./testdata/command", program: CommandProgram},
+		{name: "with error", types: typesVal{"Job", "Step"}, withError: true, path: "./testdata", program: WithErrorProgram},
+		{name: "with context", types: typesVal{"Job", "Step"}, withContext: true, path: "./testdata", program: WithContextProgram},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &app{bothReceivers: tt.bothReceivers, ptrMethodName: "DeepCopyPtr", funcMode: tt.funcMode, withError: tt.withError, withContext: tt.withContext, maxDepth: tt.maxDepth, nonNilCollections: tt.nonNil, noInitialAssign: tt.noInitial, isPtrRecv: tt.guardNil, guardNilReceiver: tt.guardNil, ifaceImpls: tt.ifaceImpls}
+			if tt.funcMode {
+				a.funcPackage = "main"
+			}
+			got, err := a.run(tt.path, tt.types, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.funcMode {
+				got = bytes.ReplaceAll(got, []byte(path.Join("github.com/texazcowboy/deep-copy", tt.path)), []byte("roundtrip/"+path.Base(tt.path)))
+				runRoundTrip(t, tt.path, nil, got, tt.program)
+				return
+			}
+			runRoundTrip(t, tt.path, got, nil, tt.program)
+		})
+	}
+}
+
+func Test_assertInterface(t *testing.T) {
+	const pkg = "github.com/texazcowboy/deep-copy/testdata/assert_iface"
+	tests := []struct {
+		name       string
+		interfaces []string
+		pointer    bool
+		want       string
+		wantErr    string
+	}{
+		{name: "generic interface", interfaces: []string{pkg + "/cloner.Cloner"}, want: AssertInterfaceFile},
+		{name: "generic interface, pointer receiver", interfaces: []string{pkg + "/cloner.Cloner"}, pointer: true, want: AssertInterfacePointerFile},
+		{name: "not an interface", interfaces: []string{pkg + ".Config"}, wantErr: "assert_iface.Config is not an interface"},
+		{name: "unknown interface", interfaces: []string{pkg + "/cloner.Missing"}, wantErr: "no type Missing in " + pkg + "/cloner"},
+		{name: "unqualified interface", interfaces: []string{"Cloner"}, wantErr: "not qualified as pkg/path.Interface"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &app{isPtrRecv: tt.pointer}
+			for _, v := range tt.interfaces {
+				iface, err := a.lookupInterface(v)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("lookupInterface() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				a.assertInterfaces = append(a.assertInterfaces, iface)
+			}
+
+			got, err := a.run("./testdata/assert_iface", typesVal{"Config", "Limits"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(normalizeComment(got)), tt.want); diff != "" {
+				t.Errorf("run() diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_assertImplements(t *testing.T) {
+	alias, f := flag.Lookup("assert-implements"), flag.Lookup("assert-interface")
+	if alias == nil || alias.Value != f.Value {
+		t.Errorf("-assert-implements doesn't set the interfaces of -assert-interface")
+	}
+}
+
+func Test_funcTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		types         typesVal
+		want          typesVal
+		wantQualifier string
+		wantErr       string
+	}{
+		{name: "unqualified", types: typesVal{"User", "Team"}, want: typesVal{"User", "Team"}},
+		{name: "qualified by the package name", types: typesVal{"models.User", "Team"}, want: typesVal{"User", "Team"}, wantQualifier: "models"},
+		{name: "qualified by an import path", types: typesVal{"example.com/models.User"}, wantErr: `"example.com/models.User" can only be qualified by the name of its package`},
+		{name: "different qualifiers", types: typesVal{"models.User", "teams.Team"}, wantErr: `"teams.Team" is qualified by teams, other types by models`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, qualifier, err := funcTypes(tt.types)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("funcTypes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("funcTypes() diff = %s", diff)
+			}
+			if qualifier != tt.wantQualifier {
+				t.Errorf("funcTypes() qualifier = %q, want %q", qualifier, tt.wantQualifier)
+			}
+		})
+	}
+}
+
+func Test_sliceOfNamedArrays(t *testing.T) {
+	a := &app{}
+	got, err := a.run("./testdata/named_array", typesVal{"Record"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), NamedArraySliceFile); diff != "" {
+		t.Errorf("run() diff = %s", diff)
+	}
+
+	runRoundTrip(t, "./testdata/named_array", got, nil, NamedArraySliceProgram)
+}
+
+func Test_sliceOfNamedBasics(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata/durations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	duration := p.Types.Imports()[0].Scope().Lookup("Duration").Type()
+	if !a.copiedByValue(duration, nil) {
+		t.Errorf("copiedByValue(%s) = false, want true", duration)
+	}
+
+	got, err := a.generate(p, typesVal{"Schedule"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), NamedBasicSliceFile); diff != "" {
+		t.Errorf("generate() diff = %s", diff)
+	}
+
+	runRoundTrip(t, "./testdata/durations", got, nil, NamedBasicSliceProgram)
+}
+
+func Test_sharedStdTypes(t *testing.T) {
+	a := &app{}
+	got, err := a.run("./testdata/shared_std", typesVal{"Router"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), SharedStdFile); diff != "" {
+		t.Errorf("run() diff = %s", diff)
+	}
+
+	runRoundTrip(t, "./testdata/shared_std", got, nil, SharedStdProgram)
+}
+
+func Test_mapOfArrayPointers(t *testing.T) {
+	a := &app{}
+	got, err := a.run("./testdata/array_map", typesVal{"Grid"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), ArrayPointerMapFile); diff != "" {
+		t.Errorf("run() diff = %s", diff)
+	}
+
+	runRoundTrip(t, "./testdata/array_map", got, nil, ArrayPointerMapProgram)
+}
+
+func Test_iotaEnum(t *testing.T) {
+	a := &app{}
+	p, err := a.loadPackage("./testdata/enum_iota")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Status", "Account"} {
+		obj, err := locateType(p.Name, name, p)
+		if err != nil {
+			t.Fatalf("locateType(%s) error = %v", name, err)
+		}
+		if obj.Obj().Name() != name {
+			t.Errorf("locateType(%s) = %s", name, obj)
+		}
+	}
+	for _, name := range []string{"Active", "DefaultStatus"} {
+		if obj, err := locateType(p.Name, name, p); err == nil {
+			t.Errorf("locateType(%s) = %s, want an error", name, obj)
+		}
+	}
+
+	got, err := a.generate(p, typesVal{"Account"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(normalizeComment(got)), IotaEnumFile); diff != "" {
+		t.Errorf("generate() diff = %s", diff)
+	}
+
+	runRoundTrip(t, "./testdata/enum_iota", got, nil, IotaEnumProgram)
+}
+
+// runRoundTrip copies the fixture package at dir into a temporary module,
+// adds the generated source to it and runs program, a main package that can
+// import the fixture as "roundtrip/<base of dir>". Generated functions are
+// added to the main package instead. The program of a command fixture is
+// added to the fixture, which is run itself.
+func runRoundTrip(t *testing.T, dir string, generated, funcs []byte, program string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping round trip in short mode")
+	}
+
+	tmp := t.TempDir()
+	pkg := filepath.Join(tmp, filepath.Base(dir))
+	if err := os.Mkdir(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := false
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), f, b, parser.PackageClauseOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		command = file.Name.Name == "main"
+		if err := os.WriteFile(filepath.Join(pkg, filepath.Base(f)), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writes := map[string][]byte{
+		filepath.Join(tmp, "go.mod"): []byte("module roundtrip\n\ngo 1.19\n"),
+	}
+	run := "."
+	if command {
+		writes[filepath.Join(pkg, "roundtrip.go")] = []byte(program)
+		run = "./" + filepath.Base(dir)
+	} else {
+		writes[filepath.Join(tmp, "main.go")] = []byte(program)
+	}
+	if generated != nil {
+		writes[filepath.Join(pkg, "deepcopy_gen.go")] = generated
+	}
+	if funcs != nil {
+		writes[filepath.Join(tmp, "deepcopy_gen.go")] = funcs
+	}
+	for name, b := range writes {
+		if err := os.WriteFile(name, b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command("go", "run", run)
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("running round trip: %v\n%s", err, out)
+	}
+}
+
+var re = regexp.MustCompile(`generated by .*deep-copy.*; DO NOT EDIT.`)
+
+func normalizeComment(in []byte) []byte {
+	return re.ReplaceAll(bytes.TrimSpace(in), []byte("generated by deep-copy; DO NOT EDIT."))
+}
+
+const (
+	FooFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+	FooPointerFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Foo
+func (o *Foo) DeepCopy() *Foo {
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}`
+	FooPointerSkipSliceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Foo
+//
+// Not deeply copied:
+//   - Map[k].Slice: skipped via -skip
+func (o *Foo) DeepCopy() *Foo {
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				// Map[k].Slice: skipped via -skip
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}`
+	FooSkipMapFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+//
+// Not deeply copied:
+//   - Map[k]: skipped via -skip
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	// Map[k]: skipped via -skip
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			cp.Map[k2] = v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+	AlphaPointer = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Alpha
+func (o Alpha) DeepCopy() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = o.B.DeepCopy()
+	}
+	cp.G = o.G.DeepCopy()
+	if o.D != nil {
+		retV := o.D.DeepCopy()
+		cp.D = &retV
+	}
+	{
+		retV := o.E.DeepCopy()
+		cp.E = *retV
+	}
+	return cp
+}`
+	SlicePointer = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SlicePointer
+//
+// Not deeply copied:
+//   - [i]: skipped via -skip
+func (o SlicePointer) DeepCopy() SlicePointer {
+	var cp SlicePointer = o
+	// [i]: skipped via -skip
+	if o != nil {
+		cp = make([]*int, len(o))
+		copy(cp, o)
+	}
+	return cp
+}`
+	FooAlphaSkips = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Alpha
+//
+// Not deeply copied:
+//   - D: skipped via -skip
+//   - E: skipped via -skip
+func (o Alpha) DeepCopy() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = o.B.DeepCopy()
+	}
+	cp.G = o.G.DeepCopy()
+	// D: skipped via -skip
+	// E: skipped via -skip
+	return cp
+}
+
+// DeepCopy generates a deep copy of Foo
+//
+// Not deeply copied:
+//   - Map[k]: skipped via -skip
+//   - ch: skipped via -skip
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	// Map[k]: skipped via -skip
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			cp.Map[k2] = v2
+		}
+	}
+	// ch: skipped via -skip
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+
+	Issue3SliceSimpleStruct = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *I3WithSlice
+func (o *I3WithSlice) DeepCopy() *I3WithSlice {
+	var cp I3WithSlice = *o
+	if o.a != nil {
+		cp.a = make([]I3SimpleStruct, len(o.a))
+		copy(cp.a, o.a)
+	}
+	return &cp
+}`
+	Issue3MapSimpleStructKey = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *I3WithMap
+func (o *I3WithMap) DeepCopy() *I3WithMap {
+	var cp I3WithMap = *o
+	if o.a != nil {
+		cp.a = make(map[I3SimpleStruct]string, len(o.a))
+		for k2, v2 := range o.a {
+			cp.a[k2] = v2
+		}
+	}
+	return &cp
+}`
+	Issue3MapSimpleStructVal = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of I3WithMapVal
+func (o I3WithMapVal) DeepCopy() I3WithMapVal {
+	var cp I3WithMapVal = o
+	if o.a != nil {
+		cp.a = make(map[string]I3SimpleStruct, len(o.a))
+		for k2, v2 := range o.a {
+			cp.a[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	Issue7ShadowedMapVars = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SomeStruct2
+func (o SomeStruct2) DeepCopy() SomeStruct2 {
 	var cp SomeStruct2 = o
 	if o.mapStruct != nil {
 		cp.mapStruct = make(map[string]SomeStruct, len(o.mapStruct))
@@ -304,250 +2383,4275 @@ func (o SomeStruct2) DeepCopy() SomeStruct2 {
 						cp_mapStruct_v2_mapSlice_v4 = make([]string, len(v4))
 						copy(cp_mapStruct_v2_mapSlice_v4, v4)
 					}
-					cp_mapStruct_v2.mapSlice[k4] = cp_mapStruct_v2_mapSlice_v4
+					cp_mapStruct_v2.mapSlice[k4] = cp_mapStruct_v2_mapSlice_v4
+				}
+			}
+			cp.mapStruct[k2] = cp_mapStruct_v2
+		}
+	}
+	return cp
+}`
+
+	Issue7ShadowedMapVars2 = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SomeStruct
+func (o SomeStruct) DeepCopy() SomeStruct {
+	var cp SomeStruct = o
+	if o.mapSlice != nil {
+		cp.mapSlice = make(map[string][]string, len(o.mapSlice))
+		for k2, v2 := range o.mapSlice {
+			var cp_mapSlice_v2 []string
+			if v2 != nil {
+				cp_mapSlice_v2 = make([]string, len(v2))
+				copy(cp_mapSlice_v2, v2)
+			}
+			cp.mapSlice[k2] = cp_mapSlice_v2
+		}
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of SomeStruct2
+func (o SomeStruct2) DeepCopy() SomeStruct2 {
+	var cp SomeStruct2 = o
+	if o.mapStruct != nil {
+		cp.mapStruct = make(map[string]SomeStruct, len(o.mapStruct))
+		for k2, v2 := range o.mapStruct {
+			var cp_mapStruct_v2 SomeStruct
+			cp_mapStruct_v2 = v2.DeepCopy()
+			cp.mapStruct[k2] = cp_mapStruct_v2
+		}
+	}
+	return cp
+}`
+
+	Issue10StructCH = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of StructCH
+func (o StructCH) DeepCopy() StructCH {
+	var cp StructCH = o
+	if o.Nested != nil {
+		cp.Nested = make([]StructNested, len(o.Nested))
+		copy(cp.Nested, o.Nested)
+		for i2 := range o.Nested {
+			if o.Nested[i2].B != nil {
+				cp.Nested[i2].B = new(int)
+				*cp.Nested[i2].B = *o.Nested[i2].B
+			}
+		}
+	}
+	return cp
+}`
+
+	PointerThatImplementsDeepcopy = `// generated by deep-copy; DO NOT EDIT.
+
+package somepkg
+
+// DeepCopy generates a deep copy of SomeStruct
+func (o SomeStruct) DeepCopy() SomeStruct {
+	var cp SomeStruct = o
+	if o.AnotherStruct != nil {
+		cp.AnotherStruct = o.AnotherStruct.DeepCopy()
+	}
+	return cp
+}`
+
+	Issue12NestedSlices = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of I12NestedSlices
+func (o I12NestedSlices) DeepCopy() I12NestedSlices {
+	var cp I12NestedSlices = o
+	if o.Slices != nil {
+		cp.Slices = make([][][]int, len(o.Slices))
+		copy(cp.Slices, o.Slices)
+		for i2 := range o.Slices {
+			if o.Slices[i2] != nil {
+				cp.Slices[i2] = make([][]int, len(o.Slices[i2]))
+				copy(cp.Slices[i2], o.Slices[i2])
+				for i3 := range o.Slices[i2] {
+					if o.Slices[i2][i3] != nil {
+						cp.Slices[i2][i3] = make([]int, len(o.Slices[i2][i3]))
+						copy(cp.Slices[i2][i3], o.Slices[i2][i3])
+					}
+				}
+			}
+		}
+	}
+	return cp
+}`
+
+	Issue12MapWithSliceValues = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of I12StructWithMapOfSlices
+func (o I12StructWithMapOfSlices) DeepCopy() I12StructWithMapOfSlices {
+	var cp I12StructWithMapOfSlices = o
+	if o.Sc1 != nil {
+		cp.Sc1 = make(map[string][]I12StructWithSlices, len(o.Sc1))
+		for k2, v2 := range o.Sc1 {
+			var cp_Sc1_v2 []I12StructWithSlices
+			if v2 != nil {
+				cp_Sc1_v2 = make([]I12StructWithSlices, len(v2))
+				copy(cp_Sc1_v2, v2)
+				for i3 := range v2 {
+					if v2[i3].Name != nil {
+						cp_Sc1_v2[i3].Name = make([]string, len(v2[i3].Name))
+						copy(cp_Sc1_v2[i3].Name, v2[i3].Name)
+					}
+				}
+			}
+			cp.Sc1[k2] = cp_Sc1_v2
+		}
+	}
+	return cp
+}`
+
+	I15ParentHasChildValueValueRecv = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Child
+func (o Child) DeepCopy() Child {
+	var cp Child = o
+	return cp
+}
+
+// DeepCopy generates a deep copy of ParentHasChildValue
+func (o ParentHasChildValue) DeepCopy() ParentHasChildValue {
+	var cp ParentHasChildValue = o
+	cp.c = o.c.DeepCopy()
+	return cp
+}`
+
+	I15ParentHasChildPointerValueRecv = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Child
+func (o Child) DeepCopy() Child {
+	var cp Child = o
+	return cp
+}
+
+// DeepCopy generates a deep copy of ParentHasChildPointer
+func (o ParentHasChildPointer) DeepCopy() ParentHasChildPointer {
+	var cp ParentHasChildPointer = o
+	if o.c != nil {
+		retV := o.c.DeepCopy()
+		cp.c = &retV
+	}
+	return cp
+}`
+
+	I15ParentHasChildValuePointerRecv = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Child
+func (o *Child) DeepCopy() *Child {
+	var cp Child = *o
+	return &cp
+}
+
+// DeepCopy generates a deep copy of *ParentHasChildValue
+func (o *ParentHasChildValue) DeepCopy() *ParentHasChildValue {
+	var cp ParentHasChildValue = *o
+	{
+		retV := o.c.DeepCopy()
+		cp.c = *retV
+	}
+	return &cp
+}`
+
+	I15ParentHasChildPointerPointerRecv = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Child
+func (o *Child) DeepCopy() *Child {
+	var cp Child = *o
+	return &cp
+}
+
+// DeepCopy generates a deep copy of *ParentHasChildPointer
+func (o *ParentHasChildPointer) DeepCopy() *ParentHasChildPointer {
+	var cp ParentHasChildPointer = *o
+	if o.c != nil {
+		cp.c = o.c.DeepCopy()
+	}
+	return &cp
+}`
+
+	Issue17MaxDepth = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Depth1
+//
+// Not deeply copied:
+//   - a1.b1: depth limit reached: shallow copy
+//   - a2.b1: depth limit reached: shallow copy
+func (o *Depth1) DeepCopy() *Depth1 {
+	var cp Depth1 = *o
+	if o.a1 != nil {
+		cp.a1 = new(Depth2)
+		*cp.a1 = *o.a1
+		// a1.b1: depth limit reached: shallow copy
+	}
+	if o.a2 != nil {
+		cp.a2 = new(Depth2)
+		*cp.a2 = *o.a2
+		// a2.b1: depth limit reached: shallow copy
+	}
+	return &cp
+}`
+
+	Issue17MaxDepth1 = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Depth1
+//
+// Not deeply copied:
+//   - a1: depth limit reached: shallow copy
+//   - a2: depth limit reached: shallow copy
+func (o *Depth1) DeepCopy() *Depth1 {
+	var cp Depth1 = *o
+	// a1: depth limit reached: shallow copy
+	// a2: depth limit reached: shallow copy
+	return &cp
+}`
+
+	AliasImport = `// generated by deep-copy; DO NOT EDIT.
+
+package import_alias
+
+import (
+	github_com_globusdigital_deep_copy_testdata_import_alias_another_item "github.com/globusdigital/deep-copy/testdata/import_alias/another/item"
+	"github.com/globusdigital/deep-copy/testdata/import_alias/item"
+)
+
+// DeepCopy generates a deep copy of Data
+func (o Data) DeepCopy() Data {
+	var cp Data = o
+	if o.Items != nil {
+		cp.Items = make([]item.Item, len(o.Items))
+		copy(cp.Items, o.Items)
+	}
+	if o.AnotherItems != nil {
+		cp.AnotherItems = make([]github_com_globusdigital_deep_copy_testdata_import_alias_another_item.Item, len(o.AnotherItems))
+		copy(cp.AnotherItems, o.AnotherItems)
+	}
+	return cp
+}`
+
+	IfaceMapDeepCopy = `// generated by deep-copy; DO NOT EDIT.
+
+package iface_map
+
+// DeepCopy generates a deep copy of Drawing
+func (o Drawing) DeepCopy() Drawing {
+	var cp Drawing = o
+	if o.Shapes != nil {
+		cp.Shapes = make(map[string]Shape, len(o.Shapes))
+		for k2, v2 := range o.Shapes {
+			var cp_Shapes_v2 Shape
+			if v2 != nil {
+				cp_Shapes_v2 = v2.DeepCopy()
+			}
+			cp.Shapes[k2] = cp_Shapes_v2
+		}
+	}
+	return cp
+}`
+
+	WithCacheFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of WithCache
+func (o WithCache) DeepCopy() WithCache {
+	var cp WithCache = o
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	if o.cache != nil {
+		cp.cache = make([]string, len(o.cache))
+		copy(cp.cache, o.cache)
+	}
+	if o.last != nil {
+		cp.last = new(int)
+		*cp.last = *o.last
+	}
+	return cp
+}`
+
+	WithCacheIgnoreUnexported = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of WithCache
+//
+// Not deeply copied:
+//   - cache: shallow copied via -ignore-unexported
+//   - last: shallow copied via -ignore-unexported
+func (o WithCache) DeepCopy() WithCache {
+	var cp WithCache = o
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	// cache: shallow copied via -ignore-unexported
+	// last: shallow copied via -ignore-unexported
+	return cp
+}`
+)
+
+const (
+	IfaceImplProgram = `package main
+
+import "roundtrip/iface_impl"
+
+func main() {
+	orig := iface_impl.Drawing{
+		Main:   iface_impl.Circle{Radius: 1, Labels: []string{"main"}},
+		Layers: []iface_impl.Shape{&iface_impl.Square{Side: 2, Points: []int{1}}, iface_impl.Triangle{Base: 1, Height: 2}, nil, iface_impl.Circle{Labels: []string{"layer"}}},
+		Named:  map[string]iface_impl.Shape{"square": &iface_impl.Square{Side: 3}},
+	}
+
+	cp := orig.DeepCopy()
+	orig.Main.(iface_impl.Circle).Labels[0] = "changed"
+	orig.Layers[0].(*iface_impl.Square).Points[0] = 42
+	orig.Layers[3].(iface_impl.Circle).Labels[0] = "changed"
+	orig.Named["square"].(*iface_impl.Square).Side = 42
+
+	if cp.Main.(iface_impl.Circle).Labels[0] != "main" {
+		panic("circle shares its labels with the original")
+	}
+	if cp.Layers[0].(*iface_impl.Square).Points[0] != 1 || cp.Named["square"].Area() != 9 {
+		panic("square shares state with the original")
+	}
+	if cp.Layers[3].(iface_impl.Circle).Labels[0] != "layer" {
+		panic("circle element shares its labels with the original")
+	}
+	if cp.Layers[1] != orig.Layers[1] || cp.Layers[2] != nil {
+		panic("unregistered or nil shapes not preserved")
+	}
+}
+`
+
+	IfaceMapProgram = `package main
+
+import "roundtrip/iface_map"
+
+func main() {
+	side := 2
+	orig := iface_map.Drawing{Shapes: map[string]iface_map.Shape{
+		"square": &iface_map.Square{Side: &side},
+		"none":   nil,
+	}}
+
+	cp := orig.DeepCopy()
+	*orig.Shapes["square"].(*iface_map.Square).Side = 42
+
+	if cp.Shapes["square"].Area() != 4 {
+		panic("copied shape shares state with the original")
+	}
+	if s, ok := cp.Shapes["none"]; !ok || s != nil {
+		panic("nil shape not preserved")
+	}
+}
+`
+
+	MapOfSlicePointers = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of MapOfSlicePointers
+func (o MapOfSlicePointers) DeepCopy() MapOfSlicePointers {
+	var cp MapOfSlicePointers = o
+	if o.M != nil {
+		cp.M = make(map[string]*[]int, len(o.M))
+		for k2, v2 := range o.M {
+			var cp_M_v2 *[]int
+			if v2 != nil {
+				cp_M_v2 = new([]int)
+				*cp_M_v2 = *v2
+				if (*v2) != nil {
+					(*cp_M_v2) = make([]int, len((*v2)))
+					copy((*cp_M_v2), (*v2))
+				}
+			}
+			cp.M[k2] = cp_M_v2
+		}
+	}
+	return cp
+}`
+
+	TreeFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Node
+func (o Node) DeepCopy() Node {
+	var cp Node = o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				retV := o.Children[i2].DeepCopy()
+				cp.Children[i2] = &retV
+			}
+		}
+	}
+	return cp
+}`
+
+	TreePointerFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Node
+func (o *Node) DeepCopy() *Node {
+	var cp Node = *o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				cp.Children[i2] = o.Children[i2].DeepCopy()
+			}
+		}
+	}
+	return &cp
+}`
+
+	InterfaceKeysFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of InterfaceKeys
+//
+// Not deeply copied:
+//   - S[i]: interface value shared
+func (o InterfaceKeys) DeepCopy() InterfaceKeys {
+	var cp InterfaceKeys = o
+	if o.M != nil {
+		cp.M = make(map[interface{}]string, len(o.M))
+		for k2, v2 := range o.M {
+			cp.M[k2] = v2
+		}
+	}
+	if o.K != nil {
+		cp.K = make(map[Keyer][]int, len(o.K))
+		for k2, v2 := range o.K {
+			var cp_K_v2 []int
+			if v2 != nil {
+				cp_K_v2 = make([]int, len(v2))
+				copy(cp_K_v2, v2)
+			}
+			cp.K[k2] = cp_K_v2
+		}
+	}
+	if o.S != nil {
+		cp.S = make([]interface{}, len(o.S))
+		copy(cp.S, o.S)
+		// S[i]: interface value shared
+	}
+	return cp
+}`
+
+	RequireTagKey = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of RequireTag
+//
+// Not deeply copied:
+//   - Shared: shallow copied, no copy tag
+func (o RequireTag) DeepCopy() RequireTag {
+	var cp RequireTag = o
+	if o.Copied != nil {
+		cp.Copied = make([]int, len(o.Copied))
+		copy(cp.Copied, o.Copied)
+	}
+	// Shared: shallow copied, no copy tag
+	if o.Other != nil {
+		cp.Other = new(int)
+		*cp.Other = *o.Other
+	}
+	if o.Map != nil {
+		cp.Map = make(map[string]int, len(o.Map))
+		for k2, v2 := range o.Map {
+			cp.Map[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	RequireTagKeyValue = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of RequireTag
+//
+// Not deeply copied:
+//   - Shared: shallow copied, no copy:true tag
+//   - Other: shallow copied, no copy:true tag
+func (o RequireTag) DeepCopy() RequireTag {
+	var cp RequireTag = o
+	if o.Copied != nil {
+		cp.Copied = make([]int, len(o.Copied))
+		copy(cp.Copied, o.Copied)
+	}
+	// Shared: shallow copied, no copy:true tag
+	// Other: shallow copied, no copy:true tag
+	if o.Map != nil {
+		cp.Map = make(map[string]int, len(o.Map))
+		for k2, v2 := range o.Map {
+			cp.Map[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	TreeBothReceiversFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Node
+func (o Node) DeepCopy() Node {
+	var cp Node = o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				retV := o.Children[i2].DeepCopy()
+				cp.Children[i2] = &retV
+			}
+		}
+	}
+	return cp
+}
+
+// DeepCopyPtr generates a deep copy of *Node
+func (o *Node) DeepCopyPtr() *Node {
+	if o == nil {
+		return nil
+	}
+	cp := o.DeepCopy()
+	return &cp
+}`
+
+	BarBothReceiversFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Bar
+func (o Bar) DeepCopy() Bar {
+	var cp Bar = o
+	if o.Slice != nil {
+		cp.Slice = make([]string, len(o.Slice))
+		copy(cp.Slice, o.Slice)
+	}
+	return cp
+}
+
+// ClonePtr generates a deep copy of *Bar
+func (o *Bar) ClonePtr() *Bar {
+	if o == nil {
+		return nil
+	}
+	cp := o.DeepCopy()
+	return &cp
+}`
+
+	SkipNestedFieldsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipNested
+//
+// Not deeply copied:
+//   - Items[i].Cache: skipped via -skip
+//   - M[k][k]: skipped via -skip
+//   - Deep[k][i].Cache: skipped via -skip
+func (o SkipNested) DeepCopy() SkipNested {
+	var cp SkipNested = o
+	if o.Items != nil {
+		cp.Items = make([]SkipItem, len(o.Items))
+		copy(cp.Items, o.Items)
+		for i2 := range o.Items {
+			// Items[i].Cache: skipped via -skip
+			if o.Items[i2].Tags != nil {
+				cp.Items[i2].Tags = make([]string, len(o.Items[i2].Tags))
+				copy(cp.Items[i2].Tags, o.Items[i2].Tags)
+			}
+		}
+	}
+	if o.M != nil {
+		cp.M = make(map[string]map[string][]int, len(o.M))
+		for k2, v2 := range o.M {
+			var cp_M_v2 map[string][]int
+			// M[k][k]: skipped via -skip
+			if v2 != nil {
+				cp_M_v2 = make(map[string][]int, len(v2))
+				for k3, v3 := range v2 {
+					cp_M_v2[k3] = v3
+				}
+			}
+			cp.M[k2] = cp_M_v2
+		}
+	}
+	if o.Deep != nil {
+		cp.Deep = make(map[string][]SkipItem, len(o.Deep))
+		for k2, v2 := range o.Deep {
+			var cp_Deep_v2 []SkipItem
+			if v2 != nil {
+				cp_Deep_v2 = make([]SkipItem, len(v2))
+				copy(cp_Deep_v2, v2)
+				for i3 := range v2 {
+					// Deep[k][i].Cache: skipped via -skip
+					if v2[i3].Tags != nil {
+						cp_Deep_v2[i3].Tags = make([]string, len(v2[i3].Tags))
+						copy(cp_Deep_v2[i3].Tags, v2[i3].Tags)
+					}
+				}
+			}
+			cp.Deep[k2] = cp_Deep_v2
+		}
+	}
+	return cp
+}`
+
+	SkipNestedElementsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipNested
+//
+// Not deeply copied:
+//   - Items[i]: skipped via -skip
+//   - M[k]: skipped via -skip
+//   - Deep[k][i]: skipped via -skip
+func (o SkipNested) DeepCopy() SkipNested {
+	var cp SkipNested = o
+	// Items[i]: skipped via -skip
+	if o.Items != nil {
+		cp.Items = make([]SkipItem, len(o.Items))
+		copy(cp.Items, o.Items)
+	}
+	// M[k]: skipped via -skip
+	if o.M != nil {
+		cp.M = make(map[string]map[string][]int, len(o.M))
+		for k2, v2 := range o.M {
+			cp.M[k2] = v2
+		}
+	}
+	if o.Deep != nil {
+		cp.Deep = make(map[string][]SkipItem, len(o.Deep))
+		for k2, v2 := range o.Deep {
+			var cp_Deep_v2 []SkipItem
+			// Deep[k][i]: skipped via -skip
+			if v2 != nil {
+				cp_Deep_v2 = make([]SkipItem, len(v2))
+				copy(cp_Deep_v2, v2)
+			}
+			cp.Deep[k2] = cp_Deep_v2
+		}
+	}
+	return cp
+}`
+
+	IgnoreErrorsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package external_pointer
+
+// DeepCopy generates a deep copy of Wrapper
+//
+// Not deeply copied:
+//   - H.Item: skipped via -skip
+func (o Wrapper) DeepCopy() Wrapper {
+	var cp Wrapper = o
+	if o.H != nil {
+		cp.H = new(Holder)
+		*cp.H = *o.H
+		// H.Item: skipped via -skip
+	}
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	return cp
+}`
+
+	ChanPolicyNilFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of ChanPolicy
+func (o ChanPolicy) DeepCopy() ChanPolicy {
+	var cp ChanPolicy = o
+	if o.Done != nil {
+		cp.Done = nil
+	}
+	if o.Workers != nil {
+		cp.Workers = make([]chan int, len(o.Workers))
+		copy(cp.Workers, o.Workers)
+		for i2 := range o.Workers {
+			if o.Workers[i2] != nil {
+				cp.Workers[i2] = nil
+			}
+		}
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]chan string, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			var cp_ByName_v2 chan string
+			if v2 != nil {
+				cp_ByName_v2 = nil
+			}
+			cp.ByName[k2] = cp_ByName_v2
+		}
+	}
+	if o.Ptr != nil {
+		cp.Ptr = new(chan bool)
+		*cp.Ptr = *o.Ptr
+		if (*o.Ptr) != nil {
+			(*cp.Ptr) = nil
+		}
+	}
+	return cp
+}`
+
+	ChanPolicyShareFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of ChanPolicy
+//
+// Not deeply copied:
+//   - Done: channel shared via -chan
+//   - Workers[i]: channel shared via -chan
+//   - ByName[k]: channel shared via -chan
+//   - Ptr: channel shared via -chan
+func (o ChanPolicy) DeepCopy() ChanPolicy {
+	var cp ChanPolicy = o
+	// Done: channel shared via -chan
+	if o.Workers != nil {
+		cp.Workers = make([]chan int, len(o.Workers))
+		copy(cp.Workers, o.Workers)
+		// Workers[i]: channel shared via -chan
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]chan string, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			// ByName[k]: channel shared via -chan
+			cp.ByName[k2] = v2
+		}
+	}
+	if o.Ptr != nil {
+		cp.Ptr = new(chan bool)
+		*cp.Ptr = *o.Ptr
+		// Ptr: channel shared via -chan
+	}
+	return cp
+}`
+
+	AlphaCloneReuseDeepCopy = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// Clone generates a deep copy of Alpha
+func (o Alpha) Clone() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = o.B.DeepCopy()
+	}
+	cp.G = o.G.DeepCopy()
+	if o.D != nil {
+		retV := o.D.DeepCopy()
+		cp.D = &retV
+	}
+	{
+		retV := o.E.DeepCopy()
+		cp.E = *retV
+	}
+	return cp
+}`
+
+	AlphaCloneFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// Clone generates a deep copy of Alpha
+func (o Alpha) Clone() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = new(Beta)
+		*cp.B = *o.B
+		if o.B.ch != nil {
+			cp.B.ch = make(chan int, cap(o.B.ch))
+		}
+	}
+	if o.D != nil {
+		cp.D = new(Delta)
+		*cp.D = *o.D
+	}
+	return cp
+}`
+
+	AnonymousStructFile = `// generated by deep-copy; DO NOT EDIT.
+
+package anon_struct
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+)
+
+// DeepCopy generates a deep copy of Anonymous
+func (o Anonymous) DeepCopy() Anonymous {
+	var cp Anonymous = o
+	if o.Entries != nil {
+		cp.Entries = make([]struct {
+			Item  *item.Item
+			Items []item.Item
+		}, len(o.Entries))
+		copy(cp.Entries, o.Entries)
+		for i2 := range o.Entries {
+			if o.Entries[i2].Item != nil {
+				cp.Entries[i2].Item = new(item.Item)
+				*cp.Entries[i2].Item = *o.Entries[i2].Item
+			}
+			if o.Entries[i2].Items != nil {
+				cp.Entries[i2].Items = make([]item.Item, len(o.Entries[i2].Items))
+				copy(cp.Entries[i2].Items, o.Entries[i2].Items)
+			}
+		}
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]struct{ Item *item.Item }, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			var cp_ByName_v2 struct{ Item *item.Item }
+			if v2.Item != nil {
+				cp_ByName_v2.Item = new(item.Item)
+				*cp_ByName_v2.Item = *v2.Item
+			}
+			cp.ByName[k2] = cp_ByName_v2
+		}
+	}
+	return cp
+}`
+
+	SkipZeroSelectorsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipZero
+//
+// Not deeply copied:
+//   - Creds: zeroed via -skip
+//   - Token: zeroed via -skip
+//   - Secret: zeroed via -skip
+//   - Conns[i]: zeroed via -skip
+//   - Handles[k]: zeroed via -skip
+//   - Shared: skipped via -skip
+func (o SkipZero) DeepCopy() SkipZero {
+	var cp SkipZero = o
+	// Creds: zeroed via -skip
+	cp.Creds = nil
+	// Token: zeroed via -skip
+	cp.Token = ""
+	// Secret: zeroed via -skip
+	cp.Secret = Credentials{}
+	// Conns[i]: zeroed via -skip
+	if o.Conns != nil {
+		cp.Conns = make([]*Credentials, len(o.Conns))
+	}
+	// Handles[k]: zeroed via -skip
+	if o.Handles != nil {
+		cp.Handles = make(map[string]*Credentials, len(o.Handles))
+		for k2 := range o.Handles {
+			cp.Handles[k2] = nil
+		}
+	}
+	// Shared: skipped via -skip
+	return cp
+}`
+
+	SkipZeroAllFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipZero
+//
+// Not deeply copied:
+//   - Creds: zeroed via -skip
+//   - Shared: zeroed via -skip
+func (o SkipZero) DeepCopy() SkipZero {
+	var cp SkipZero = o
+	// Creds: zeroed via -skip
+	cp.Creds = nil
+	if o.Secret.Password != nil {
+		cp.Secret.Password = make([]byte, len(o.Secret.Password))
+		copy(cp.Secret.Password, o.Secret.Password)
+	}
+	if o.Conns != nil {
+		cp.Conns = make([]*Credentials, len(o.Conns))
+		copy(cp.Conns, o.Conns)
+		for i2 := range o.Conns {
+			if o.Conns[i2] != nil {
+				cp.Conns[i2] = new(Credentials)
+				*cp.Conns[i2] = *o.Conns[i2]
+				if o.Conns[i2].Password != nil {
+					cp.Conns[i2].Password = make([]byte, len(o.Conns[i2].Password))
+					copy(cp.Conns[i2].Password, o.Conns[i2].Password)
+				}
+			}
+		}
+	}
+	if o.Handles != nil {
+		cp.Handles = make(map[string]*Credentials, len(o.Handles))
+		for k2, v2 := range o.Handles {
+			var cp_Handles_v2 *Credentials
+			if v2 != nil {
+				cp_Handles_v2 = new(Credentials)
+				*cp_Handles_v2 = *v2
+				if v2.Password != nil {
+					cp_Handles_v2.Password = make([]byte, len(v2.Password))
+					copy(cp_Handles_v2.Password, v2.Password)
+				}
+			}
+			cp.Handles[k2] = cp_Handles_v2
+		}
+	}
+	// Shared: zeroed via -skip
+	cp.Shared = nil
+	return cp
+}`
+	SkipZeroKindsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipZeroKinds
+//
+// Not deeply copied:
+//   - Name: zeroed via -skip
+//   - Enabled: zeroed via -skip
+//   - Count: zeroed via -skip
+//   - Ratio: zeroed via -skip
+//   - Creds: zeroed via -skip
+//   - Tags: zeroed via -skip
+//   - Labels: zeroed via -skip
+//   - Value: zeroed via -skip
+//   - Events: zeroed via -skip
+//   - Hook: zeroed via -skip
+//   - Secret: zeroed via -skip
+func (o SkipZeroKinds) DeepCopy() SkipZeroKinds {
+	var cp SkipZeroKinds = o
+	// Name: zeroed via -skip
+	cp.Name = ""
+	// Enabled: zeroed via -skip
+	cp.Enabled = false
+	// Count: zeroed via -skip
+	cp.Count = 0
+	// Ratio: zeroed via -skip
+	cp.Ratio = 0
+	// Creds: zeroed via -skip
+	cp.Creds = nil
+	// Tags: zeroed via -skip
+	cp.Tags = nil
+	// Labels: zeroed via -skip
+	cp.Labels = nil
+	// Value: zeroed via -skip
+	cp.Value = nil
+	// Events: zeroed via -skip
+	cp.Events = nil
+	// Hook: zeroed via -skip
+	cp.Hook = nil
+	// Secret: zeroed via -skip
+	cp.Secret = Credentials{}
+	return cp
+}`
+	IfaceImplFile = `// generated by deep-copy; DO NOT EDIT.
+
+package iface_impl
+
+// DeepCopy generates a deep copy of Drawing
+//
+// Not deeply copied:
+//   - Main: interface value shared, unless of a type registered with -iface-impl
+//   - Layers[i]: interface value shared, unless of a type registered with -iface-impl
+//   - Named[k]: interface value shared, unless of a type registered with -iface-impl
+func (o Drawing) DeepCopy() Drawing {
+	var cp Drawing = o
+	if o.Main != nil {
+		switch c2 := o.Main.(type) {
+		case Circle:
+			cp_Main_Circle := c2
+			cp_Main_Circle = c2.DeepCopy()
+			cp.Main = cp_Main_Circle
+		case *Square:
+			cp_Main_Square := c2
+			if c2 != nil {
+				cp_Main_Square = new(Square)
+				*cp_Main_Square = *c2
+				if c2.Points != nil {
+					cp_Main_Square.Points = make([]int, len(c2.Points))
+					copy(cp_Main_Square.Points, c2.Points)
+				}
+			}
+			cp.Main = cp_Main_Square
+		case Triangle:
+			cp.Main = c2
+		default:
+			// Main: interface value shared, unless of a type registered with -iface-impl
+			cp.Main = o.Main
+		}
+	}
+	if o.Layers != nil {
+		cp.Layers = make([]Shape, len(o.Layers))
+		copy(cp.Layers, o.Layers)
+		for i2 := range o.Layers {
+			if o.Layers[i2] != nil {
+				switch c3 := o.Layers[i2].(type) {
+				case Circle:
+					cp_Layers_i2_Circle := c3
+					cp_Layers_i2_Circle = c3.DeepCopy()
+					cp.Layers[i2] = cp_Layers_i2_Circle
+				case *Square:
+					cp_Layers_i2_Square := c3
+					if c3 != nil {
+						cp_Layers_i2_Square = new(Square)
+						*cp_Layers_i2_Square = *c3
+						if c3.Points != nil {
+							cp_Layers_i2_Square.Points = make([]int, len(c3.Points))
+							copy(cp_Layers_i2_Square.Points, c3.Points)
+						}
+					}
+					cp.Layers[i2] = cp_Layers_i2_Square
+				case Triangle:
+					cp.Layers[i2] = c3
+				default:
+					// Layers[i]: interface value shared, unless of a type registered with -iface-impl
+					cp.Layers[i2] = o.Layers[i2]
+				}
+			}
+		}
+	}
+	if o.Named != nil {
+		cp.Named = make(map[string]Shape, len(o.Named))
+		for k2, v2 := range o.Named {
+			var cp_Named_v2 Shape
+			if v2 != nil {
+				switch c3 := v2.(type) {
+				case Circle:
+					cp_Named_v2_Circle := c3
+					cp_Named_v2_Circle = c3.DeepCopy()
+					cp_Named_v2 = cp_Named_v2_Circle
+				case *Square:
+					cp_Named_v2_Square := c3
+					if c3 != nil {
+						cp_Named_v2_Square = new(Square)
+						*cp_Named_v2_Square = *c3
+						if c3.Points != nil {
+							cp_Named_v2_Square.Points = make([]int, len(c3.Points))
+							copy(cp_Named_v2_Square.Points, c3.Points)
+						}
+					}
+					cp_Named_v2 = cp_Named_v2_Square
+				case Triangle:
+					cp_Named_v2 = c3
+				default:
+					// Named[k]: interface value shared, unless of a type registered with -iface-impl
+					cp_Named_v2 = v2
+				}
+			}
+			cp.Named[k2] = cp_Named_v2
+		}
+	}
+	return cp
+}`
+	IfaceImplCanvasFile = `// generated by deep-copy; DO NOT EDIT.
+
+package canvas
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/iface_impl"
+)
+
+// DeepCopy generates a deep copy of Canvas
+//
+// Not deeply copied:
+//   - Shapes[i]: interface value shared, unless of a type registered with -iface-impl
+func (o Canvas) DeepCopy() Canvas {
+	var cp Canvas = o
+	if o.Shapes != nil {
+		cp.Shapes = make([]iface_impl.Shape, len(o.Shapes))
+		copy(cp.Shapes, o.Shapes)
+		for i2 := range o.Shapes {
+			if o.Shapes[i2] != nil {
+				switch c3 := o.Shapes[i2].(type) {
+				case iface_impl.Circle:
+					cp_Shapes_i2_iface_impl_Circle := c3
+					cp_Shapes_i2_iface_impl_Circle = c3.DeepCopy()
+					cp.Shapes[i2] = cp_Shapes_i2_iface_impl_Circle
+				case *iface_impl.Square:
+					cp_Shapes_i2_iface_impl_Square := c3
+					if c3 != nil {
+						cp_Shapes_i2_iface_impl_Square = new(iface_impl.Square)
+						*cp_Shapes_i2_iface_impl_Square = *c3
+						if c3.Points != nil {
+							cp_Shapes_i2_iface_impl_Square.Points = make([]int, len(c3.Points))
+							copy(cp_Shapes_i2_iface_impl_Square.Points, c3.Points)
+						}
+					}
+					cp.Shapes[i2] = cp_Shapes_i2_iface_impl_Square
+				default:
+					// Shapes[i]: interface value shared, unless of a type registered with -iface-impl
+					cp.Shapes[i2] = o.Shapes[i2]
+				}
+			}
+		}
+	}
+	return cp
+}`
+	LongLinesFile = `// generated by deep-copy; DO NOT EDIT.
+
+package long_lines
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/long_lines/collections"
+)
+
+// DeepCopy generates a deep copy of Inventory
+func (o Inventory) DeepCopy() Inventory {
+	type cpType1 = collections.OrderedDictionary[string, []byte]
+	type cpType2 = collections.OrderedDictionary[string, cpType1]
+	type cpType3 = []cpType2
+	type cpType4 = map[string]cpType1
+	type cpType5 = map[string]*collections.OrderedDictionary[string, int]
+	var cp Inventory = o
+	if o.Sections != nil {
+		cp.Sections = make(cpType3, len(o.Sections))
+		copy(cp.Sections, o.Sections)
+		for i2 := range o.Sections {
+			if o.Sections[i2].Keys != nil {
+				cp.Sections[i2].Keys = make([]string, len(o.Sections[i2].Keys))
+				copy(cp.Sections[i2].Keys, o.Sections[i2].Keys)
+			}
+			if o.Sections[i2].Values != nil {
+				cp.Sections[i2].Values = make(cpType4, len(o.Sections[i2].Values))
+				for k4, v4 := range o.Sections[i2].Values {
+					var cp_Sections_i2_Values_v4 collections.OrderedDictionary[string, []byte]
+					if v4.Keys != nil {
+						cp_Sections_i2_Values_v4.Keys = make([]string, len(v4.Keys))
+						copy(cp_Sections_i2_Values_v4.Keys, v4.Keys)
+					}
+					if v4.Values != nil {
+						cp_Sections_i2_Values_v4.Values = make(map[string][]byte, len(v4.Values))
+						for k6, v6 := range v4.Values {
+							var cp_Sections_i2_Values_v4_Values_v6 []byte
+							if v6 != nil {
+								cp_Sections_i2_Values_v4_Values_v6 = make([]byte, len(v6))
+								copy(cp_Sections_i2_Values_v4_Values_v6, v6)
+							}
+							cp_Sections_i2_Values_v4.Values[k6] = cp_Sections_i2_Values_v4_Values_v6
+						}
+					}
+					cp.Sections[i2].Values[k4] = cp_Sections_i2_Values_v4
+				}
+			}
+		}
+	}
+	if o.Index != nil {
+		cp.Index = make(cpType5, len(o.Index))
+		for k2, v2 := range o.Index {
+			var cp_Index_v2 *collections.OrderedDictionary[string, int]
+			if v2 != nil {
+				cp_Index_v2 = new(collections.OrderedDictionary[string, int])
+				*cp_Index_v2 = *v2
+				if v2.Keys != nil {
+					cp_Index_v2.Keys = make([]string, len(v2.Keys))
+					copy(cp_Index_v2.Keys, v2.Keys)
+				}
+				if v2.Values != nil {
+					cp_Index_v2.Values = make(map[string]int, len(v2.Values))
+					for k5, v5 := range v2.Values {
+						cp_Index_v2.Values[k5] = v5
+					}
+				}
+			}
+			cp.Index[k2] = cp_Index_v2
+		}
+	}
+	return cp
+}`
+
+	ZeroSizeFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of ZeroSize
+func (o ZeroSize) DeepCopy() ZeroSize {
+	var cp ZeroSize = o
+	cp.Empty = o.Empty.DeepCopy()
+	if o.Set != nil {
+		cp.Set = make(map[string]struct{}, len(o.Set))
+		for k2, v2 := range o.Set {
+			cp.Set[k2] = v2
+		}
+	}
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	return cp
+}`
+
+	ZeroSizeSkippedFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of ZeroSize
+func (o ZeroSize) DeepCopy() ZeroSize {
+	var cp ZeroSize = o
+	if o.Set != nil {
+		cp.Set = make(map[string]struct{}, len(o.Set))
+		for k2, v2 := range o.Set {
+			cp.Set[k2] = v2
+		}
+	}
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	return cp
+}`
+
+	AnnotatedFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Annotated
+//
+// Not deeply copied:
+//   - OnDone: func value shared
+//   - raw: interface value shared
+//   - Count: skipped via -skip
+func (o Annotated) DeepCopy() Annotated {
+	var cp Annotated = o
+	// OnDone: func value shared
+	// raw: interface value shared
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	// Count: skipped via -skip
+	return cp
+}`
+
+	AnnotatedNoCommentsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Annotated
+func (o Annotated) DeepCopy() Annotated {
+	var cp Annotated = o
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	return cp
+}`
+
+	SkipByTagFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Pod
+//
+// Not deeply copied:
+//   - Meta.Labels: skipped via -skip
+//   - Spec.Containers[i].SecurityContext: skipped via -skip
+//   - Spec.Containers[i].Args: skipped via -skip
+//   - Spec.Volumes: zeroed via -skip
+func (o Pod) DeepCopy() Pod {
+	var cp Pod = o
+	// Meta.Labels: skipped via -skip
+	if o.Spec.Containers != nil {
+		cp.Spec.Containers = make([]*Container, len(o.Spec.Containers))
+		copy(cp.Spec.Containers, o.Spec.Containers)
+		for i3 := range o.Spec.Containers {
+			if o.Spec.Containers[i3] != nil {
+				cp.Spec.Containers[i3] = new(Container)
+				*cp.Spec.Containers[i3] = *o.Spec.Containers[i3]
+				// Spec.Containers[i].SecurityContext: skipped via -skip
+				// Spec.Containers[i].Args: skipped via -skip
+			}
+		}
+	}
+	// Spec.Volumes: zeroed via -skip
+	cp.Spec.Volumes = nil
+	if o.Spec.Internal != nil {
+		cp.Spec.Internal = make([]string, len(o.Spec.Internal))
+		copy(cp.Spec.Internal, o.Spec.Internal)
+	}
+	return cp
+}`
+
+	SkipByTagWithSkipsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Pod
+//
+// Not deeply copied:
+//   - Meta.Labels: skipped via -skip
+//   - Spec.Internal: skipped via -skip
+func (o Pod) DeepCopy() Pod {
+	var cp Pod = o
+	// Meta.Labels: skipped via -skip
+	if o.Spec.Containers != nil {
+		cp.Spec.Containers = make([]*Container, len(o.Spec.Containers))
+		copy(cp.Spec.Containers, o.Spec.Containers)
+		for i3 := range o.Spec.Containers {
+			if o.Spec.Containers[i3] != nil {
+				cp.Spec.Containers[i3] = new(Container)
+				*cp.Spec.Containers[i3] = *o.Spec.Containers[i3]
+				if o.Spec.Containers[i3].SecurityContext != nil {
+					cp.Spec.Containers[i3].SecurityContext = new(SecurityContext)
+					*cp.Spec.Containers[i3].SecurityContext = *o.Spec.Containers[i3].SecurityContext
+					if o.Spec.Containers[i3].SecurityContext.Capabilities != nil {
+						cp.Spec.Containers[i3].SecurityContext.Capabilities = make([]string, len(o.Spec.Containers[i3].SecurityContext.Capabilities))
+						copy(cp.Spec.Containers[i3].SecurityContext.Capabilities, o.Spec.Containers[i3].SecurityContext.Capabilities)
+					}
+				}
+				if o.Spec.Containers[i3].Args != nil {
+					cp.Spec.Containers[i3].Args = make([]string, len(o.Spec.Containers[i3].Args))
+					copy(cp.Spec.Containers[i3].Args, o.Spec.Containers[i3].Args)
+				}
+			}
+		}
+	}
+	if o.Spec.Volumes != nil {
+		cp.Spec.Volumes = make(map[string][]string, len(o.Spec.Volumes))
+		for k3, v3 := range o.Spec.Volumes {
+			var cp_Spec_Volumes_v3 []string
+			if v3 != nil {
+				cp_Spec_Volumes_v3 = make([]string, len(v3))
+				copy(cp_Spec_Volumes_v3, v3)
+			}
+			cp.Spec.Volumes[k3] = cp_Spec_Volumes_v3
+		}
+	}
+	// Spec.Internal: skipped via -skip
+	return cp
+}`
+
+	EnumMapFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of EnumMap
+func (o EnumMap) DeepCopy() EnumMap {
+	var cp EnumMap = o
+	if o.Durations != nil {
+		cp.Durations = make(map[Phase][]int, len(o.Durations))
+		for k2, v2 := range o.Durations {
+			var cp_Durations_v2 []int
+			if v2 != nil {
+				cp_Durations_v2 = make([]int, len(v2))
+				copy(cp_Durations_v2, v2)
+			}
+			cp.Durations[k2] = cp_Durations_v2
+		}
+	}
+	if o.Names != nil {
+		cp.Names = make(map[Phase]string, len(o.Names))
+		for k2, v2 := range o.Names {
+			cp.Names[k2] = v2
+		}
+	}
+	if o.Levels != nil {
+		cp.Levels = make(map[string]Level, len(o.Levels))
+		for k2, v2 := range o.Levels {
+			cp.Levels[k2] = v2
+		}
+	}
+	if o.ByLevel != nil {
+		cp.ByLevel = make(map[Level]Phase, len(o.ByLevel))
+		for k2, v2 := range o.ByLevel {
+			cp.ByLevel[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	SkipLiteralFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Image
+//
+// Not deeply copied:
+//   - Layers[0]: skipped via -skip
+//   - Tags[1]: zeroed via -skip
+//   - Annotations["managed-by"]: skipped via -skip
+//   - Ports[80]: zeroed via -skip
+//   - Nested[i][2]: skipped via -skip
+func (o Image) DeepCopy() Image {
+	var cp Image = o
+	if o.Layers != nil {
+		cp.Layers = make([]*Layer, len(o.Layers))
+		copy(cp.Layers, o.Layers)
+		for i2 := range o.Layers {
+			if i2 == 0 {
+				// Layers[0]: skipped via -skip
+				continue
+			}
+			if o.Layers[i2] != nil {
+				cp.Layers[i2] = new(Layer)
+				*cp.Layers[i2] = *o.Layers[i2]
+				if o.Layers[i2].Files != nil {
+					cp.Layers[i2].Files = make([]string, len(o.Layers[i2].Files))
+					copy(cp.Layers[i2].Files, o.Layers[i2].Files)
+				}
+			}
+		}
+	}
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+		for i2 := range o.Tags {
+			if i2 == 1 {
+				// Tags[1]: zeroed via -skip
+				cp.Tags[i2] = ""
+				continue
+			}
+		}
+	}
+	if o.Annotations != nil {
+		cp.Annotations = make(map[string][]string, len(o.Annotations))
+		for k2, v2 := range o.Annotations {
+			if k2 == "managed-by" {
+				// Annotations["managed-by"]: skipped via -skip
+				cp.Annotations[k2] = v2
+				continue
+			}
+			var cp_Annotations_v2 []string
+			if v2 != nil {
+				cp_Annotations_v2 = make([]string, len(v2))
+				copy(cp_Annotations_v2, v2)
+			}
+			cp.Annotations[k2] = cp_Annotations_v2
+		}
+	}
+	if o.Ports != nil {
+		cp.Ports = make(map[int]*Layer, len(o.Ports))
+		for k2, v2 := range o.Ports {
+			if k2 == 80 {
+				// Ports[80]: zeroed via -skip
+				cp.Ports[k2] = nil
+				continue
+			}
+			var cp_Ports_v2 *Layer
+			if v2 != nil {
+				cp_Ports_v2 = new(Layer)
+				*cp_Ports_v2 = *v2
+				if v2.Files != nil {
+					cp_Ports_v2.Files = make([]string, len(v2.Files))
+					copy(cp_Ports_v2.Files, v2.Files)
+				}
+			}
+			cp.Ports[k2] = cp_Ports_v2
+		}
+	}
+	if o.Nested != nil {
+		cp.Nested = make([][]*Layer, len(o.Nested))
+		copy(cp.Nested, o.Nested)
+		for i2 := range o.Nested {
+			if o.Nested[i2] != nil {
+				cp.Nested[i2] = make([]*Layer, len(o.Nested[i2]))
+				copy(cp.Nested[i2], o.Nested[i2])
+				for i3 := range o.Nested[i2] {
+					if i3 == 2 {
+						// Nested[i][2]: skipped via -skip
+						continue
+					}
+					if o.Nested[i2][i3] != nil {
+						cp.Nested[i2][i3] = new(Layer)
+						*cp.Nested[i2][i3] = *o.Nested[i2][i3]
+						if o.Nested[i2][i3].Files != nil {
+							cp.Nested[i2][i3].Files = make([]string, len(o.Nested[i2][i3].Files))
+							copy(cp.Nested[i2][i3].Files, o.Nested[i2][i3].Files)
+						}
+					}
+				}
+			}
+		}
+	}
+	return cp
+}`
+
+	ShadowedImportFile = `// generated by deep-copy; DO NOT EDIT.
+
+package shadowed_import
+
+import (
+	github_com_texazcowboy_deep_copy_testdata_shadowed_import_time "github.com/texazcowboy/deep-copy/testdata/shadowed_import/time"
+)
+
+// deepCopy generates a deep copy of time
+func (o time) deepCopy() time {
+	var cp time = o
+	if o.C.Ticks != nil {
+		cp.C.Ticks = make([]github_com_texazcowboy_deep_copy_testdata_shadowed_import_time.Duration, len(o.C.Ticks))
+		copy(cp.C.Ticks, o.C.Ticks)
+	}
+	return cp
+}`
+
+	ImmutableFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Compiler
+//
+// Not deeply copied:
+//   - Symbols: immutable via -treat-as-immutable
+//   - Config: immutable via -treat-as-immutable
+//   - History[i]: immutable via -treat-as-immutable
+//   - ByName[k]: immutable via -treat-as-immutable
+func (o Compiler) DeepCopy() Compiler {
+	var cp Compiler = o
+	// Symbols: immutable via -treat-as-immutable
+	// Config: immutable via -treat-as-immutable
+	if o.History != nil {
+		cp.History = make([]*SymbolTable, len(o.History))
+		copy(cp.History, o.History)
+		// History[i]: immutable via -treat-as-immutable
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]*SymbolTable, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			// ByName[k]: immutable via -treat-as-immutable
+			cp.ByName[k2] = v2
+		}
+	}
+	if o.Args != nil {
+		cp.Args = make([]string, len(o.Args))
+		copy(cp.Args, o.Args)
+	}
+	return cp
+}`
+
+	SkipGlobFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipGlob
+//
+// Not deeply copied:
+//   - cachedNames: skipped via -skip
+//   - InternalIndex: zeroed via -skip
+//   - Inner.cachedValues: skipped via -skip
+//   - Inner.InternalState: zeroed via -skip
+//   - Items[i].Values: skipped via -skip
+//   - Items[i].cachedValues: skipped via -skip
+//   - Items[i].InternalState: zeroed via -skip
+func (o SkipGlob) DeepCopy() SkipGlob {
+	var cp SkipGlob = o
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	// cachedNames: skipped via -skip
+	// InternalIndex: zeroed via -skip
+	cp.InternalIndex = nil
+	if o.Inner != nil {
+		cp.Inner = new(GlobInner)
+		*cp.Inner = *o.Inner
+		if o.Inner.Values != nil {
+			cp.Inner.Values = make([]int, len(o.Inner.Values))
+			copy(cp.Inner.Values, o.Inner.Values)
+		}
+		// Inner.cachedValues: skipped via -skip
+		// Inner.InternalState: zeroed via -skip
+		cp.Inner.InternalState = nil
+	}
+	if o.Items != nil {
+		cp.Items = make([]GlobInner, len(o.Items))
+		copy(cp.Items, o.Items)
+		for i2 := range o.Items {
+			// Items[i].Values: skipped via -skip
+			// Items[i].cachedValues: skipped via -skip
+			// Items[i].InternalState: zeroed via -skip
+			cp.Items[i2].InternalState = nil
+		}
+	}
+	return cp
+}`
+
+	SkipGlobExactFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of SkipGlob
+//
+// Not deeply copied:
+//   - cachedNames: zeroed via -skip
+//   - Inner.Values: skipped via -skip
+//   - Inner.cachedValues: skipped via -skip
+//   - Inner.InternalState: skipped via -skip
+//   - Items[i].cachedValues: skipped via -skip
+//   - Items[i].InternalState: zeroed via -skip
+func (o SkipGlob) DeepCopy() SkipGlob {
+	var cp SkipGlob = o
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	// cachedNames: zeroed via -skip
+	cp.cachedNames = nil
+	if o.InternalIndex != nil {
+		cp.InternalIndex = make(map[string]int, len(o.InternalIndex))
+		for k2, v2 := range o.InternalIndex {
+			cp.InternalIndex[k2] = v2
+		}
+	}
+	if o.Inner != nil {
+		cp.Inner = new(GlobInner)
+		*cp.Inner = *o.Inner
+		// Inner.Values: skipped via -skip
+		// Inner.cachedValues: skipped via -skip
+		// Inner.InternalState: skipped via -skip
+	}
+	if o.Items != nil {
+		cp.Items = make([]GlobInner, len(o.Items))
+		copy(cp.Items, o.Items)
+		for i2 := range o.Items {
+			if o.Items[i2].Values != nil {
+				cp.Items[i2].Values = make([]int, len(o.Items[i2].Values))
+				copy(cp.Items[i2].Values, o.Items[i2].Values)
+			}
+			// Items[i].cachedValues: skipped via -skip
+			// Items[i].InternalState: zeroed via -skip
+			cp.Items[i2].InternalState = nil
+		}
+	}
+	return cp
+}`
+
+	AlphaForceDeepFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Alpha
+func (o Alpha) DeepCopy() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = new(Beta)
+		*cp.B = *o.B
+		if o.B.ch != nil {
+			cp.B.ch = make(chan int, cap(o.B.ch))
+		}
+	}
+	cp.G = o.G.DeepCopy()
+	if o.D != nil {
+		retV := o.D.DeepCopy()
+		cp.D = &retV
+	}
+	{
+		retV := o.E.DeepCopy()
+		cp.E = *retV
+	}
+	return cp
+}`
+
+	AlphaNoReuseFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Alpha
+func (o Alpha) DeepCopy() Alpha {
+	var cp Alpha = o
+	if o.B != nil {
+		cp.B = new(Beta)
+		*cp.B = *o.B
+		if o.B.ch != nil {
+			cp.B.ch = make(chan int, cap(o.B.ch))
+		}
+	}
+	if o.D != nil {
+		cp.D = new(Delta)
+		*cp.D = *o.D
+	}
+	return cp
+}`
+
+	InsertedFile = `package external_pointer
+
+import (
+	"fmt"
+	"github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+)
+
+// String is written by hand.
+func (h Holder) String() string {
+	return fmt.Sprint(h.Item)
+}
+
+// deep-copy:begin
+
+// DeepCopy generates a deep copy of Holder
+func (o Holder) DeepCopy() Holder {
+	var cp Holder = o
+	if o.Item != nil {
+		cp.Item = new(item.Item)
+		*cp.Item = *o.Item
+	}
+	return cp
+}
+
+// deep-copy:end
+
+func after() {}
+`
+
+	MergedFile = `package external_pointer
+
+import (
+	"fmt"
+	"github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+)
+
+// String is written by hand.
+func (h Holder) String() string {
+	return fmt.Sprint(h.Item)
+}
+
+// DeepCopy generates a deep copy of Holder
+func (o Holder) DeepCopy() Holder {
+	var cp Holder = o
+	if o.Item != nil {
+		cp.Item = new(item.Item)
+		*cp.Item = *o.Item
+	}
+	return cp
+}
+
+func after() {}
+`
+
+	PragmaFile = `// generated by deep-copy; DO NOT EDIT.
+
+package pragma
+
+// DeepCopy generates a deep copy of Pragma
+//
+//go:nosplit
+//go:norace
+func (o Pragma) DeepCopy() Pragma {
+	var cp Pragma = o
+	if o.Data != nil {
+		cp.Data = make([]byte, len(o.Data))
+		copy(cp.Data, o.Data)
+	}
+	return cp
+}`
+
+	FuncFile = `// generated by deep-copy; DO NOT EDIT.
+
+package copies
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata"
+)
+
+// DeepCopyBar generates a deep copy of testdata.Bar
+func DeepCopyBar(src testdata.Bar) testdata.Bar {
+	var cp testdata.Bar = src
+	if src.Slice != nil {
+		cp.Slice = make([]string, len(src.Slice))
+		copy(cp.Slice, src.Slice)
+	}
+	return cp
+}
+
+// DeepCopyFoo generates a deep copy of testdata.Foo
+func DeepCopyFoo(src testdata.Foo) testdata.Foo {
+	var cp testdata.Foo = src
+	if src.Map != nil {
+		cp.Map = make(map[string]*testdata.Bar, len(src.Map))
+		for k2, v2 := range src.Map {
+			var cp_Map_v2 *testdata.Bar
+			if v2 != nil {
+				retV := DeepCopyBar(*v2)
+				cp_Map_v2 = &retV
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	return cp
+}`
+
+	FuncPointerFile = `// generated by deep-copy; DO NOT EDIT.
+
+package copies
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata"
+)
+
+// DeepCopyNode generates a deep copy of *testdata.Node
+func DeepCopyNode(o *testdata.Node) *testdata.Node {
+	var cp testdata.Node = *o
+	if o.Children != nil {
+		cp.Children = make([]*testdata.Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				cp.Children[i2] = DeepCopyNode(o.Children[i2])
+			}
+		}
+	}
+	return &cp
+}`
+
+	NamedArraySliceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package named_array
+
+// DeepCopy generates a deep copy of Record
+func (o Record) DeepCopy() Record {
+	var cp Record = o
+	if o.IDs != nil {
+		cp.IDs = make([]UUID, len(o.IDs))
+		copy(cp.IDs, o.IDs)
+	}
+	if o.Groups != nil {
+		cp.Groups = make(map[string][]UUID, len(o.Groups))
+		for k2, v2 := range o.Groups {
+			var cp_Groups_v2 []UUID
+			if v2 != nil {
+				cp_Groups_v2 = make([]UUID, len(v2))
+				copy(cp_Groups_v2, v2)
+			}
+			cp.Groups[k2] = cp_Groups_v2
+		}
+	}
+	return cp
+}`
+
+	FuncModelsProgram = `package main
+
+import "roundtrip/models"
+
+func main() {
+	orig := models.NewUser("ann", "admin")
+	orig.Teams = []*models.Team{{Name: "core", Members: []string{"ann"}}}
+
+	cp := DeepCopyUser(orig)
+	cp.Teams[0].Members[0] = "bob"
+	cp.Teams[0].Name = "other"
+
+	if orig.Teams[0].Members[0] != "ann" || orig.Teams[0].Name != "core" {
+		panic("copied teams share state with the original")
+	}
+	if cp.Tags()[0] != "admin" {
+		panic("unexported preferences not copied")
+	}
+}
+`
+
+	ArrayPointerMapProgram = `package main
+
+import "roundtrip/array_map"
+
+func main() {
+	orig := array_map.Grid{
+		Cells: map[string]*[4]*array_map.Item{"a": {{Tags: []string{"x"}}, nil, {}}},
+		Raw:   map[string]*[4]byte{"a": {1, 2, 3, 4}, "nil": nil},
+	}
+
+	cp := orig.DeepCopy()
+	cp.Cells["a"][0].Tags[0] = "y"
+	cp.Cells["a"][1] = &array_map.Item{}
+	cp.Raw["a"][3] = 42
+
+	if orig.Cells["a"][0].Tags[0] != "x" || orig.Cells["a"][1] != nil || orig.Raw["a"][3] != 4 {
+		panic("copied arrays share state with the original")
+	}
+	if cp.Raw["nil"] != nil {
+		panic("nil array pointer not kept")
+	}
+}
+`
+
+	IotaEnumProgram = `package main
+
+import "roundtrip/enum_iota"
+
+func main() {
+	inactive := enum_iota.Inactive
+	orig := enum_iota.Account{
+		Status:  enum_iota.Active,
+		History: []enum_iota.Status{enum_iota.Inactive},
+		ByName:  map[enum_iota.Status]*enum_iota.Status{enum_iota.Active: &inactive},
+	}
+
+	cp := orig.DeepCopy()
+	cp.History[0] = enum_iota.Active
+	*cp.ByName[enum_iota.Active] = enum_iota.Active
+
+	if orig.History[0] != enum_iota.Inactive || inactive != enum_iota.Inactive {
+		panic("copied enums share state with the original")
+	}
+}
+`
+
+	CommandProgram = `package main
+
+func init() {
+	orig := Options{Args: []string{"-v"}, config: config{Flags: map[string][]string{"a": {"b"}}, Next: &config{Name: "next"}}}
+
+	cp := orig.DeepCopy()
+	cp.Args[0] = "-q"
+	cp.config.Flags["a"][0] = "c"
+	cp.config.Next.Name = "other"
+
+	if orig.Args[0] != "-v" || orig.config.Flags["a"][0] != "b" || orig.config.Next.Name != "next" {
+		panic("copied options share state with the original")
+	}
+}
+`
+
+	NamedArraySliceProgram = `package main
+
+import "roundtrip/named_array"
+
+func main() {
+	orig := named_array.Record{
+		IDs:    []named_array.UUID{{1, 2, 3, 4}},
+		Groups: map[string][]named_array.UUID{"a": {{5, 6, 7, 8}}},
+	}
+
+	cp := orig.DeepCopy()
+	cp.IDs[0][3] = 42
+	cp.Groups["a"][0][3] = 42
+
+	if orig.IDs[0][3] != 4 || orig.Groups["a"][0][3] != 8 {
+		panic("copied arrays share state with the original")
+	}
+}
+`
+
+	NamedBasicSliceProgram = `package main
+
+import (
+	"time"
+
+	"roundtrip/durations"
+)
+
+func main() {
+	orig := durations.Schedule{
+		Intervals: []time.Duration{time.Second},
+		Backoffs:  map[string][]time.Duration{"a": {time.Minute}},
+		Steps:     [][]time.Duration{{time.Hour}},
+	}
+
+	cp := orig.DeepCopy()
+	cp.Intervals[0] = 0
+	cp.Backoffs["a"][0] = 0
+	cp.Steps[0][0] = 0
+
+	if orig.Intervals[0] != time.Second || orig.Backoffs["a"][0] != time.Minute || orig.Steps[0][0] != time.Hour {
+		panic("copied durations share state with the original")
+	}
+}
+`
+
+	AssertNonNilFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Pod
+func (o Pod) DeepCopy() Pod {
+	var cp Pod = o
+	if o.Meta.Labels == nil {
+		panic("deep copy: Meta.Labels is nil")
+	}
+	if o.Meta.Labels != nil {
+		cp.Meta.Labels = make(map[string]string, len(o.Meta.Labels))
+		for k3, v3 := range o.Meta.Labels {
+			cp.Meta.Labels[k3] = v3
+		}
+	}
+	if o.Spec.Containers != nil {
+		cp.Spec.Containers = make([]*Container, len(o.Spec.Containers))
+		copy(cp.Spec.Containers, o.Spec.Containers)
+		for i3 := range o.Spec.Containers {
+			if o.Spec.Containers[i3] != nil {
+				cp.Spec.Containers[i3] = new(Container)
+				*cp.Spec.Containers[i3] = *o.Spec.Containers[i3]
+				if o.Spec.Containers[i3].SecurityContext == nil {
+					panic("deep copy: Spec.Containers[i].SecurityContext is nil")
+				}
+				if o.Spec.Containers[i3].SecurityContext != nil {
+					cp.Spec.Containers[i3].SecurityContext = new(SecurityContext)
+					*cp.Spec.Containers[i3].SecurityContext = *o.Spec.Containers[i3].SecurityContext
+					if o.Spec.Containers[i3].SecurityContext.Capabilities != nil {
+						cp.Spec.Containers[i3].SecurityContext.Capabilities = make([]string, len(o.Spec.Containers[i3].SecurityContext.Capabilities))
+						copy(cp.Spec.Containers[i3].SecurityContext.Capabilities, o.Spec.Containers[i3].SecurityContext.Capabilities)
+					}
+				}
+				if o.Spec.Containers[i3].Args != nil {
+					cp.Spec.Containers[i3].Args = make([]string, len(o.Spec.Containers[i3].Args))
+					copy(cp.Spec.Containers[i3].Args, o.Spec.Containers[i3].Args)
+				}
+			}
+		}
+	}
+	if o.Spec.Volumes != nil {
+		cp.Spec.Volumes = make(map[string][]string, len(o.Spec.Volumes))
+		for k3, v3 := range o.Spec.Volumes {
+			var cp_Spec_Volumes_v3 []string
+			if v3 != nil {
+				cp_Spec_Volumes_v3 = make([]string, len(v3))
+				copy(cp_Spec_Volumes_v3, v3)
+			}
+			cp.Spec.Volumes[k3] = cp_Spec_Volumes_v3
+		}
+	}
+	if o.Spec.Internal != nil {
+		cp.Spec.Internal = make([]string, len(o.Spec.Internal))
+		copy(cp.Spec.Internal, o.Spec.Internal)
+	}
+	return cp
+}`
+
+	FuncModelsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package clones
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/models"
+)
+
+// DeepCopyTeam generates a deep copy of models.Team
+func DeepCopyTeam(o models.Team) models.Team {
+	var cp models.Team = o
+	if o.Members != nil {
+		cp.Members = make([]string, len(o.Members))
+		copy(cp.Members, o.Members)
+	}
+	return cp
+}
+
+// DeepCopyUser generates a deep copy of models.User
+//
+// Not deeply copied:
+//   - Prefs: shallow copied, models.prefs is unexported
+func DeepCopyUser(o models.User) models.User {
+	var cp models.User = o
+	if o.Teams != nil {
+		cp.Teams = make([]*models.Team, len(o.Teams))
+		copy(cp.Teams, o.Teams)
+		for i2 := range o.Teams {
+			if o.Teams[i2] != nil {
+				retV := DeepCopyTeam(*o.Teams[i2])
+				cp.Teams[i2] = &retV
+			}
+		}
+	}
+	// Prefs: shallow copied, models.prefs is unexported
+	return cp
+}`
+
+	ArrayPointerMapFile = `// generated by deep-copy; DO NOT EDIT.
+
+package array_map
+
+// DeepCopy generates a deep copy of Grid
+func (o Grid) DeepCopy() Grid {
+	var cp Grid = o
+	if o.Cells != nil {
+		cp.Cells = make(map[string]*[4]*Item, len(o.Cells))
+		for k2, v2 := range o.Cells {
+			var cp_Cells_v2 *[4]*Item
+			if v2 != nil {
+				cp_Cells_v2 = new([4]*Item)
+				*cp_Cells_v2 = *v2
+				for i4 := range *v2 {
+					if (*v2)[i4] != nil {
+						(*cp_Cells_v2)[i4] = new(Item)
+						*(*cp_Cells_v2)[i4] = *(*v2)[i4]
+						if (*v2)[i4].Tags != nil {
+							(*cp_Cells_v2)[i4].Tags = make([]string, len((*v2)[i4].Tags))
+							copy((*cp_Cells_v2)[i4].Tags, (*v2)[i4].Tags)
+						}
+					}
+				}
+			}
+			cp.Cells[k2] = cp_Cells_v2
+		}
+	}
+	if o.Raw != nil {
+		cp.Raw = make(map[string]*[4]byte, len(o.Raw))
+		for k2, v2 := range o.Raw {
+			var cp_Raw_v2 *[4]byte
+			if v2 != nil {
+				cp_Raw_v2 = new([4]byte)
+				*cp_Raw_v2 = *v2
+			}
+			cp.Raw[k2] = cp_Raw_v2
+		}
+	}
+	return cp
+}`
+
+	UnexportedTypeFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// deepCopy generates a deep copy of cacheShard
+func (o cacheShard) deepCopy() cacheShard {
+	var cp cacheShard = o
+	if o.entries != nil {
+		cp.entries = make(map[string]*cacheEntry, len(o.entries))
+		for k2, v2 := range o.entries {
+			var cp_entries_v2 *cacheEntry
+			if v2 != nil {
+				retV := v2.deepCopy()
+				cp_entries_v2 = &retV
+			}
+			cp.entries[k2] = cp_entries_v2
+		}
+	}
+	if o.stats != nil {
+		retV := o.stats.deepCopy()
+		cp.stats = &retV
+	}
+	if o.Owner != nil {
+		cp.Owner = new(Bar)
+		*cp.Owner = *o.Owner
+		if o.Owner.Slice != nil {
+			cp.Owner.Slice = make([]string, len(o.Owner.Slice))
+			copy(cp.Owner.Slice, o.Owner.Slice)
+		}
+	}
+	return cp
+}
+
+// deepCopy generates a deep copy of shardStats
+func (o shardStats) deepCopy() shardStats {
+	var cp shardStats = o
+	if o.hits != nil {
+		cp.hits = make([]int, len(o.hits))
+		copy(cp.hits, o.hits)
+	}
+	return cp
+}`
+
+	UnexportedTypeMethodNameFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// Clone generates a deep copy of shardStats
+func (o shardStats) Clone() shardStats {
+	var cp shardStats = o
+	if o.hits != nil {
+		cp.hits = make([]int, len(o.hits))
+		copy(cp.hits, o.hits)
+	}
+	return cp
+}`
+
+	IotaEnumFile = `// generated by deep-copy; DO NOT EDIT.
+
+package enum_iota
+
+// DeepCopy generates a deep copy of Account
+func (o Account) DeepCopy() Account {
+	var cp Account = o
+	if o.History != nil {
+		cp.History = make([]Status, len(o.History))
+		copy(cp.History, o.History)
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[Status]*Status, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			var cp_ByName_v2 *Status
+			if v2 != nil {
+				cp_ByName_v2 = new(Status)
+				*cp_ByName_v2 = *v2
+			}
+			cp.ByName[k2] = cp_ByName_v2
+		}
+	}
+	return cp
+}`
+
+	CommandFile = `// generated by deep-copy; DO NOT EDIT.
+
+package main
+
+// DeepCopy generates a deep copy of Options
+func (o Options) DeepCopy() Options {
+	var cp Options = o
+	if o.Args != nil {
+		cp.Args = make([]string, len(o.Args))
+		copy(cp.Args, o.Args)
+	}
+	cp.config = o.config.deepCopy()
+	return cp
+}
+
+// deepCopy generates a deep copy of config
+func (o config) deepCopy() config {
+	var cp config = o
+	if o.Flags != nil {
+		cp.Flags = make(map[string][]string, len(o.Flags))
+		for k2, v2 := range o.Flags {
+			var cp_Flags_v2 []string
+			if v2 != nil {
+				cp_Flags_v2 = make([]string, len(v2))
+				copy(cp_Flags_v2, v2)
+			}
+			cp.Flags[k2] = cp_Flags_v2
+		}
+	}
+	if o.Next != nil {
+		retV := o.Next.deepCopy()
+		cp.Next = &retV
+	}
+	return cp
+}`
+
+	AssertInterfaceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package assert_iface
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/assert_iface/cloner"
+)
+
+// DeepCopy generates a deep copy of Config
+func (o Config) DeepCopy() Config {
+	var cp Config = o
+	if o.Hosts != nil {
+		cp.Hosts = make([]string, len(o.Hosts))
+		copy(cp.Hosts, o.Hosts)
+	}
+	return cp
+}
+
+var _ cloner.Cloner[Config] = (*Config)(nil)
+
+// DeepCopy generates a deep copy of Limits
+func (o Limits) DeepCopy() Limits {
+	var cp Limits = o
+	if o.Values != nil {
+		cp.Values = make(map[string]int, len(o.Values))
+		for k2, v2 := range o.Values {
+			cp.Values[k2] = v2
+		}
+	}
+	return cp
+}
+
+var _ cloner.Cloner[Limits] = (*Limits)(nil)`
+
+	AssertInterfacePointerFile = `// generated by deep-copy; DO NOT EDIT.
+
+package assert_iface
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/assert_iface/cloner"
+)
+
+// DeepCopy generates a deep copy of *Config
+func (o *Config) DeepCopy() *Config {
+	var cp Config = *o
+	if o.Hosts != nil {
+		cp.Hosts = make([]string, len(o.Hosts))
+		copy(cp.Hosts, o.Hosts)
+	}
+	return &cp
+}
+
+var _ cloner.Cloner[*Config] = (*Config)(nil)
+
+// DeepCopy generates a deep copy of *Limits
+func (o *Limits) DeepCopy() *Limits {
+	var cp Limits = *o
+	if o.Values != nil {
+		cp.Values = make(map[string]int, len(o.Values))
+		for k2, v2 := range o.Values {
+			cp.Values[k2] = v2
+		}
+	}
+	return &cp
+}
+
+var _ cloner.Cloner[*Limits] = (*Limits)(nil)`
+
+	UnsafeFieldsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"unsafe"
+)
+
+// DeepCopy generates a deep copy of UnsafeFields
+//
+// Not deeply copied:
+//   - Data: WARNING: unsafe.Pointer field shared
+//   - Addr: WARNING: uintptr field shared
+//   - Handle: WARNING: uintptr field shared
+//   - Refs[i]: WARNING: unsafe.Pointer field shared
+func (o UnsafeFields) DeepCopy() UnsafeFields {
+	var cp UnsafeFields = o
+	// Data: WARNING: unsafe.Pointer field shared
+	// Addr: WARNING: uintptr field shared
+	// Handle: WARNING: uintptr field shared
+	if o.Refs != nil {
+		cp.Refs = make([]unsafe.Pointer, len(o.Refs))
+		copy(cp.Refs, o.Refs)
+		// Refs[i]: WARNING: unsafe.Pointer field shared
+	}
+	return cp
+}`
+
+	UnsafeFieldsSkippedFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"unsafe"
+)
+
+// DeepCopy generates a deep copy of UnsafeFields
+//
+// Not deeply copied:
+//   - Data: skipped via -skip
+//   - Addr: skipped via -skip
+//   - Handle: skipped via -skip
+//   - Refs[i]: skipped via -skip
+func (o UnsafeFields) DeepCopy() UnsafeFields {
+	var cp UnsafeFields = o
+	// Data: skipped via -skip
+	// Addr: skipped via -skip
+	// Handle: skipped via -skip
+	// Refs[i]: skipped via -skip
+	if o.Refs != nil {
+		cp.Refs = make([]unsafe.Pointer, len(o.Refs))
+		copy(cp.Refs, o.Refs)
+	}
+	return cp
+}`
+
+	WithErrorFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"fmt"
+)
+
+// DeepCopy generates a deep copy of Job
+func (o Job) DeepCopy() (Job, error) {
+	var cp Job = o
+	if o.Plugin != nil {
+		return Job{}, fmt.Errorf("Plugin: %T can't be deep copied", o.Plugin)
+	}
+	if o.State != nil {
+		retV, err := o.State.DeepCopy()
+		if err != nil {
+			return Job{}, fmt.Errorf("State: %w", err)
+		}
+		cp.State = retV
+	}
+	if o.Limits != nil {
+		{
+			retV, err := o.Limits.DeepCopy()
+			if err != nil {
+				return Job{}, fmt.Errorf("Limits: %w", err)
+			}
+			cp.Limits = &retV
+		}
+	}
+	cp.Retries = o.Retries.DeepCopy()
+	if o.Steps != nil {
+		cp.Steps = make([]Step, len(o.Steps))
+		copy(cp.Steps, o.Steps)
+		for i2 := range o.Steps {
+			{
+				retV, err := o.Steps[i2].DeepCopy()
+				if err != nil {
+					return Job{}, fmt.Errorf("Steps[i]: %w", err)
+				}
+				cp.Steps[i2] = retV
+			}
+		}
+	}
+	return cp, nil
+}
+
+// DeepCopy generates a deep copy of Step
+func (o Step) DeepCopy() (Step, error) {
+	var cp Step = o
+	if o.Plugins != nil {
+		cp.Plugins = make(map[string]Plugin, len(o.Plugins))
+		for k2, v2 := range o.Plugins {
+			var cp_Plugins_v2 Plugin
+			if v2 != nil {
+				return Step{}, fmt.Errorf("Plugins[k]: %T can't be deep copied", v2)
+			}
+			cp.Plugins[k2] = cp_Plugins_v2
+		}
+	}
+	return cp, nil
+}`
+
+	WithErrorBothReceiversFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"fmt"
+)
+
+// DeepCopy generates a deep copy of Step
+func (o Step) DeepCopy() (Step, error) {
+	var cp Step = o
+	if o.Plugins != nil {
+		cp.Plugins = make(map[string]Plugin, len(o.Plugins))
+		for k2, v2 := range o.Plugins {
+			var cp_Plugins_v2 Plugin
+			if v2 != nil {
+				return Step{}, fmt.Errorf("Plugins[k]: %T can't be deep copied", v2)
+			}
+			cp.Plugins[k2] = cp_Plugins_v2
+		}
+	}
+	return cp, nil
+}
+
+// DeepCopyPtr generates a deep copy of *Step
+func (o *Step) DeepCopyPtr() (*Step, error) {
+	if o == nil {
+		return nil, nil
+	}
+	cp, err := o.DeepCopy()
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}`
+
+	WithErrorSkippedFile = `// generated by deep-copy; DO NOT EDIT.
+
+package clones
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/models"
+)
+
+// DeepCopyAccount generates a deep copy of models.Account
+func DeepCopyAccount(o models.Account) (models.Account, error) {
+	var cp models.Account = o
+	return cp, nil
+}`
+
+	RouterFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Router
+//
+// Not deeply copied:
+//   - Handler: func value shared
+//   - Middleware[i]: func value shared
+//   - Routes[k]: func value shared
+func (o Router) DeepCopy() Router {
+	var cp Router = o
+	// Handler: func value shared
+	if o.Middleware != nil {
+		cp.Middleware = make([]func(int) error, len(o.Middleware))
+		copy(cp.Middleware, o.Middleware)
+		// Middleware[i]: func value shared
+	}
+	if o.Routes != nil {
+		cp.Routes = make(map[string]func(int) error, len(o.Routes))
+		for k2, v2 := range o.Routes {
+			// Routes[k]: func value shared
+			cp.Routes[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	WithContextFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeepCopy generates a deep copy of Job
+//
+// Not deeply copied:
+//   - Plugin: interface value shared
+func (o Job) DeepCopy(ctx context.Context) (Job, error) {
+	var cp Job = o
+	// Plugin: interface value shared
+	if o.State != nil {
+		retV, err := o.State.DeepCopy()
+		if err != nil {
+			return Job{}, fmt.Errorf("State: %w", err)
+		}
+		cp.State = retV
+	}
+	if o.Limits != nil {
+		{
+			retV, err := o.Limits.DeepCopy()
+			if err != nil {
+				return Job{}, fmt.Errorf("Limits: %w", err)
+			}
+			cp.Limits = &retV
+		}
+	}
+	cp.Retries = o.Retries.DeepCopy()
+	if o.Steps != nil {
+		cp.Steps = make([]Step, len(o.Steps))
+		copy(cp.Steps, o.Steps)
+		for i2 := range o.Steps {
+			if err := ctx.Err(); err != nil {
+				return Job{}, err
+			}
+			{
+				retV, err := o.Steps[i2].DeepCopy(ctx)
+				if err != nil {
+					return Job{}, fmt.Errorf("Steps[i]: %w", err)
+				}
+				cp.Steps[i2] = retV
+			}
+		}
+	}
+	return cp, nil
+}
+
+// DeepCopy generates a deep copy of Step
+//
+// Not deeply copied:
+//   - Plugins[k]: interface value shared
+func (o Step) DeepCopy(ctx context.Context) (Step, error) {
+	var cp Step = o
+	if o.Plugins != nil {
+		cp.Plugins = make(map[string]Plugin, len(o.Plugins))
+		for k2, v2 := range o.Plugins {
+			if err := ctx.Err(); err != nil {
+				return Step{}, err
+			}
+			// Plugins[k]: interface value shared
+			cp.Plugins[k2] = v2
+		}
+	}
+	return cp, nil
+}`
+
+	WithContextBothReceiversFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"context"
+)
+
+// DeepCopy generates a deep copy of Step
+//
+// Not deeply copied:
+//   - Plugins[k]: interface value shared
+func (o Step) DeepCopy(ctx context.Context) (Step, error) {
+	var cp Step = o
+	if o.Plugins != nil {
+		cp.Plugins = make(map[string]Plugin, len(o.Plugins))
+		for k2, v2 := range o.Plugins {
+			if err := ctx.Err(); err != nil {
+				return Step{}, err
+			}
+			// Plugins[k]: interface value shared
+			cp.Plugins[k2] = v2
+		}
+	}
+	return cp, nil
+}
+
+// DeepCopyPtr generates a deep copy of *Step
+func (o *Step) DeepCopyPtr(ctx context.Context) (*Step, error) {
+	if o == nil {
+		return nil, nil
+	}
+	cp, err := o.DeepCopy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}`
+
+	WithContextFuncFile = `// generated by deep-copy; DO NOT EDIT.
+
+package clones
+
+import (
+	"context"
+	"fmt"
+	"github.com/texazcowboy/deep-copy/testdata/models"
+)
+
+// DeepCopyTeam generates a deep copy of models.Team
+func DeepCopyTeam(ctx context.Context, o models.Team) (models.Team, error) {
+	var cp models.Team = o
+	if o.Members != nil {
+		cp.Members = make([]string, len(o.Members))
+		copy(cp.Members, o.Members)
+	}
+	return cp, nil
+}
+
+// DeepCopyUser generates a deep copy of models.User
+//
+// Not deeply copied:
+//   - Prefs: shallow copied, models.prefs is unexported
+func DeepCopyUser(ctx context.Context, o models.User) (models.User, error) {
+	var cp models.User = o
+	if o.Teams != nil {
+		cp.Teams = make([]*models.Team, len(o.Teams))
+		copy(cp.Teams, o.Teams)
+		for i2 := range o.Teams {
+			if err := ctx.Err(); err != nil {
+				return models.User{}, err
+			}
+			if o.Teams[i2] != nil {
+				{
+					retV, err := DeepCopyTeam(ctx, *o.Teams[i2])
+					if err != nil {
+						return models.User{}, fmt.Errorf("Teams[i]: %w", err)
+					}
+					cp.Teams[i2] = &retV
+				}
+			}
+		}
+	}
+	// Prefs: shallow copied, models.prefs is unexported
+	return cp, nil
+}`
+
+	MaxDepthTreeFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Node
+//
+// Values nested 3 levels deep or more are shallow copied.
+func (o Node) DeepCopy() Node {
+	return o.deepCopyDepth(0)
+}
+
+// deepCopyDepth generates a deep copy of Node, at depth d of the copied value.
+func (o Node) deepCopyDepth(d int) Node {
+	var cp Node = o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				if d+1 < 3 {
+					retV := o.Children[i2].deepCopyDepth(d + 1)
+					cp.Children[i2] = &retV
+				}
+			}
+		}
+	}
+	return cp
+}`
+
+	MaxDepthTreeContextFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeepCopy generates a deep copy of *Node
+//
+// Values nested 2 levels deep or more are shallow copied.
+func (o *Node) DeepCopy(ctx context.Context) (*Node, error) {
+	return o.deepCopyDepth(ctx, 0)
+}
+
+// deepCopyDepth generates a deep copy of *Node, at depth d of the copied value.
+func (o *Node) deepCopyDepth(ctx context.Context, d int) (*Node, error) {
+	var cp Node = *o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if o.Children[i2] != nil {
+				if d+1 < 2 {
+					{
+						retV, err := o.Children[i2].deepCopyDepth(ctx, d+1)
+						if err != nil {
+							return nil, fmt.Errorf("Children[i]: %w", err)
+						}
+						cp.Children[i2] = retV
+					}
+				}
+			}
+		}
+	}
+	return &cp, nil
+}`
+
+	NonNilCollectionsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	cp.Map = make(map[string]*Bar, len(o.Map))
+	for k2, v2 := range o.Map {
+		var cp_Map_v2 *Bar
+		if v2 != nil {
+			cp_Map_v2 = new(Bar)
+			*cp_Map_v2 = *v2
+			cp_Map_v2.Slice = make([]string, len(v2.Slice))
+			copy(cp_Map_v2.Slice, v2.Slice)
+		}
+		cp.Map[k2] = cp_Map_v2
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of I12StructWithMapOfSlices
+func (o I12StructWithMapOfSlices) DeepCopy() I12StructWithMapOfSlices {
+	var cp I12StructWithMapOfSlices = o
+	cp.Sc1 = make(map[string][]I12StructWithSlices, len(o.Sc1))
+	for k2, v2 := range o.Sc1 {
+		var cp_Sc1_v2 []I12StructWithSlices
+		cp_Sc1_v2 = make([]I12StructWithSlices, len(v2))
+		copy(cp_Sc1_v2, v2)
+		for i3 := range v2 {
+			cp_Sc1_v2[i3].Name = make([]string, len(v2[i3].Name))
+			copy(cp_Sc1_v2[i3].Name, v2[i3].Name)
+		}
+		cp.Sc1[k2] = cp_Sc1_v2
+	}
+	return cp
+}`
+
+	EmbedSourceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// Copied from the definition of Annotated:
+//
+//	type Annotated struct {
+//		OnDone func()
+//		raw    interface{}
+//		Names  []string
+//		Count  int
+//	}
+
+// DeepCopy generates a deep copy of Annotated
+//
+// Not deeply copied:
+//   - OnDone: func value shared
+//   - raw: interface value shared
+func (o Annotated) DeepCopy() Annotated {
+	var cp Annotated = o
+	// OnDone: func value shared
+	// raw: interface value shared
+	if o.Names != nil {
+		cp.Names = make([]string, len(o.Names))
+		copy(cp.Names, o.Names)
+	}
+	return cp
+}
+
+// Copied from the definition of Node:
+//
+//	type Node struct {
+//		Name     string
+//		Children []*Node
+//	}
+
+// DeepCopy generates a deep copy of Node
+func (o Node) DeepCopy() Node {
+	var cp Node = o
+	if o.Children != nil {
+		cp.Children = make([]*Node, len(o.Children))
+		copy(cp.Children, o.Children)
+		for i2 := range o.Children {
+			if o.Children[i2] != nil {
+				retV := o.Children[i2].DeepCopy()
+				cp.Children[i2] = &retV
+			}
+		}
+	}
+	return cp
+}`
+
+	KubeHolderFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of KubeHolder
+func (o KubeHolder) DeepCopy() KubeHolder {
+	var cp KubeHolder = o
+	if o.Specs != nil {
+		cp.Specs = make(map[string]KubeSpec, len(o.Specs))
+		for k2, v2 := range o.Specs {
+			var cp_Specs_v2 KubeSpec
+			v2.DeepCopyInto(&cp_Specs_v2)
+			cp.Specs[k2] = cp_Specs_v2
+		}
+	}
+	if o.Spec != nil {
+		cp.Spec = new(KubeSpec)
+		o.Spec.DeepCopyInto(cp.Spec)
+	}
+	o.Direct.DeepCopyInto(&cp.Direct)
+	if o.List != nil {
+		cp.List = make([]KubeSpec, len(o.List))
+		copy(cp.List, o.List)
+		for i2 := range o.List {
+			o.List[i2].DeepCopyInto(&cp.List[i2])
+		}
+	}
+	return cp
+}`
+
+	OutputVarFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var clone Foo = o
+	if o.Map != nil {
+		clone.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var clone_Map_v2 *Bar
+			if v2 != nil {
+				clone_Map_v2 = new(Bar)
+				*clone_Map_v2 = *v2
+				if v2.Slice != nil {
+					clone_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(clone_Map_v2.Slice, v2.Slice)
+				}
+			}
+			clone.Map[k2] = clone_Map_v2
+		}
+	}
+	if o.ch != nil {
+		clone.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		clone.baz.StringPointer = new(string)
+		*clone.baz.StringPointer = *o.baz.StringPointer
+	}
+	return clone
+}`
+
+	ConstrainedFile = `// generated by deep-copy; DO NOT EDIT.
+
+//go:build linux
+
+package constrained
+
+// DeepCopy generates a deep copy of Config
+func (o Config) DeepCopy() Config {
+	var cp Config = o
+	if o.Mounts != nil {
+		cp.Mounts = make([]Mount, len(o.Mounts))
+		copy(cp.Mounts, o.Mounts)
+		for i2 := range o.Mounts {
+			cp.Mounts[i2] = o.Mounts[i2].DeepCopy()
+		}
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of Mount
+func (o Mount) DeepCopy() Mount {
+	var cp Mount = o
+	if o.Options != nil {
+		cp.Options = make(map[string]string, len(o.Options))
+		for k2, v2 := range o.Options {
+			cp.Options[k2] = v2
+		}
+	}
+	return cp
+}`
+
+	GenericFile = `// generated by deep-copy; DO NOT EDIT.
+
+package generic
+
+// DeepCopy generates a deep copy of Container
+//
+// Not deeply copied:
+//   - Value: type parameter T shallow copied
+//   - List[i]: type parameter T shallow copied
+//   - ByName[k]: type parameter T shallow copied
+//   - Ptr: type parameter T shallow copied
+func (o Container[T]) DeepCopy() Container[T] {
+	var cp Container[T] = o
+	// Value: type parameter T shallow copied
+	if o.List != nil {
+		cp.List = make([]T, len(o.List))
+		copy(cp.List, o.List)
+		// List[i]: type parameter T shallow copied
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]T, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			// ByName[k]: type parameter T shallow copied
+			cp.ByName[k2] = v2
+		}
+	}
+	if o.Ptr != nil {
+		cp.Ptr = new(T)
+		*cp.Ptr = *o.Ptr
+		// Ptr: type parameter T shallow copied
+	}
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of Pair
+//
+// Not deeply copied:
+//   - Keys[i]: type parameter K shallow copied
+func (o Pair[K, V]) DeepCopy() Pair[K, V] {
+	var cp Pair[K, V] = o
+	if o.Keys != nil {
+		cp.Keys = make([]K, len(o.Keys))
+		copy(cp.Keys, o.Keys)
+		// Keys[i]: type parameter K shallow copied
+	}
+	cp.Value = o.Value.DeepCopy()
+	if o.Values != nil {
+		cp.Values = make([]V, len(o.Values))
+		copy(cp.Values, o.Values)
+		for i2 := range o.Values {
+			cp.Values[i2] = o.Values[i2].DeepCopy()
+		}
+	}
+	if o.ByKey != nil {
+		cp.ByKey = make(map[K]V, len(o.ByKey))
+		for k2, v2 := range o.ByKey {
+			var cp_ByKey_v2 V
+			cp_ByKey_v2 = v2.DeepCopy()
+			cp.ByKey[k2] = cp_ByKey_v2
+		}
+	}
+	return cp
+}`
+
+	ByteMapsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of ByteMaps
+func (o ByteMaps) DeepCopy() ByteMaps {
+	var cp ByteMaps = o
+	if o.Blobs != nil {
+		cp.Blobs = make(map[string][]byte, len(o.Blobs))
+		for k2, v2 := range o.Blobs {
+			var cp_Blobs_v2 []byte
+			if v2 != nil {
+				cp_Blobs_v2 = make([]byte, len(v2))
+				copy(cp_Blobs_v2, v2)
+			}
+			cp.Blobs[k2] = cp_Blobs_v2
+		}
+	}
+	if o.Nested != nil {
+		cp.Nested = make(map[string]map[string][]byte, len(o.Nested))
+		for k2, v2 := range o.Nested {
+			var cp_Nested_v2 map[string][]byte
+			if v2 != nil {
+				cp_Nested_v2 = make(map[string][]byte, len(v2))
+				for k3, v3 := range v2 {
+					var cp_Nested_v2_v3 []byte
+					if v3 != nil {
+						cp_Nested_v2_v3 = make([]byte, len(v3))
+						copy(cp_Nested_v2_v3, v3)
+					}
+					cp_Nested_v2[k3] = cp_Nested_v2_v3
+				}
+			}
+			cp.Nested[k2] = cp_Nested_v2
+		}
+	}
+	if o.Pages != nil {
+		cp.Pages = make([]map[int][]byte, len(o.Pages))
+		copy(cp.Pages, o.Pages)
+		for i2 := range o.Pages {
+			if o.Pages[i2] != nil {
+				cp.Pages[i2] = make(map[int][]byte, len(o.Pages[i2]))
+				for k3, v3 := range o.Pages[i2] {
+					var cp_Pages_i2_v3 []byte
+					if v3 != nil {
+						cp_Pages_i2_v3 = make([]byte, len(v3))
+						copy(cp_Pages_i2_v3, v3)
+					}
+					cp.Pages[i2][k3] = cp_Pages_i2_v3
+				}
+			}
+		}
+	}
+	return cp
+}`
+
+	FieldDocFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	// copying field: Map (type: map[string]*Bar)
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				// copying field: Slice (type: []string)
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	// copying field: ch (type: chan float32)
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	// copying field: baz (type: Baz)
+	// copying field: StringPointer (type: *string)
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+
+	MapOfPointerSlicesFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of MapOfPointerSlices
+func (o MapOfPointerSlices) DeepCopy() MapOfPointerSlices {
+	var cp MapOfPointerSlices = o
+	if o.M != nil {
+		cp.M = make(map[string][]*Bar, len(o.M))
+		for k2, v2 := range o.M {
+			var cp_M_v2 []*Bar
+			if v2 != nil {
+				cp_M_v2 = make([]*Bar, len(v2))
+				copy(cp_M_v2, v2)
+				for i3 := range v2 {
+					if v2[i3] != nil {
+						cp_M_v2[i3] = new(Bar)
+						*cp_M_v2[i3] = *v2[i3]
+						if v2[i3].Slice != nil {
+							cp_M_v2[i3].Slice = make([]string, len(v2[i3].Slice))
+							copy(cp_M_v2[i3].Slice, v2[i3].Slice)
+						}
+					}
+				}
+			}
+			cp.M[k2] = cp_M_v2
+		}
+	}
+	return cp
+}`
+
+	FooPointerGuardFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Foo
+func (o *Foo) DeepCopy() *Foo {
+	if o == nil {
+		return nil
+	}
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}`
+
+	NamedInterfaceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Labeled
+//
+// Not deeply copied:
+//   - Label: interface value shared
+//   - Labels[i]: interface value shared
+//   - ByName[k]: interface value shared
+func (o Labeled) DeepCopy() Labeled {
+	var cp Labeled = o
+	// Label: interface value shared
+	if o.Labels != nil {
+		cp.Labels = make([]Stringer, len(o.Labels))
+		copy(cp.Labels, o.Labels)
+		// Labels[i]: interface value shared
+	}
+	if o.ByName != nil {
+		cp.ByName = make(map[string]*Stringer, len(o.ByName))
+		for k2, v2 := range o.ByName {
+			var cp_ByName_v2 *Stringer
+			if v2 != nil {
+				cp_ByName_v2 = new(Stringer)
+				*cp_ByName_v2 = *v2
+				// ByName[k]: interface value shared
+			}
+			cp.ByName[k2] = cp_ByName_v2
+		}
+	}
+	return cp
+}`
+
+	SharedStdFile = `// generated by deep-copy; DO NOT EDIT.
+
+package shared_std
+
+import (
+	"regexp"
+)
+
+// DeepCopy generates a deep copy of Router
+//
+// Not deeply copied:
+//   - Pattern: shared, *regexp.Regexp is safe to share
+//   - Patterns[k]: shared, *regexp.Regexp is safe to share
+//   - Zone: shared, *time.Location is safe to share
+func (o Router) DeepCopy() Router {
+	var cp Router = o
+	// Pattern: shared, *regexp.Regexp is safe to share
+	if o.Patterns != nil {
+		cp.Patterns = make(map[string]*regexp.Regexp, len(o.Patterns))
+		for k2, v2 := range o.Patterns {
+			// Patterns[k]: shared, *regexp.Regexp is safe to share
+			cp.Patterns[k2] = v2
+		}
+	}
+	// Zone: shared, *time.Location is safe to share
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+	}
+	return cp
+}`
+
+	ShareTypeFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+//
+// Not deeply copied:
+//   - Map[k]: shared via -share-type
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			// Map[k]: shared via -share-type
+			cp.Map[k2] = v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+
+	SortedFieldsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	return cp
+}`
+
+	NoInitialAssignFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Inventory
+//
+// Not deeply copied:
+//   - Owner: skipped via -skip
+//   - Any: interface value shared
+func (o Inventory) DeepCopy() Inventory {
+	var cp Inventory
+	cp.Name = o.Name
+	cp.Count = o.Count
+	// Owner: skipped via -skip
+	cp.Owner = o.Owner
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+	}
+	if o.Done != nil {
+		cp.Done = make(chan bool, cap(o.Done))
+	}
+	cp.Shelf.Row = o.Shelf.Row
+	if o.Shelf.Items != nil {
+		cp.Shelf.Items = make([]string, len(o.Shelf.Items))
+		copy(cp.Shelf.Items, o.Shelf.Items)
+	}
+	if o.Labels != nil {
+		cp.Labels = make(map[string]Shelf, len(o.Labels))
+		for k2, v2 := range o.Labels {
+			var cp_Labels_v2 Shelf
+			if v2.Items != nil {
+				cp_Labels_v2.Items = make([]string, len(v2.Items))
+				copy(cp_Labels_v2.Items, v2.Items)
+			}
+			cp.Labels[k2] = cp_Labels_v2
+		}
+	}
+	// Any: interface value shared
+	cp.Any = o.Any
+	return cp
+}`
+
+	NamedBasicSliceFile = `// generated by deep-copy; DO NOT EDIT.
+
+package durations
+
+import (
+	"time"
+)
+
+// DeepCopy generates a deep copy of Schedule
+func (o Schedule) DeepCopy() Schedule {
+	var cp Schedule = o
+	if o.Intervals != nil {
+		cp.Intervals = make([]time.Duration, len(o.Intervals))
+		copy(cp.Intervals, o.Intervals)
+	}
+	if o.Backoffs != nil {
+		cp.Backoffs = make(map[string][]time.Duration, len(o.Backoffs))
+		for k2, v2 := range o.Backoffs {
+			var cp_Backoffs_v2 []time.Duration
+			if v2 != nil {
+				cp_Backoffs_v2 = make([]time.Duration, len(v2))
+				copy(cp_Backoffs_v2, v2)
+			}
+			cp.Backoffs[k2] = cp_Backoffs_v2
+		}
+	}
+	if o.Steps != nil {
+		cp.Steps = make([][]time.Duration, len(o.Steps))
+		copy(cp.Steps, o.Steps)
+		for i2 := range o.Steps {
+			if o.Steps[i2] != nil {
+				cp.Steps[i2] = make([]time.Duration, len(o.Steps[i2]))
+				copy(cp.Steps[i2], o.Steps[i2])
+			}
+		}
+	}
+	return cp
+}`
+
+	TempPrefixFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for tmpKey2, tmpVal2 := range o.Map {
+			var cp_Map_tmpVal2 *Bar
+			if tmpVal2 != nil {
+				cp_Map_tmpVal2 = new(Bar)
+				*cp_Map_tmpVal2 = *tmpVal2
+				if tmpVal2.Slice != nil {
+					cp_Map_tmpVal2.Slice = make([]string, len(tmpVal2.Slice))
+					copy(cp_Map_tmpVal2.Slice, tmpVal2.Slice)
+				}
+			}
+			cp.Map[tmpKey2] = cp_Map_tmpVal2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of I12NestedSlices
+func (o I12NestedSlices) DeepCopy() I12NestedSlices {
+	var cp I12NestedSlices = o
+	if o.Slices != nil {
+		cp.Slices = make([][][]int, len(o.Slices))
+		copy(cp.Slices, o.Slices)
+		for tmpIdx2 := range o.Slices {
+			if o.Slices[tmpIdx2] != nil {
+				cp.Slices[tmpIdx2] = make([][]int, len(o.Slices[tmpIdx2]))
+				copy(cp.Slices[tmpIdx2], o.Slices[tmpIdx2])
+				for tmpIdx3 := range o.Slices[tmpIdx2] {
+					if o.Slices[tmpIdx2][tmpIdx3] != nil {
+						cp.Slices[tmpIdx2][tmpIdx3] = make([]int, len(o.Slices[tmpIdx2][tmpIdx3]))
+						copy(cp.Slices[tmpIdx2][tmpIdx3], o.Slices[tmpIdx2][tmpIdx3])
+					}
 				}
 			}
-			cp.mapStruct[k2] = cp_mapStruct_v2
 		}
 	}
-	return cp
-}`
+	return cp
+}`
+	OutputVarBothReceiversFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of I12NestedSlices
+func (o I12NestedSlices) DeepCopy() I12NestedSlices {
+	var dst I12NestedSlices = o
+	if o.Slices != nil {
+		dst.Slices = make([][][]int, len(o.Slices))
+		copy(dst.Slices, o.Slices)
+		for i2 := range o.Slices {
+			if o.Slices[i2] != nil {
+				dst.Slices[i2] = make([][]int, len(o.Slices[i2]))
+				copy(dst.Slices[i2], o.Slices[i2])
+				for i3 := range o.Slices[i2] {
+					if o.Slices[i2][i3] != nil {
+						dst.Slices[i2][i3] = make([]int, len(o.Slices[i2][i3]))
+						copy(dst.Slices[i2][i3], o.Slices[i2][i3])
+					}
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// DeepCopyPtr generates a deep copy of *I12NestedSlices
+func (o *I12NestedSlices) DeepCopyPtr() *I12NestedSlices {
+	if o == nil {
+		return nil
+	}
+	dst := o.DeepCopy()
+	return &dst
+}`
+	ZeroFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Record
+//
+// Not deeply copied:
+//   - ID: zeroed via -zero
+//   - Tags: zeroed via -zero
+//   - Owner.ID: zeroed via -zero
+func (o Record) DeepCopy() Record {
+	var cp Record = o
+	// ID: zeroed via -zero
+	cp.ID = 0
+	// Tags: zeroed via -zero
+	cp.Tags = nil
+	// Owner.ID: zeroed via -zero
+	cp.Owner.ID = 0
+	if o.Owner.Email != nil {
+		cp.Owner.Email = new(string)
+		*cp.Owner.Email = *o.Owner.Email
+	}
+	return cp
+}`
+	ZeroWithSkipsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Record
+//
+// Not deeply copied:
+//   - CreatedAt: zeroed via -zero
+//   - Owner.Email: skipped via -skip
+func (o Record) DeepCopy() Record {
+	var cp Record = o
+	// CreatedAt: zeroed via -zero
+	cp.CreatedAt = 0
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+	}
+	// Owner.Email: skipped via -skip
+	return cp
+}`
+	PromotedSkipsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Server
+//
+// Not deeply copied:
+//   - ServerConfig.Timeout: skipped via -skip
+//   - ServerConfig.Hosts[i]: skipped via -skip
+//   - ServerConfig.TLS.CertFile: skipped via -skip
+//   - Name: skipped via -skip
+func (o Server) DeepCopy() Server {
+	var cp Server = o
+	// ServerConfig.Timeout: skipped via -skip
+	// ServerConfig.Hosts[i]: skipped via -skip
+	if o.ServerConfig.Hosts != nil {
+		cp.ServerConfig.Hosts = make([]string, len(o.ServerConfig.Hosts))
+		copy(cp.ServerConfig.Hosts, o.ServerConfig.Hosts)
+	}
+	// ServerConfig.TLS.CertFile: skipped via -skip
+	if o.ServerConfig.Name != nil {
+		cp.ServerConfig.Name = new(string)
+		*cp.ServerConfig.Name = *o.ServerConfig.Name
+	}
+	// Name: skipped via -skip
+	return cp
+}`
+	EmbeddedSkipsFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Server
+//
+// Not deeply copied:
+//   - ServerConfig.Timeout: skipped via -skip
+//   - ServerConfig.Name: skipped via -skip
+func (o Server) DeepCopy() Server {
+	var cp Server = o
+	// ServerConfig.Timeout: skipped via -skip
+	if o.ServerConfig.Hosts != nil {
+		cp.ServerConfig.Hosts = make([]string, len(o.ServerConfig.Hosts))
+		copy(cp.ServerConfig.Hosts, o.ServerConfig.Hosts)
+	}
+	if o.ServerConfig.TLS.CertFile != nil {
+		cp.ServerConfig.TLS.CertFile = new(string)
+		*cp.ServerConfig.TLS.CertFile = *o.ServerConfig.TLS.CertFile
+	}
+	// ServerConfig.Name: skipped via -skip
+	if o.Name != nil {
+		cp.Name = new(string)
+		*cp.Name = *o.Name
+	}
+	return cp
+}`
+	BarCustomReceiverFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of Bar
+func (b Bar) DeepCopy() Bar {
+	var cp Bar = b
+	if b.Slice != nil {
+		cp.Slice = make([]string, len(b.Slice))
+		copy(cp.Slice, b.Slice)
+	}
+	return cp
+}
+
+// DeepCopyPtr generates a deep copy of *Bar
+func (b *Bar) DeepCopyPtr() *Bar {
+	if b == nil {
+		return nil
+	}
+	cp := b.DeepCopy()
+	return &cp
+}`
+
+	ExternalPointer = `// generated by deep-copy; DO NOT EDIT.
+
+package external_pointer
+
+import (
+	"github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+)
+
+// DeepCopy generates a deep copy of Holder
+func (o Holder) DeepCopy() Holder {
+	var cp Holder = o
+	if o.Item != nil {
+		cp.Item = new(item.Item)
+		*cp.Item = *o.Item
+	}
+	return cp
+}`
+
+	ExternalPointerSkipped = `// generated by deep-copy; DO NOT EDIT.
+
+package external_pointer
+
+// DeepCopy generates a deep copy of Holder
+//
+// Not deeply copied:
+//   - Item: skipped via -skip
+func (o Holder) DeepCopy() Holder {
+	var cp Holder = o
+	// Item: skipped via -skip
+	return cp
+}`
+
+	ListFile = `Cloner  interface             DeepCopy: yes  interfaces: yes
+Holder  struct with 1 field   DeepCopy: yes  interfaces: yes
+Limits  struct with 1 field   DeepCopy: yes  interfaces: no
+Names   slice                 DeepCopy: no   interfaces: no
+Plain   struct with 2 fields  DeepCopy: no   interfaces: no
+plains  map                   DeepCopy: no   interfaces: no
+`
+
+	ListTypesFile = `Holder  struct with 1 field   DeepCopy: yes  interfaces: yes
+Limits  struct with 1 field   DeepCopy: yes  interfaces: no
+Names   slice                 DeepCopy: no   interfaces: no
+Plain   struct with 2 fields  DeepCopy: no   interfaces: no
+`
+
+	ListAllTypesFile = `Holder  struct with 1 field   DeepCopy: yes  interfaces: yes
+Limits  struct with 1 field   DeepCopy: yes  interfaces: no
+Names   slice                 DeepCopy: no   interfaces: no
+Plain   struct with 2 fields  DeepCopy: no   interfaces: no
+plains  map                   DeepCopy: no   interfaces: no
+`
+
+	ListTaggedFile = `Cloner  interface             DeepCopy: yes  interfaces: yes
+Holder  struct with 1 field   DeepCopy: yes  interfaces: yes
+Limits  struct with 1 field   DeepCopy: yes  interfaces: no
+Names   slice                 DeepCopy: no   interfaces: no
+Plain   struct with 2 fields  DeepCopy: no   interfaces: no
+Tagged  struct with 1 field   DeepCopy: no   interfaces: no
+plains  map                   DeepCopy: no   interfaces: no
+`
+
+	FuzzTargetFuzzFile = `// generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"fmt"
+	"testing"
+)
+
+// FuzzFuzzTargetDeepCopy asserts that mutating a deep copy of FuzzTarget does not
+// change the original value.
+func FuzzFuzzTargetDeepCopy(f *testing.F) {
+	f.Add([]byte("deep-copy"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var o FuzzTarget
+		d := deepCopyFuzzData(data)
+		_ = d
+		o.Name = d.nextString()
+		o.Count = d.nextInt()
+		o.Tags = make([]string, d.nextLen())
+		for i := range o.Tags {
+			o.Tags[i] = d.nextString()
+		}
+		o.Scores = make(map[string]float64)
+		for n := d.nextLen(); n > 0; n-- {
+			o.Scores[d.nextString()] = float64(d.nextInt())
+		}
+		before := fmt.Sprintf("%#v", o)
+		cp := o.DeepCopy()
+		cp.Name = cp.Name + "~"
+		cp.Count = cp.Count + 1
+		for i := range cp.Tags {
+			cp.Tags[i] = cp.Tags[i] + "~"
+		}
+		for k := range cp.Scores {
+			cp.Scores[k] = cp.Scores[k] + 1
+		}
+		if after := fmt.Sprintf("%#v", o); after != before {
+			t.Fatalf("mutating the copy changed the original:\nbefore: %s\nafter:  %s", before, after)
+		}
+	})
+}
+
+// deepCopyFuzzData hands out values decoded from the fuzzer input.
+type deepCopyFuzzData []byte
+
+func (d *deepCopyFuzzData) nextByte() byte {
+	if len(*d) == 0 {
+		return 0
+	}
+	b := (*d)[0]
+	*d = (*d)[1:]
+	return b
+}
+
+func (d *deepCopyFuzzData) nextInt() int {
+	return int(int8(d.nextByte()))
+}
+
+func (d *deepCopyFuzzData) nextBool() bool {
+	return d.nextByte()&1 == 1
+}
+
+func (d *deepCopyFuzzData) nextLen() int {
+	return int(d.nextByte() % 8)
+}
+
+func (d *deepCopyFuzzData) nextString() string {
+	n := d.nextLen()
+	if n > len(*d) {
+		n = len(*d)
+	}
+	s := string((*d)[:n])
+	*d = (*d)[n:]
+	return s
+}`
+
+	MapOfPointerSlicesProgram = `package main
+
+import "roundtrip/testdata"
+
+func main() {
+	orig := testdata.MapOfPointerSlices{M: map[string][]*testdata.Bar{
+		"k":     {{IntV: 1, Slice: []string{"a"}}, nil},
+		"empty": {},
+		"nil":   nil,
+	}}
+
+	cp := orig.DeepCopy()
+	cp.M["k"][0].IntV = 42
+	cp.M["k"][0].Slice[0] = "b"
+	cp.M["k"][1] = &testdata.Bar{}
+
+	if got := orig.M["k"][0]; got.IntV != 1 || got.Slice[0] != "a" {
+		panic("copied pointers share state with the original")
+	}
+	if orig.M["k"][1] != nil {
+		panic("copied slice shares state with the original")
+	}
+	if got, ok := cp.M["empty"]; !ok || got == nil || len(got) != 0 {
+		panic("empty slice not preserved")
+	}
+	if got, ok := cp.M["nil"]; !ok || got != nil {
+		panic("nil slice not preserved")
+	}
+}
+`
+
+	GuardNilReceiverProgram = `package main
+
+import "roundtrip/testdata"
+
+func main() {
+	var nilNode *testdata.Node
+	if cp := nilNode.DeepCopy(); cp != nil {
+		panic("copy of a nil receiver isn't nil")
+	}
+
+	orig := &testdata.Node{Name: "root", Children: []*testdata.Node{{Name: "leaf"}, nil}}
+	cp := orig.DeepCopy()
+	orig.Children[0].Name = "changed"
+	if cp.Children[0].Name != "leaf" || cp.Children[1] != nil {
+		panic("copy shares state with the original")
+	}
+}
+`
+
+	SharedStdProgram = `package main
+
+import (
+	"regexp"
+	"time"
+
+	"roundtrip/shared_std"
+)
+
+func main() {
+	orig := shared_std.Router{
+		Name:     "api",
+		Pattern:  regexp.MustCompile("^/api/"),
+		Patterns: map[string]*regexp.Regexp{"id": regexp.MustCompile("[0-9]+")},
+		Zone:     time.UTC,
+		Tags:     []string{"public"},
+	}
+	cp := orig.DeepCopy()
+
+	if cp.Pattern != orig.Pattern || cp.Patterns["id"] != orig.Patterns["id"] || cp.Zone != orig.Zone {
+		panic("shared pointers not shared")
+	}
+	if !cp.Pattern.MatchString("/api/users") || !cp.Patterns["id"].MatchString("42") {
+		panic("shared regexp doesn't match")
+	}
+
+	orig.Patterns["name"] = regexp.MustCompile("[a-z]+")
+	orig.Tags[0] = "internal"
+	if len(cp.Patterns) != 1 || cp.Tags[0] != "public" {
+		panic("copy shares state with the original")
+	}
+}
+`
+
+	MapOfSlicePointersProgram = `package main
+
+import "roundtrip/testdata"
+
+func main() {
+	ints := []int{1, 2, 3}
+	orig := testdata.MapOfSlicePointers{M: map[string]*[]int{
+		"ints": &ints,
+		"nil":  nil,
+	}}
+
+	cp := orig.DeepCopy()
+	(*orig.M["ints"])[0] = 42
+	*orig.M["ints"] = append(*orig.M["ints"], 4)
+
+	if got := *cp.M["ints"]; len(got) != 3 || got[0] != 1 {
+		panic("copied slice shares state with the original")
+	}
+	if p, ok := cp.M["nil"]; !ok || p != nil {
+		panic("nil slice pointer not preserved")
+	}
+}
+`
+
+	WithErrorProgram = `package main
+
+import (
+	"errors"
+	"strings"
+
+	"roundtrip/testdata"
+)
+
+type plugin string
+
+func (p plugin) Name() string { return string(p) }
+
+type state struct{ fail bool }
+
+func (s *state) DeepCopy() (testdata.Cloner, error) {
+	if s.fail {
+		return nil, errBroken
+	}
+	cp := *s
+	return &cp, nil
+}
+
+var errBroken = errors.New("broken state")
+
+func main() {
+	orig := testdata.Job{
+		Name:    "job",
+		State:   &state{},
+		Limits:  &testdata.Limits{Values: []int{1}},
+		Retries: testdata.Retries{Delays: []int{1}},
+		Steps:   []testdata.Step{{Plugins: map[string]testdata.Plugin{"none": nil}}},
+	}
+
+	cp, err := orig.DeepCopy()
+	if err != nil {
+		panic(err)
+	}
+	orig.Limits.Values[0] = 42
+	orig.Retries.Delays[0] = 42
+	if cp.Limits.Values[0] != 1 || cp.Retries.Delays[0] != 1 || cp.State == orig.State {
+		panic("copy shares state with the original")
+	}
+	if _, ok := cp.Steps[0].Plugins["none"]; !ok {
+		panic("nil plugin not copied")
+	}
+
+	orig.Plugin = plugin("shared")
+	if _, err := orig.DeepCopy(); err == nil || !strings.Contains(err.Error(), "Plugin: main.plugin can't be deep copied") {
+		panic("plugin copied without an error")
+	}
+
+	orig.Plugin = nil
+	orig.State = &state{fail: true}
+	if _, err := orig.DeepCopy(); !errors.Is(err, errBroken) {
+		panic("error of the state not returned")
+	}
+
+	orig.State = nil
+	orig.Steps[0].Plugins["shared"] = plugin("shared")
+	if _, err := orig.DeepCopy(); err == nil || !strings.Contains(err.Error(), "Steps[i]: Plugins[k]: main.plugin can't be deep copied") {
+		panic("step plugin copied without an error")
+	}
+}
+`
+
+	WithContextProgram = `package main
+
+import (
+	"context"
+	"errors"
+
+	"roundtrip/testdata"
+)
+
+func main() {
+	orig := testdata.Job{
+		Name: "job",
+		Steps: []testdata.Step{
+			{Plugins: map[string]testdata.Plugin{"none": nil}},
+		},
+	}
 
-	Issue7ShadowedMapVars2 = `// generated by deep-copy; DO NOT EDIT.
+	cp, err := orig.DeepCopy(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	if len(cp.Steps) != 1 || len(cp.Steps[0].Plugins) != 1 {
+		panic("steps not copied")
+	}
 
-package testdata
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := orig.DeepCopy(ctx); !errors.Is(err, context.Canceled) {
+		panic("copy not stopped by the canceled context")
+	}
+	if _, err := orig.Steps[0].DeepCopy(ctx); !errors.Is(err, context.Canceled) {
+		panic("copy of a step not stopped by the canceled context")
+	}
+}
+`
 
-// DeepCopy generates a deep copy of SomeStruct
-func (o SomeStruct) DeepCopy() SomeStruct {
-	var cp SomeStruct = o
-	if o.mapSlice != nil {
-		cp.mapSlice = make(map[string][]string, len(o.mapSlice))
-		for k2, v2 := range o.mapSlice {
-			var cp_mapSlice_v2 []string
-			if v2 != nil {
-				cp_mapSlice_v2 = make([]string, len(v2))
-				copy(cp_mapSlice_v2, v2)
-			}
-			cp.mapSlice[k2] = cp_mapSlice_v2
-		}
+	MaxDepthTreeProgram = `package main
+
+import "roundtrip/testdata"
+
+func main() {
+	leaf := &testdata.Node{Name: "3"}
+	orig := testdata.Node{Name: "0", Children: []*testdata.Node{
+		{Name: "1", Children: []*testdata.Node{
+			{Name: "2", Children: []*testdata.Node{leaf}},
+		}},
+	}}
+
+	cp := orig.DeepCopy()
+	orig.Children[0].Name = "changed"
+	orig.Children[0].Children[0].Name = "changed"
+	if cp.Children[0].Name != "1" || cp.Children[0].Children[0].Name != "2" {
+		panic("copied node shares state with the original")
+	}
+	if cp.Children[0].Children[0].Children[0] != leaf {
+		panic("node past the max depth not shallow copied")
 	}
-	return cp
 }
+`
 
-// DeepCopy generates a deep copy of SomeStruct2
-func (o SomeStruct2) DeepCopy() SomeStruct2 {
-	var cp SomeStruct2 = o
-	if o.mapStruct != nil {
-		cp.mapStruct = make(map[string]SomeStruct, len(o.mapStruct))
-		for k2, v2 := range o.mapStruct {
-			var cp_mapStruct_v2 SomeStruct
-			cp_mapStruct_v2 = v2.DeepCopy()
-			cp.mapStruct[k2] = cp_mapStruct_v2
-		}
+	NonNilCollectionsProgram = `package main
+
+import (
+	"encoding/json"
+
+	"roundtrip/testdata"
+)
+
+func main() {
+	orig := testdata.I12StructWithMapOfSlices{}
+	if b, _ := json.Marshal(orig); string(b) != "{\"Sc1\":null}" {
+		panic("unexpected encoding of the original: " + string(b))
+	}
+	if b, _ := json.Marshal(orig.DeepCopy()); string(b) != "{\"Sc1\":{}}" {
+		panic("nil map not copied as an empty one: " + string(b))
 	}
-	return cp
-}`
 
-	Issue10StructCH = `// generated by deep-copy; DO NOT EDIT.
+	withNil := testdata.I12StructWithMapOfSlices{Sc1: map[string][]testdata.I12StructWithSlices{
+		"nil":   nil,
+		"names": {{Name: nil}},
+	}}
+	want := "{\"Sc1\":{\"names\":[{\"Name\":[]}],\"nil\":[]}}"
+	if b, _ := json.Marshal(withNil.DeepCopy()); string(b) != want {
+		panic("nested nil slices not copied as empty ones: " + string(b))
+	}
 
-package testdata
+	nested := testdata.I12NestedSlices{Slices: [][][]int{nil, {nil}}}
+	if b, _ := json.Marshal(nested.DeepCopy()); string(b) != "{\"Slices\":[[],[[]]]}" {
+		panic("nested nil slices not copied as empty ones: " + string(b))
+	}
+}
+`
 
-// DeepCopy generates a deep copy of StructCH
-func (o StructCH) DeepCopy() StructCH {
-	var cp StructCH = o
-	if o.Nested != nil {
-		cp.Nested = make([]StructNested, len(o.Nested))
-		copy(cp.Nested, o.Nested)
-		for i2 := range o.Nested {
-			if o.Nested[i2].B != nil {
-				cp.Nested[i2].B = new(int)
-				*cp.Nested[i2].B = *o.Nested[i2].B
-			}
-		}
+	KubeHolderProgram = `package main
+
+import "roundtrip/testdata"
+
+func main() {
+	orig := testdata.KubeHolder{
+		Specs:  map[string]testdata.KubeSpec{"a": {Args: []string{"a"}}},
+		Spec:   &testdata.KubeSpec{Args: []string{"p"}},
+		Direct: testdata.KubeSpec{Args: []string{"d"}},
+		List:   []testdata.KubeSpec{{Args: []string{"l"}}},
 	}
-	return cp
-}`
 
-	PointerThatImplementsDeepcopy = `// generated by deep-copy; DO NOT EDIT.
+	cp := orig.DeepCopy()
+	orig.Specs["a"].Args[0] = "changed"
+	orig.Spec.Args[0] = "changed"
+	orig.Direct.Args[0] = "changed"
+	orig.List[0].Args[0] = "changed"
 
-package somepkg
+	if cp.Specs["a"].Args[0] != "a" || cp.Spec.Args[0] != "p" || cp.Direct.Args[0] != "d" || cp.List[0].Args[0] != "l" {
+		panic("copy shares state with the original")
+	}
+	if cp.Spec == orig.Spec {
+		panic("pointer shared with the original")
+	}
+}
+`
 
-// DeepCopy generates a deep copy of SomeStruct
-func (o SomeStruct) DeepCopy() SomeStruct {
-	var cp SomeStruct = o
-	if o.AnotherStruct != nil {
-		cp.AnotherStruct = o.AnotherStruct.DeepCopy()
+	GenericProgram = `package main
+
+import "roundtrip/generic"
+
+type item struct{ tags []string }
+
+func (i item) DeepCopy() item {
+	return item{tags: append([]string(nil), i.tags...)}
+}
+
+func main() {
+	n := 1
+	orig := generic.Container[*int]{Value: &n, List: []*int{&n}, Tags: []string{"a"}}
+	cp := orig.DeepCopy()
+	if cp.Value != &n || cp.List[0] != &n {
+		panic("type parameter values not shallow copied")
+	}
+	cp.List[0], cp.Tags[0] = nil, "b"
+	if orig.List[0] != &n || orig.Tags[0] != "a" {
+		panic("copied slices share state with the original")
 	}
-	return cp
-}`
 
-	Issue12NestedSlices = `// generated by deep-copy; DO NOT EDIT.
+	pair := generic.Pair[string, item]{Value: item{tags: []string{"a"}}, Values: []item{{tags: []string{"b"}}}, ByKey: map[string]item{"c": {tags: []string{"c"}}}}
+	pairCp := pair.DeepCopy()
+	pairCp.Value.tags[0], pairCp.Values[0].tags[0], pairCp.ByKey["c"].tags[0] = "x", "y", "z"
+	if pair.Value.tags[0] != "a" || pair.Values[0].tags[0] != "b" || pair.ByKey["c"].tags[0] != "c" {
+		panic("values copying themselves share state with the original")
+	}
+}
+`
 
-package testdata
+	GenericFuncProgram = `package main
 
-// DeepCopy generates a deep copy of I12NestedSlices
-func (o I12NestedSlices) DeepCopy() I12NestedSlices {
-	var cp I12NestedSlices = o
-	if o.Slices != nil {
-		cp.Slices = make([][][]int, len(o.Slices))
-		copy(cp.Slices, o.Slices)
-		for i2 := range o.Slices {
-			if o.Slices[i2] != nil {
-				cp.Slices[i2] = make([][]int, len(o.Slices[i2]))
-				copy(cp.Slices[i2], o.Slices[i2])
-				for i3 := range o.Slices[i2] {
-					if o.Slices[i2][i3] != nil {
-						cp.Slices[i2][i3] = make([]int, len(o.Slices[i2][i3]))
-						copy(cp.Slices[i2][i3], o.Slices[i2][i3])
-					}
-				}
-			}
-		}
+import "roundtrip/generic"
+
+type item struct{ tags []string }
+
+func (i item) DeepCopy() item {
+	return item{tags: append([]string(nil), i.tags...)}
+}
+
+func main() {
+	orig := generic.Container[string]{Value: "a", List: []string{"b"}}
+	cp := DeepCopyContainer(orig)
+	cp.List[0] = "c"
+	if cp.Value != "a" || orig.List[0] != "b" {
+		panic("copied slices share state with the original")
 	}
-	return cp
-}`
 
-	Issue12MapWithSliceValues = `// generated by deep-copy; DO NOT EDIT.
+	pair := generic.Pair[int, item]{Keys: []int{1}, Value: item{tags: []string{"a"}}}
+	pairCp := DeepCopyPair(pair)
+	pairCp.Value.tags[0] = "x"
+	if pair.Value.tags[0] != "a" {
+		panic("values copying themselves share state with the original")
+	}
+}
+`
 
-package testdata
+	ByteMapsProgram = `package main
 
-// DeepCopy generates a deep copy of I12StructWithMapOfSlices
-func (o I12StructWithMapOfSlices) DeepCopy() I12StructWithMapOfSlices {
-	var cp I12StructWithMapOfSlices = o
-	if o.Sc1 != nil {
-		cp.Sc1 = make(map[string][]I12StructWithSlices, len(o.Sc1))
-		for k2, v2 := range o.Sc1 {
-			var cp_Sc1_v2 []I12StructWithSlices
-			if v2 != nil {
-				cp_Sc1_v2 = make([]I12StructWithSlices, len(v2))
-				copy(cp_Sc1_v2, v2)
-				for i3 := range v2 {
-					if v2[i3].Name != nil {
-						cp_Sc1_v2[i3].Name = make([]string, len(v2[i3].Name))
-						copy(cp_Sc1_v2[i3].Name, v2[i3].Name)
-					}
-				}
+import (
+	"bytes"
+
+	"roundtrip/testdata"
+)
+
+// check panics unless the copy of each value of the original has the same
+// nil-ness and content, and doesn't share its memory.
+func check(orig, cp map[string][]byte) {
+	for k, v := range orig {
+		c, ok := cp[k]
+		if !ok {
+			panic("map entry " + k + " lost")
+		}
+		if (v == nil) != (c == nil) || !bytes.Equal(v, c) {
+			panic("map value " + k + " not preserved")
+		}
+		if len(v) > 0 {
+			v[0]++
+			if c[0] == v[0] {
+				panic("map value " + k + " shares state with the original")
 			}
-			cp.Sc1[k2] = cp_Sc1_v2
 		}
 	}
-	return cp
-}`
+}
 
-	I15ParentHasChildValueValueRecv = `// generated by deep-copy; DO NOT EDIT.
+func main() {
+	values := func() map[string][]byte {
+		return map[string][]byte{"nil": nil, "empty": {}, "full": {1, 2, 3}}
+	}
 
-package testdata
+	orig := testdata.ByteMaps{
+		Blobs:  values(),
+		Nested: map[string]map[string][]byte{"a": values(), "nil": nil},
+		Pages:  []map[int][]byte{{0: nil, 1: {}, 2: {4}}},
+	}
+	cp := orig.DeepCopy()
 
-// DeepCopy generates a deep copy of ParentHasChildValue
-func (o ParentHasChildValue) DeepCopy() ParentHasChildValue {
-	var cp ParentHasChildValue = o
-	cp.c = o.c.DeepCopy()
-	return cp
+	check(orig.Blobs, cp.Blobs)
+	check(orig.Nested["a"], cp.Nested["a"])
+	if v, ok := cp.Nested["nil"]; !ok || v != nil {
+		panic("nil nested map not preserved")
+	}
+	if cp.Pages[0][0] != nil || cp.Pages[0][1] == nil || len(cp.Pages[0][1]) != 0 || cp.Pages[0][2][0] != 4 {
+		panic("map values of a slice not preserved")
+	}
+	orig.Pages[0][2][0] = 42
+	if cp.Pages[0][2][0] != 4 {
+		panic("map values of a slice share state with the original")
+	}
 }
+`
 
-// DeepCopy generates a deep copy of Child
-func (o Child) DeepCopy() Child {
-	var cp Child = o
-	return cp
-}`
+	NoInitialAssignProgram = `package main
 
-	I15ParentHasChildPointerValueRecv = `// generated by deep-copy; DO NOT EDIT.
+import (
+	"reflect"
 
-package testdata
+	"roundtrip/testdata"
+)
 
-// DeepCopy generates a deep copy of ParentHasChildPointer
-func (o ParentHasChildPointer) DeepCopy() ParentHasChildPointer {
-	var cp ParentHasChildPointer = o
-	if o.c != nil {
-		retV := o.c.DeepCopy()
-		cp.c = &retV
+func main() {
+	owner := "ops"
+	orig := testdata.Inventory{
+		Name:  "pantry",
+		Count: 3,
+		Owner: &owner,
+		Tags:  []string{"dry"},
+		Done:  make(chan bool, 1),
+		Shelf: testdata.Shelf{Row: 2, Items: []string{"rice"}},
+		Any:   42,
+	}
+	cp := orig.DeepCopy()
+
+	orig.Done = nil
+	if cp.Done == nil || cap(cp.Done) != 1 {
+		panic("channel not copied")
+	}
+	cp.Done = nil
+	if !reflect.DeepEqual(orig, cp) {
+		panic("fields not assigned")
+	}
+
+	*orig.Owner = "dev"
+	orig.Tags[0] = "wet"
+	orig.Shelf.Items[0] = "beans"
+	if *cp.Owner != "ops" || cp.Tags[0] != "dry" || cp.Shelf.Items[0] != "rice" {
+		panic("copy shares state with the original")
 	}
-	return cp
 }
+`
 
-// DeepCopy generates a deep copy of Child
-func (o Child) DeepCopy() Child {
-	var cp Child = o
-	return cp
-}`
+	NilSlicesProgram = `package main
 
-	I15ParentHasChildValuePointerRecv = `// generated by deep-copy; DO NOT EDIT.
+import "roundtrip/testdata"
 
-package testdata
+func main() {
+	if cp := (testdata.I12NestedSlices{}).DeepCopy(); cp.Slices != nil {
+		panic("nil slice copied as an empty one")
+	}
 
-// DeepCopy generates a deep copy of *ParentHasChildValue
-func (o *ParentHasChildValue) DeepCopy() *ParentHasChildValue {
-	var cp ParentHasChildValue = *o
-	{
-		retV := o.c.DeepCopy()
-		cp.c = *retV
+	orig := testdata.I12NestedSlices{Slices: [][][]int{nil, {}, {nil, {}, {1}}}}
+	cp := orig.DeepCopy()
+	if cp.Slices[0] != nil || cp.Slices[2][0] != nil {
+		panic("nil slice copied as an empty one")
+	}
+	if cp.Slices[1] == nil || len(cp.Slices[1]) != 0 || cp.Slices[2][1] == nil || len(cp.Slices[2][1]) != 0 {
+		panic("empty slice copied as a nil one")
+	}
+	orig.Slices[2][2][0] = 42
+	if cp.Slices[2][2][0] != 1 {
+		panic("copied slice shares state with the original")
+	}
+
+	if cp := (testdata.I12StructWithMapOfSlices{Sc1: map[string][]testdata.I12StructWithSlices{}}).DeepCopy(); cp.Sc1 == nil {
+		panic("empty map copied as a nil one")
+	}
+
+	withMap := testdata.I12StructWithMapOfSlices{Sc1: map[string][]testdata.I12StructWithSlices{
+		"nil":   nil,
+		"empty": {},
+		"names": {{Name: nil}, {Name: []string{}}},
+	}}
+	mapCp := withMap.DeepCopy()
+	if v, ok := mapCp.Sc1["nil"]; !ok || v != nil {
+		panic("nil map value not preserved")
+	}
+	if v := mapCp.Sc1["empty"]; v == nil || len(v) != 0 {
+		panic("empty map value copied as a nil one")
+	}
+	if names := mapCp.Sc1["names"]; names[0].Name != nil || names[1].Name == nil {
+		panic("nil-ness of nested slices not preserved")
 	}
-	return &cp
 }
+`
 
-// DeepCopy generates a deep copy of *Child
-func (o *Child) DeepCopy() *Child {
-	var cp Child = *o
-	return &cp
-}`
+	TreeProgram = `package main
 
-	I15ParentHasChildPointerPointerRecv = `// generated by deep-copy; DO NOT EDIT.
+import "roundtrip/testdata"
 
-package testdata
+func main() {
+	orig := testdata.Node{Name: "root", Children: []*testdata.Node{
+		{Name: "child", Children: []*testdata.Node{
+			{Name: "grandchild"},
+		}},
+		nil,
+	}}
 
-// DeepCopy generates a deep copy of *ParentHasChildPointer
-func (o *ParentHasChildPointer) DeepCopy() *ParentHasChildPointer {
-	var cp ParentHasChildPointer = *o
-	if o.c != nil {
-		cp.c = o.c.DeepCopy()
+	cp := orig.DeepCopy()
+	orig.Children[0].Children[0].Name = "changed"
+	orig.Children[0].Children = append(orig.Children[0].Children, &testdata.Node{Name: "added"})
+
+	if got := cp.Children[0].Children; len(got) != 1 || got[0].Name != "grandchild" {
+		panic("copied grandchild shares state with the original")
+	}
+	if cp.Children[1] != nil {
+		panic("nil child not preserved")
 	}
-	return &cp
 }
+`
 
-// DeepCopy generates a deep copy of *Child
-func (o *Child) DeepCopy() *Child {
-	var cp Child = *o
-	return &cp
-}`
+	FuncProgram = `package main
 
-	Issue17MaxDepth = `// generated by deep-copy; DO NOT EDIT.
+import "roundtrip/testdata"
 
-package testdata
+func main() {
+	orig := testdata.Node{Name: "root", Children: []*testdata.Node{{Name: "child"}, nil}}
 
-// DeepCopy generates a deep copy of *Depth1
-func (o *Depth1) DeepCopy() *Depth1 {
-	var cp Depth1 = *o
-	if o.a1 != nil {
-		cp.a1 = new(Depth2)
-		*cp.a1 = *o.a1
-	}
-	if o.a2 != nil {
-		cp.a2 = new(Depth2)
-		*cp.a2 = *o.a2
-	}
-	return &cp
-}`
+	cp := DeepCopyNode(orig)
+	orig.Children[0].Name = "changed"
 
-	AliasImport = `// generated by deep-copy; DO NOT EDIT.
+	if cp.Children[0].Name != "child" || cp.Children[1] != nil {
+		panic("function copy shares state with the original")
+	}
+}
+`
 
-package import_alias
+	BothReceiversProgram = `package main
 
-import (
-	github_com_globusdigital_deep_copy_testdata_import_alias_another_item "github.com/globusdigital/deep-copy/testdata/import_alias/another/item"
-	"github.com/globusdigital/deep-copy/testdata/import_alias/item"
-)
+import "roundtrip/testdata"
 
-// DeepCopy generates a deep copy of Data
-func (o Data) DeepCopy() Data {
-	var cp Data = o
-	if o.Items != nil {
-		cp.Items = make([]item.Item, len(o.Items))
-		copy(cp.Items, o.Items)
+func main() {
+	var nilNode *testdata.Node
+	if nilNode.DeepCopyPtr() != nil {
+		panic("copy of a nil pointer is not nil")
 	}
-	if o.AnotherItems != nil {
-		cp.AnotherItems = make([]github_com_globusdigital_deep_copy_testdata_import_alias_another_item.Item, len(o.AnotherItems))
-		copy(cp.AnotherItems, o.AnotherItems)
+
+	orig := &testdata.Node{Name: "root", Children: []*testdata.Node{{Name: "child"}}}
+	cp := orig.DeepCopyPtr()
+	orig.Children[0].Name = "changed"
+
+	if cp == orig || cp.Children[0].Name != "child" {
+		panic("pointer copy shares state with the original")
 	}
-	return cp
-}`
+}
+`
 )
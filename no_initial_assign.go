@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// assignField copies the field i of the struct v from source to sink, with
+// -no-initial-assign: sink isn't a shallow copy of source, so the fields
+// walkField leaves as is are assigned. Fields of struct types are assigned
+// field by field in turn, unless they have fields of another package that
+// can't be named.
+func (a *app) assignField(source, sink, sel, x string, v *types.Struct, i int, needExported bool, w io.Writer, imports map[string]string, skips skips, generating []object, depth int) error {
+	field := v.Field(i)
+	fname := field.Name()
+	t := field.Type()
+
+	_, isStruct := t.Underlying().(*types.Struct)
+	_, isArray := t.Underlying().(*types.Array)
+	whole := isArray || !holdsReferences(t, map[types.Type]bool{}) || isStruct && foreignUnexportedFields(t, x)
+
+	var b bytes.Buffer
+	a.stats.unassigned = isStruct && !whole
+	err := a.walkField(source, sink, sel, x, v, i, needExported, &b, imports, skips, generating, depth)
+	a.stats.unassigned = false
+	if err != nil {
+		return err
+	}
+
+	assign := fmt.Sprintf("%s.%s = %s.%s\n", sink, fname, source, fname)
+	switch _, zero := a.isSkipped(skips, joinSel(sel, fname)); {
+	case needExported && !field.Exported(), zero, a.skipZeroSize && isZeroSize(t):
+		// Left out, or left to its zero value.
+	case !hasCode(b.Bytes()):
+		// Shallow copied.
+		b.WriteString(assign)
+	case whole:
+		// Arrays and structs of other packages are copied as a whole
+		// first, their values holding references are then walked.
+		io.WriteString(w, assign)
+	}
+	b.WriteTo(w)
+
+	return nil
+}
+
+// hasUnexportedFields reports whether t is a struct with unexported fields.
+func hasUnexportedFields(t types.Type) bool {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if !st.Field(i).Exported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// foreignUnexportedFields reports whether t is a struct type of a package
+// other than x with unexported fields, which can't be assigned one by one.
+func foreignUnexportedFields(t types.Type, x string) bool {
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil || n.Obj().Pkg().Name() == x {
+		return false
+	}
+
+	return hasUnexportedFields(n)
+}
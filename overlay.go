@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// overlayFile is the format of the -overlay file, the one of go build
+// -overlay: the replacement file of each source file.
+type overlayFile struct {
+	Replace map[string]string
+}
+
+// readOverlay reads the -overlay file name, and returns the contents of the
+// replacement files by the absolute path of the files they replace, as
+// packages.Config.Overlay expects. As with go build, relative paths are
+// resolved from the current directory. Deleting files isn't supported.
+func readOverlay(name string) (map[string][]byte, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var f overlayFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", name, err)
+	}
+
+	overlay := make(map[string][]byte, len(f.Replace))
+	for path, replacement := range f.Replace {
+		if replacement == "" {
+			return nil, fmt.Errorf("%s is deleted, which isn't supported", path)
+		}
+
+		content, err := os.ReadFile(replacement)
+		if err != nil {
+			return nil, fmt.Errorf("reading the replacement of %s: %v", path, err)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		overlay[abs] = content
+	}
+
+	return overlay, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "go/types"
+
+// sharedTypes are the types of the standard library whose pointers are
+// shared with the copy: their values are safe for concurrent use and never
+// modified once built, while their unexported state can't be copied.
+var sharedTypes = map[string]bool{
+	"regexp.Regexp":   true,
+	"time.Location":   true,
+	"log.Logger":      true,
+	"log/slog.Logger": true,
+}
+
+// sharedType returns the name of the type t points to when pointers to it are
+// shared with the copy, as those of sharedTypes or given with -share-type, and
+// whether it was given with -share-type, or "" if they aren't.
+func (a *app) sharedType(t types.Type) (name string, given bool) {
+	p, ok := t.(*types.Pointer)
+	if !ok {
+		return "", false
+	}
+	n, ok := p.Elem().(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return "", false
+	}
+
+	name = n.Obj().Pkg().Path() + "." + n.Obj().Name()
+	switch {
+	case a.shared[name]:
+		return name, true
+	case sharedTypes[name]:
+		return name, false
+	}
+
+	return "", false
+}
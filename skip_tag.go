@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// tagSkips are skip selectors addressing fields by the names in a struct tag,
+// such as json:spec.containers.securityContext, instead of their Go names.
+type tagSkips struct {
+	key   string
+	paths []string
+}
+
+type tagSkipsVal []tagSkips
+
+func (f *tagSkipsVal) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, s := range *f {
+		parts = append(parts, s.key+":"+strings.Join(s.paths, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (f *tagSkipsVal) Set(v string) error {
+	key, paths, ok := strings.Cut(v, ":")
+	if !ok || key == "" || paths == "" {
+		return errors.New("expected key:path[,path...]")
+	}
+
+	*f = append(*f, tagSkips{key: key, paths: strings.Split(paths, ",")})
+
+	return nil
+}
+
+// resolve resolves the paths to the skip selectors of the fields of t.
+func (s tagSkips) resolve(t types.Type) (skips, error) {
+	resolved := skips{}
+	for _, path := range s.paths {
+		trimmed := strings.TrimSuffix(path, zeroSuffix)
+
+		sel, err := resolveTagPath(t, s.key, trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s:%s: %v", s.key, path, err)
+		}
+
+		resolved[sel+strings.TrimPrefix(path, trimmed)] = struct{}{}
+	}
+
+	return resolved, nil
+}
+
+// resolveTagPath resolves a dot separated path of tag names to a selector.
+// Fields without a name in the tag are addressed by their Go name, and the
+// fields of embedded structs without a name are promoted, as encoding/json
+// does. Slices, maps and pointers are traversed implicitly.
+func resolveTagPath(t types.Type, key, path string) (string, error) {
+	var sel string
+	for _, name := range strings.Split(path, ".") {
+		var st *types.Struct
+		t, st, sel = traverseToStruct(t, sel)
+		if st == nil {
+			return "", fmt.Errorf("%s has no fields, looking for %q", t, name)
+		}
+
+		matches, err := findTagField(st, key, name)
+		if err != nil {
+			return "", err
+		}
+		if len(matches) == 0 {
+			return "", fmt.Errorf("%s has no field named %q", t, name)
+		}
+
+		sel, t = joinSel(sel, matches[0].sel), matches[0].typ
+	}
+
+	return sel, nil
+}
+
+// traverseToStruct follows pointers, slices and maps from t to a struct,
+// extending the selector with the slice and map elements on the way.
+func traverseToStruct(t types.Type, sel string) (types.Type, *types.Struct, string) {
+	for {
+		switch v := t.Underlying().(type) {
+		case *types.Struct:
+			return t, v, sel
+		case *types.Pointer:
+			t = v.Elem()
+		case *types.Slice:
+			t, sel = v.Elem(), joinSel(sel, "[i]")
+		case *types.Map:
+			t, sel = v.Elem(), joinSel(sel, "[k]")
+		default:
+			return t, nil, sel
+		}
+	}
+}
+
+type tagField struct {
+	sel string
+	typ types.Type
+}
+
+// findTagField finds the field of st named name in the tag key. Fields of the
+// struct itself take precedence over the promoted fields of embedded structs.
+// More than one match at the same level is an error.
+func findTagField(st *types.Struct, key, name string) ([]tagField, error) {
+	var direct, promoted []tagField
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+
+		n, _, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get(key), ",")
+		if n == "-" {
+			continue
+		}
+
+		if n == "" && field.Anonymous() {
+			if _, embedded, _ := traverseToStruct(field.Type(), ""); embedded != nil {
+				if _, ok := field.Type().Underlying().(*types.Pointer); ok || embedded == field.Type().Underlying() {
+					matches, err := findTagField(embedded, key, name)
+					if err != nil {
+						return nil, err
+					}
+					for _, m := range matches {
+						promoted = append(promoted, tagField{sel: field.Name() + "." + m.sel, typ: m.typ})
+					}
+					continue
+				}
+			}
+		}
+
+		if n == "" {
+			n = field.Name()
+		}
+		if n == name {
+			direct = append(direct, tagField{sel: field.Name(), typ: field.Type()})
+		}
+	}
+
+	matches := direct
+	if len(matches) == 0 {
+		matches = promoted
+	}
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = m.sel
+		}
+		return nil, fmt.Errorf("%s name %q is ambiguous between %s", key, name, strings.Join(names, " and "))
+	}
+
+	return matches, nil
+}
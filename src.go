@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// srcModule is the module synthesized around the file given with -src.
+const srcModule = "deepcopysrc"
+
+// readSource reads the file given with -src, or STDIN when it is -.
+func readSource(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(name)
+}
+
+// loadSource loads the package made of the single Go file src, without a
+// checkout: the file is written in a module synthesized in a temporary
+// directory, which is removed once the package is loaded. As the module has no
+// requirements, the file can only import the standard library.
+func (a *app) loadSource(src []byte) (*packages.Package, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "src.go", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, resolveError{fmt.Errorf("parsing source: %v", err)}
+	}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			return nil, resolveError{fmt.Errorf("%s is imported, but only the standard library can be imported with -src", path)}
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "deep-copy-src")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+srcModule+"\n\ngo 1.22\n"), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src.go"), src, 0o644); err != nil {
+		return nil, err
+	}
+
+	defer func(dir string) { a.dir = dir }(a.dir)
+	a.dir = dir
+
+	return a.loadPackage(".")
+}
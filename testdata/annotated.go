@@ -0,0 +1,8 @@
+package testdata
+
+type Annotated struct {
+	OnDone func()
+	raw    interface{}
+	Names  []string
+	Count  int
+}
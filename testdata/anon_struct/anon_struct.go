@@ -0,0 +1,13 @@
+package anon_struct
+
+import "github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+
+type Anonymous struct {
+	Entries []struct {
+		Item  *item.Item
+		Items []item.Item
+	}
+	ByName map[string]struct {
+		Item *item.Item
+	}
+}
@@ -0,0 +1,10 @@
+package array_map
+
+type Item struct {
+	Tags []string
+}
+
+type Grid struct {
+	Cells map[string]*[4]*Item
+	Raw   map[string]*[4]byte
+}
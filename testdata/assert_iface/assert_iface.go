@@ -0,0 +1,9 @@
+package assert_iface
+
+type Config struct {
+	Hosts []string
+}
+
+type Limits struct {
+	Values map[string]int
+}
@@ -0,0 +1,5 @@
+package cloner
+
+type Cloner[T any] interface {
+	DeepCopy() T
+}
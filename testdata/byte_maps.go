@@ -0,0 +1,7 @@
+package testdata
+
+type ByteMaps struct {
+	Blobs  map[string][]byte
+	Nested map[string]map[string][]byte
+	Pages  []map[int][]byte
+}
@@ -0,0 +1,8 @@
+package testdata
+
+type ChanPolicy struct {
+	Done    chan struct{}
+	Workers []chan int
+	ByName  map[string]chan string
+	Ptr     *chan bool
+}
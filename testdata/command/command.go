@@ -0,0 +1,17 @@
+package main
+
+type config struct {
+	Name  string
+	Flags map[string][]string
+	Next  *config
+}
+
+type Options struct {
+	Verbose bool
+	Args    []string
+	config  config
+}
+
+func main() {
+	_ = Options{}
+}
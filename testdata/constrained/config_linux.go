@@ -0,0 +1,10 @@
+package constrained
+
+// Config is only declared on linux, along with the types of its fields.
+type Config struct {
+	Mounts []Mount
+}
+
+type Mount struct {
+	Options map[string]string
+}
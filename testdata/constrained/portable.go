@@ -0,0 +1,5 @@
+package constrained
+
+type Portable struct {
+	Names []string
+}
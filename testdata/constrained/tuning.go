@@ -0,0 +1,7 @@
+//go:build linux && (amd64 || arm64)
+
+package constrained
+
+type Tuning struct {
+	Values []int
+}
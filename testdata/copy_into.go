@@ -0,0 +1,21 @@
+package testdata
+
+// KubeSpec only has a Kubernetes style DeepCopyInto method.
+type KubeSpec struct {
+	Args []string
+}
+
+func (in *KubeSpec) DeepCopyInto(out *KubeSpec) {
+	*out = *in
+	if in.Args != nil {
+		out.Args = make([]string, len(in.Args))
+		copy(out.Args, in.Args)
+	}
+}
+
+type KubeHolder struct {
+	Specs  map[string]KubeSpec
+	Spec   *KubeSpec
+	Direct KubeSpec
+	List   []KubeSpec
+}
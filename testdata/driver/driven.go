@@ -0,0 +1,7 @@
+package driven
+
+// Driven is resolved by a fake GOPACKAGESDRIVER, as in a Bazel workspace.
+type Driven struct {
+	Names []string
+	Index map[string]int
+}
@@ -0,0 +1,9 @@
+package durations
+
+import "time"
+
+type Schedule struct {
+	Intervals []time.Duration
+	Backoffs  map[string][]time.Duration
+	Steps     [][]time.Duration
+}
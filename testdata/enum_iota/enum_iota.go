@@ -0,0 +1,16 @@
+package enum_iota
+
+type Status int
+
+const (
+	Active Status = iota
+	Inactive
+)
+
+var DefaultStatus = Active
+
+type Account struct {
+	Status  Status
+	History []Status
+	ByName  map[Status]*Status
+}
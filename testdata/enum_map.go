@@ -0,0 +1,22 @@
+package testdata
+
+type Phase int
+
+const (
+	Planning Phase = iota
+	Running
+)
+
+type Level uint8
+
+const (
+	Low Level = iota
+	High
+)
+
+type EnumMap struct {
+	Durations map[Phase][]int
+	Names     map[Phase]string
+	Levels    map[string]Level
+	ByLevel   map[Level]Phase
+}
@@ -0,0 +1,12 @@
+package external_pointer
+
+import "github.com/texazcowboy/deep-copy/testdata/import_alias/item"
+
+type Holder struct {
+	Item *item.Item
+}
+
+type Wrapper struct {
+	H     *Holder
+	Names []string
+}
@@ -0,0 +1,10 @@
+package testdata
+
+type FuzzTarget struct {
+	Name   string
+	Count  int
+	Tags   []string
+	Scores map[string]float64
+	Next   *FuzzTarget
+	hidden []int
+}
@@ -0,0 +1,25 @@
+package generic
+
+// Container holds values of any type, which can't be deep copied without
+// knowing it.
+type Container[T any] struct {
+	Name   string
+	Value  T
+	List   []T
+	ByName map[string]T
+	Ptr    *T
+	Tags   []string
+}
+
+// Cloner is implemented by the values that copy themselves.
+type Cloner[T any] interface {
+	DeepCopy() T
+}
+
+// Pair holds values that copy themselves, along with the keys of any type.
+type Pair[K comparable, V Cloner[V]] struct {
+	Keys   []K
+	Value  V
+	Values []V
+	ByKey  map[K]V
+}
@@ -0,0 +1,8 @@
+package testdata
+
+// Router holds closures, which share their captured state with the copy.
+type Router struct {
+	Handler    func(int) error
+	Middleware []func(int) error
+	Routes     map[string]func(int) error
+}
@@ -0,0 +1,7 @@
+package canvas
+
+import "github.com/texazcowboy/deep-copy/testdata/iface_impl"
+
+type Canvas struct {
+	Shapes []iface_impl.Shape
+}
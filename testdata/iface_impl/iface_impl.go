@@ -0,0 +1,46 @@
+package iface_impl
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64
+	Labels []string
+}
+
+func (c Circle) Area() float64 {
+	return 3 * c.Radius * c.Radius
+}
+
+func (c Circle) DeepCopy() Circle {
+	cp := c
+	if c.Labels != nil {
+		cp.Labels = make([]string, len(c.Labels))
+		copy(cp.Labels, c.Labels)
+	}
+	return cp
+}
+
+type Square struct {
+	Side   float64
+	Points []int
+}
+
+func (s *Square) Area() float64 {
+	return s.Side * s.Side
+}
+
+type Triangle struct {
+	Base, Height float64
+}
+
+func (t Triangle) Area() float64 {
+	return t.Base * t.Height / 2
+}
+
+type Drawing struct {
+	Main   Shape
+	Layers []Shape
+	Named  map[string]Shape
+}
@@ -0,0 +1,23 @@
+package iface_map
+
+type Shape interface {
+	Area() int
+	DeepCopy() Shape
+}
+
+type Square struct {
+	Side *int
+}
+
+func (s *Square) Area() int {
+	return *s.Side * *s.Side
+}
+
+func (s *Square) DeepCopy() Shape {
+	side := *s.Side
+	return &Square{Side: &side}
+}
+
+type Drawing struct {
+	Shapes map[string]Shape
+}
@@ -0,0 +1,7 @@
+package testdata
+
+type WithCache struct {
+	Names []string
+	cache []string
+	last  *int
+}
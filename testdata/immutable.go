@@ -0,0 +1,18 @@
+package testdata
+
+type SymbolTable struct {
+	Names []string
+	Index map[string]int
+}
+
+type FrozenConfig struct {
+	Values map[string]string
+}
+
+type Compiler struct {
+	Symbols *SymbolTable
+	Config  FrozenConfig
+	History []*SymbolTable
+	ByName  map[string]*SymbolTable
+	Args    []string
+}
@@ -0,0 +1,11 @@
+package testdata
+
+type Keyer interface {
+	DeepCopy() Keyer
+}
+
+type InterfaceKeys struct {
+	M map[interface{}]string
+	K map[Keyer][]int
+	S []interface{}
+}
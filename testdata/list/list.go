@@ -0,0 +1,30 @@
+package list
+
+type Plain struct {
+	A, B int
+}
+
+type Names []string
+
+type Cloner interface {
+	DeepCopy() Cloner
+}
+
+type Holder struct {
+	C map[string]Cloner
+}
+
+func (h Holder) DeepCopy() Holder {
+	return h
+}
+
+// Limits has a hand-written copy that can fail.
+type Limits struct {
+	Values []int
+}
+
+func (l Limits) DeepCopy() (Limits, error) {
+	return l, nil
+}
+
+type plains map[string]Plain
@@ -0,0 +1,7 @@
+//go:build listextra
+
+package list
+
+type Tagged struct {
+	V *int
+}
@@ -0,0 +1,3 @@
+package list
+
+type TestOnly struct{}
@@ -0,0 +1,6 @@
+package collections
+
+type OrderedDictionary[K comparable, V any] struct {
+	Keys   []K
+	Values map[K]V
+}
@@ -0,0 +1,8 @@
+package long_lines
+
+import "github.com/texazcowboy/deep-copy/testdata/long_lines/collections"
+
+type Inventory struct {
+	Sections []collections.OrderedDictionary[string, collections.OrderedDictionary[string, []byte]]
+	Index    map[string]*collections.OrderedDictionary[string, int]
+}
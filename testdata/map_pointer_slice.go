@@ -0,0 +1,9 @@
+package testdata
+
+type MapOfSlicePointers struct {
+	M map[string]*[]int
+}
+
+type MapOfPointerSlices struct {
+	M map[string][]*Bar
+}
@@ -0,0 +1,36 @@
+package models
+
+type User struct {
+	Name  string
+	Teams []*Team
+	Prefs *prefs
+}
+
+type Team struct {
+	Name    string
+	Members []string
+}
+
+type prefs struct {
+	Tags []string
+}
+
+// NewUser returns a user with preferences, which can't be set from another
+// package.
+func NewUser(name string, tags ...string) User {
+	return User{Name: name, Prefs: &prefs{Tags: tags}}
+}
+
+func (u User) Tags() []string {
+	if u.Prefs == nil {
+		return nil
+	}
+	return u.Prefs.Tags
+}
+
+// Account keeps its sessions unexported, they can't be copied from another
+// package.
+type Account struct {
+	Owner    string
+	sessions []string
+}
@@ -0,0 +1,8 @@
+package named_array
+
+type UUID [16]byte
+
+type Record struct {
+	IDs    []UUID
+	Groups map[string][]UUID
+}
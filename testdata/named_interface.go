@@ -0,0 +1,11 @@
+package testdata
+
+type Stringer interface {
+	String() string
+}
+
+type Labeled struct {
+	Label  Stringer
+	Labels []Stringer
+	ByName map[string]*Stringer
+}
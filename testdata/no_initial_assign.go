@@ -0,0 +1,17 @@
+package testdata
+
+type Inventory struct {
+	Name   string
+	Count  int
+	Owner  *string
+	Tags   []string
+	Done   chan bool
+	Shelf  Shelf
+	Labels map[string]Shelf
+	Any    interface{}
+}
+
+type Shelf struct {
+	Row   int
+	Items []string
+}
@@ -0,0 +1,12 @@
+package pragma
+
+type Pragma struct {
+	Data []byte
+}
+
+// DeepCopy is a stub, replaced by the generated method.
+//
+//go:nosplit
+//go:noescape
+//go:norace
+func (p *Pragma) DeepCopy() Pragma
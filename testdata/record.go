@@ -0,0 +1,14 @@
+package testdata
+
+type Record struct {
+	ID        int
+	CreatedAt int64
+	Name      string
+	Tags      []string
+	Owner     RecordOwner
+}
+
+type RecordOwner struct {
+	ID    int
+	Email *string
+}
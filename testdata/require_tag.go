@@ -0,0 +1,8 @@
+package testdata
+
+type RequireTag struct {
+	Copied []int          `copy:"true"`
+	Shared []int          `json:"shared"`
+	Other  *int           `copy:"false"`
+	Map    map[string]int `json:"map" copy:"true"`
+}
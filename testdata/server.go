@@ -0,0 +1,17 @@
+package testdata
+
+type ServerConfig struct {
+	Timeout *int
+	Hosts   []string
+	TLS     ServerTLS
+	Name    *string
+}
+
+type ServerTLS struct {
+	CertFile *string
+}
+
+type Server struct {
+	ServerConfig
+	Name *string
+}
@@ -0,0 +1,15 @@
+package shadowed
+
+// newLocal declares a type of the same name as the package level one, before
+// it in the file.
+func newLocal() interface{} {
+	type Config struct {
+		Local *int
+	}
+
+	return Config{}
+}
+
+type Config struct {
+	Values []int
+}
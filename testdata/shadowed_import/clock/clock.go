@@ -0,0 +1,7 @@
+package clock
+
+import "github.com/texazcowboy/deep-copy/testdata/shadowed_import/time"
+
+type Clock struct {
+	Ticks []time.Duration
+}
@@ -0,0 +1,8 @@
+package shadowed_import
+
+import "github.com/texazcowboy/deep-copy/testdata/shadowed_import/clock"
+
+// time shares its name with the package of the durations of clock.Clock.
+type time struct {
+	C clock.Clock
+}
@@ -0,0 +1,3 @@
+package time
+
+type Duration int64
@@ -0,0 +1,14 @@
+package shared_std
+
+import (
+	"regexp"
+	"time"
+)
+
+type Router struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Patterns map[string]*regexp.Regexp
+	Zone     *time.Location
+	Tags     []string
+}
@@ -0,0 +1,15 @@
+package testdata
+
+type GlobInner struct {
+	Values        []int
+	cachedValues  []int
+	InternalState map[string]int
+}
+
+type SkipGlob struct {
+	Names         []string
+	cachedNames   []string
+	InternalIndex map[string]int
+	Inner         *GlobInner
+	Items         []GlobInner
+}
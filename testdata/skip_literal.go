@@ -0,0 +1,13 @@
+package testdata
+
+type Layer struct {
+	Files []string
+}
+
+type Image struct {
+	Layers      []*Layer
+	Tags        []string
+	Annotations map[string][]string
+	Ports       map[int]*Layer
+	Nested      [][]*Layer
+}
@@ -0,0 +1,13 @@
+package testdata
+
+type SkipNested struct {
+	Items []SkipItem
+	M     map[string]map[string][]int
+	Deep  map[string][]SkipItem
+}
+
+type SkipItem struct {
+	Name  string
+	Cache []byte
+	Tags  []string
+}
@@ -0,0 +1,31 @@
+package testdata
+
+type Pod struct {
+	Meta
+	Spec PodSpec `json:"spec"`
+}
+
+type Meta struct {
+	Labels map[string]string `json:"labels"`
+}
+
+type PodSpec struct {
+	Containers []*Container        `json:"containers"`
+	Volumes    map[string][]string `json:"volumes,omitempty"`
+	Internal   []string            `json:"-"`
+}
+
+type Container struct {
+	Name            string           `json:"name"`
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
+	Args            []string
+}
+
+type SecurityContext struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+type AmbiguousTags struct {
+	A []string `json:"a"`
+	B []string `json:"a"`
+}
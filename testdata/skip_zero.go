@@ -0,0 +1,30 @@
+package testdata
+
+type Credentials struct {
+	User     string
+	Password []byte
+}
+
+type SkipZero struct {
+	Name    string
+	Creds   *Credentials
+	Token   string
+	Secret  Credentials
+	Conns   []*Credentials
+	Handles map[string]*Credentials
+	Shared  []string
+}
+
+type SkipZeroKinds struct {
+	Name    string
+	Enabled bool
+	Count   int
+	Ratio   float64
+	Creds   *Credentials
+	Tags    []string
+	Labels  map[string]string
+	Value   interface{}
+	Events  chan int
+	Hook    func()
+	Secret  Credentials
+}
@@ -0,0 +1,6 @@
+package testdata
+
+type Node struct {
+	Name     string
+	Children []*Node
+}
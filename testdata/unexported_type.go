@@ -0,0 +1,21 @@
+package testdata
+
+type cacheShard struct {
+	entries map[string]*cacheEntry
+	stats   *shardStats
+	Owner   *Bar
+}
+
+type cacheEntry struct {
+	value []byte
+}
+
+func (e cacheEntry) deepCopy() cacheEntry {
+	cp := e
+	cp.value = append([]byte(nil), e.value...)
+	return cp
+}
+
+type shardStats struct {
+	hits []int
+}
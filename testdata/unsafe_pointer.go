@@ -0,0 +1,13 @@
+package testdata
+
+import "unsafe"
+
+type RawHandle uintptr
+
+type UnsafeFields struct {
+	Name   string
+	Data   unsafe.Pointer
+	Addr   uintptr
+	Handle RawHandle
+	Refs   []unsafe.Pointer
+}
@@ -0,0 +1,8 @@
+package app
+
+import "example.com/dep"
+
+type Service struct {
+	Config  dep.Config
+	Backups []*dep.Config
+}
@@ -0,0 +1,46 @@
+package testdata
+
+// Job is copied with -with-error: its plugins can't be deep copied.
+type Job struct {
+	Name    string
+	Plugin  Plugin
+	State   Cloner
+	Limits  *Limits
+	Retries Retries
+	Steps   []Step
+}
+
+type Plugin interface {
+	Name() string
+}
+
+// Cloner values are copied by a method that can fail.
+type Cloner interface {
+	DeepCopy() (Cloner, error)
+}
+
+// Limits has a hand-written copy that can fail.
+type Limits struct {
+	Values []int
+}
+
+func (l Limits) DeepCopy() (Limits, error) {
+	cp := l
+	cp.Values = append([]int(nil), l.Values...)
+	return cp, nil
+}
+
+// Retries has a hand-written copy that can't fail.
+type Retries struct {
+	Delays []int
+}
+
+func (r Retries) DeepCopy() Retries {
+	cp := r
+	cp.Delays = append([]int(nil), r.Delays...)
+	return cp
+}
+
+type Step struct {
+	Plugins map[string]Plugin
+}
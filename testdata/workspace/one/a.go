@@ -0,0 +1,9 @@
+package one
+
+import "example.com/two"
+
+// A embeds B of the other module of the workspace, which one doesn't require.
+type A struct {
+	two.B
+	Names []string
+}
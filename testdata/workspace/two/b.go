@@ -0,0 +1,6 @@
+package two
+
+type B struct {
+	Tags  map[string]string
+	Ports []int
+}
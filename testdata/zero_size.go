@@ -0,0 +1,8 @@
+package testdata
+
+type ZeroSize struct {
+	Marker struct{}
+	Empty  Gamma
+	Set    map[string]struct{}
+	Names  []string
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// outputUpToDate reports whether the output file is newer than all the source
+// files of its package, as make would, in which case -detect-codegen skips the
+// generation. The output file itself, when in the package, isn't a source. An
+// empty output file, as created when opening a new -o, is never up to date.
+func outputUpToDate(output string, sources []string) (bool, error) {
+	fi, err := os.Stat(output)
+	if err != nil || fi.Size() == 0 {
+		return false, nil
+	}
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range sources {
+		if abs, err := filepath.Abs(name); err == nil && abs == output {
+			continue
+		}
+
+		si, err := os.Stat(name)
+		if err != nil {
+			return false, err
+		}
+		if !si.ModTime().Before(fi.ModTime()) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
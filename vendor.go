@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// modFlag returns the -mod build flag the packages are loaded with: the one
+// given with -mod, or vendor when the module of the directory has a vendor
+// tree, so that a -mod=mod in the GOFLAGS of the environment doesn't make the
+// go command download the vendored modules. It is "" otherwise, or when
+// -buildflags already sets it.
+func (a *app) modFlag() string {
+	if a.mod != "" {
+		return a.mod
+	}
+	for _, f := range a.buildFlags {
+		if strings.HasPrefix(f, "-mod=") || strings.HasPrefix(f, "--mod=") {
+			return ""
+		}
+	}
+
+	dir, err := filepath.Abs(a.dir)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(moduleRoot(dir), "vendor", "modules.txt")); err != nil {
+		return ""
+	}
+
+	return "vendor"
+}
+
+// unvendoredPath returns the import path of a package of a vendor tree, such
+// as example.com/app/vendor/example.com/dep, without the vendor prefix, as it
+// is imported.
+func unvendoredPath(path string) string {
+	if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+		return path[i+len("/vendor/"):]
+	}
+
+	return strings.TrimPrefix(path, "vendor/")
+}